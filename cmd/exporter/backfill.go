@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/bus"
+	"github.com/re-cinq/aether/pkg/calculator"
+	"github.com/re-cinq/aether/pkg/config"
+	"github.com/re-cinq/aether/pkg/plugin"
+	"github.com/re-cinq/aether/pkg/source"
+	"github.com/re-cinq/aether/pkg/telemetry"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+)
+
+const backfillUsage = `Usage: aether backfill --from <time> [flags]
+
+Fetch the metrics of the sources between two times, and calculate and export
+the emissions of every step at the time the metrics were collected. The AWS
+and GCP sources, and the source plugins implementing FetchRange, can be
+backfilled as far as their provider keeps the metrics.
+
+The times are RFC 3339, like 2024-01-01T00:00:00Z. The sources and exporter
+plugins are read from the config file.
+
+Flags:
+`
+
+// runBackfill runs the backfill command and returns the exit code
+func runBackfill(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, backfillUsage)
+		fs.PrintDefaults()
+	}
+
+	from := fs.String("from", "", "the start of the backfill")
+	to := fs.String("to", "", "the end of the backfill, defaults to now")
+	step := fs.Duration("step", 0, "the window the metrics are averaged over, defaults to the interval of each source")
+	sources := fs.String("sources", "", "a comma separated list of the sources to backfill, like aws/eu-west-1,gcp/my-project, defaults to all")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	start, err := time.Parse(time.RFC3339, *from)
+	if err != nil {
+		fmt.Fprintf(stderr, "error invalid from: %s\n\n", err)
+		fs.Usage()
+		return 2
+	}
+
+	end := time.Now()
+	if *to != "" {
+		end, err = time.Parse(time.RFC3339, *to)
+		if err != nil {
+			fmt.Fprintf(stderr, "error invalid to: %s\n\n", err)
+			fs.Usage()
+			return 2
+		}
+	}
+
+	var names []string
+	if *sources != "" {
+		names = strings.Split(*sources, ",")
+	}
+
+	result, err := backfill(ctx, start, end, *step, names)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "backfilled %d instances\n", result.instances)
+	if result.failed > 0 {
+		fmt.Fprintf(stderr, "error the emissions of %d instances failed to publish\n", result.failed)
+		return 1
+	}
+	return 0
+}
+
+// backfillResult is what a backfill published
+type backfillResult struct {
+	// the instances the sources published
+	instances int
+
+	// the instances the calculator failed to publish the
+	// emissions of, and the emissions the exporters dropped
+	failed uint64
+}
+
+// backfillExporter is a handler of the calculated emissions
+type backfillExporter struct {
+	name   string
+	handle func(ctx context.Context, instance v1.Instance)
+}
+
+// backfill publishes the metrics of the sources between start and end on a
+// bus with the calculator and the exporter plugins, and waits until they
+// handled all of them
+func backfill(ctx context.Context, start, end time.Time, step time.Duration, names []string) (backfillResult, error) {
+	config.InitConfig(ctx)
+
+	exporters := &plugin.ExportPluginSystem{
+		Dir: config.AppConfig().Plugins.ExporterDir,
+	}
+	if err := exporters.Load(ctx); err != nil {
+		return backfillResult{}, fmt.Errorf("error loading exporter plugins: %w", err)
+	}
+
+	sources := &plugin.SourcePluginSystem{
+		Dir: config.AppConfig().Plugins.SourceDir,
+	}
+	if err := sources.Load(ctx); err != nil {
+		return backfillResult{}, fmt.Errorf("error loading source plugins: %w", err)
+	}
+
+	shutdownTelemetry, err := telemetry.Setup(ctx, &config.AppConfig().Tracing)
+	if err != nil {
+		return backfillResult{}, fmt.Errorf("error setting up telemetry: %w", err)
+	}
+	defer func() { _ = shutdownTelemetry(ctx) }()
+
+	return backfillSources(ctx, sources, []backfillExporter{{
+		name:   "plugins",
+		handle: plugin.NewHandler(ctx, exporters).SendToExporters,
+	}}, start, end, step, names)
+}
+
+// backfillSources publishes the metrics of the sources on a bus with the
+// calculator and the exporters. The queue of the calculator is drained
+// first, and then the queues of the exporters, before the bus is stopped.
+func backfillSources(ctx context.Context, sources *plugin.SourcePluginSystem, exporters []backfillExporter, start, end time.Time, step time.Duration, names []string) (backfillResult, error) {
+	middleware, err := busMiddleware()
+	if err != nil {
+		return backfillResult{}, fmt.Errorf("error setting up the bus: %w", err)
+	}
+
+	// the publishing blocks when the calculator or exporters fall behind,
+	// so no metrics are dropped
+	busConfig := config.AppConfig().Bus
+	b := bus.New(
+		bus.WithBufferSize(busConfig.BufferSize),
+		bus.WithWorkers(busConfig.Workers),
+		bus.WithMiddleware(middleware...),
+	)
+
+	calculatorHandler, err := calculator.NewHandler(ctx, b)
+	if err != nil {
+		return backfillResult{}, err
+	}
+
+	calculated := v1.MetricsCollected(b).Subscribe(
+		calculatorHandler.HandleInstance,
+		bus.WithName("calculator"),
+		bus.OnStop(calculatorHandler.Stop),
+	)
+
+	var exported []*bus.Subscription
+	for _, e := range exporters {
+		exported = append(exported, v1.EmissionsCalculated(b).Subscribe(e.handle, bus.WithName(e.name)))
+	}
+	b.Start(ctx)
+
+	manager := source.New(ctx,
+		source.WithBus(b),
+		source.WithPlugins(sources),
+	)
+
+	var result backfillResult
+	result.instances, err = manager.Backfill(ctx, start, end, step, names...)
+	errs := []error{err}
+
+	// the calculator publishes the emissions to the exporters,
+	// so its queue is drained before theirs
+	for _, sub := range append([]*bus.Subscription{calculated}, exported...) {
+		if err := sub.Wait(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("error waiting for %s: %w", sub.Name(), err))
+		}
+	}
+
+	b.Stop(ctx)
+	manager.Stop(ctx)
+
+	result.failed = calculated.Stats().Failed
+	for _, sub := range exported {
+		result.failed += sub.Stats().Dropped
+	}
+
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/config"
+	"github.com/re-cinq/aether/pkg/plugin"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// rangeSource returns an instance per step of the range
+type rangeSource struct{}
+
+func (s *rangeSource) Fetch(ctx context.Context) ([]*v1.Instance, error) {
+	return nil, nil
+}
+
+func (s *rangeSource) FetchRange(ctx context.Context, start, end time.Time, step time.Duration) ([]*v1.Instance, error) {
+	var instances []*v1.Instance
+	for at := start.Add(step); !at.After(end); at = at.Add(step) {
+		m := v1.NewMetric(v1.CPU.String())
+		m.ResourceType = v1.CPU
+		m.Usage = 50
+		m.UnitAmount = 2
+		m.Unit = v1.VCPU
+		m.UpdatedAt = at
+
+		instance := &v1.Instance{
+			Name:     "test",
+			Provider: v1.AWS,
+			Region:   "eu-west-1",
+			Kind:     "m5.large",
+			Metrics:  v1.Metrics{},
+		}
+		instance.Metrics.Upsert(m)
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+func (s *rangeSource) Stop(ctx context.Context) error {
+	return nil
+}
+
+// slowExporter records the emissions it exports, slower than the calculator
+type slowExporter struct {
+	mu       sync.Mutex
+	exported []v1.Instance
+}
+
+func (e *slowExporter) export(ctx context.Context, instance v1.Instance) {
+	time.Sleep(5 * time.Millisecond)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.exported = append(e.exported, instance)
+}
+
+// initConfig loads a config file with the defaults
+func initConfig(ctx context.Context, t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "local.yaml"), []byte("logLevel: error\n"), 0o600))
+	assert.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	config.InitConfig(ctx)
+}
+
+func TestBackfillSources(t *testing.T) {
+	ctx := context.Background()
+	initConfig(ctx, t)
+
+	sources := &plugin.SourcePluginSystem{
+		Plugins: []*plugin.RegisteredSourcePlugin{{Name: "test", Source: &rangeSource{}}},
+	}
+	exporter := &slowExporter{}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	result, err := backfillSources(ctx, sources, []backfillExporter{{
+		name:   "test",
+		handle: exporter.export,
+	}}, start, start.Add(2*time.Hour), 5*time.Minute, nil)
+	assert.NoError(t, err)
+
+	// the emissions of every step are exported, including the last ones
+	assert.Equal(t, 24, result.instances)
+	assert.Zero(t, result.failed)
+	assert.Len(t, exporter.exported, 24)
+
+	for _, instance := range exporter.exported {
+		assert.Positive(t, instance.Metrics[v1.CPU.String()].Emissions.Value)
+		assert.Positive(t, instance.EmbodiedEmissions.Value)
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+)
+
+// embeddedDataset is the dataset argument for the snapshot embedded in the binary
+const embeddedDataset = "embedded"
+
+const factorsUsage = `Usage: aether factors <command> [flags] [arguments]
+
+Inspect and validate the emission factor datasets.
+
+Commands:
+  providers                   list the providers with their number of regions and machine types
+  regions [provider]          list the regions with their annual grid intensity
+  machine <provider> <kind>   print the emission factors of a machine type
+  validate <path>             check a dataset directory or tarball for problems
+  diff <old> <new>            print the changes between two dataset directories or tarballs
+
+The datasets are laid out like the data directory of the emissions-data repo,
+use "embedded" as path for the snapshot embedded in the binary.
+
+Flags:
+`
+
+// runFactors runs the factors command and returns the exit code
+func runFactors(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("factors", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, factorsUsage)
+		fs.PrintDefaults()
+	}
+
+	path := fs.String("path", "", "the dataset directory or tarball, defaults to the embedded snapshot")
+	overrides := fs.String("overrides", "", "a directory with datasets merged on top of the datasets")
+
+	if len(args) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	command := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	// the extracted datasets are only used by this command
+	defer func() { _ = factors.RemoveFactorsData() }()
+
+	var err error
+	switch command {
+	case "providers":
+		err = listProviders(ctx, stdout, *path, *overrides)
+	case "regions":
+		err = listRegions(ctx, stdout, *path, *overrides, fs.Arg(0))
+	case "machine":
+		if fs.NArg() != 2 {
+			fs.Usage()
+			return 2
+		}
+		err = printMachine(ctx, stdout, *path, *overrides, v1.Provider(fs.Arg(0)), fs.Arg(1))
+	case "validate":
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return 2
+		}
+		err = validateDataset(ctx, stdout, fs.Arg(0), *overrides)
+	case "diff":
+		if fs.NArg() != 2 {
+			fs.Usage()
+			return 2
+		}
+		err = diffDatasets(ctx, stdout, fs.Arg(0), fs.Arg(1), *overrides)
+	case "help", "-h", "--help":
+		fs.Usage()
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command: %s\n\n", command)
+		fs.Usage()
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// loadDataset reads the dataset from the directory or tarball and merges
+// the overrides on top of it
+func loadDataset(ctx context.Context, path, overrides string) (*factors.Dataset, error) {
+	if path == embeddedDataset {
+		path = ""
+	}
+
+	dir, err := factors.LoadFactorsData(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := factors.LoadDataset(dir.Version, dir.DataPath, dir.InstanceDataPath)
+	if err != nil || overrides == "" {
+		return d, err
+	}
+
+	return d, d.ApplyOverrides(ctx, overrides)
+}
+
+// sortedProviders returns the providers of the dataset sorted by name
+func sortedProviders(d *factors.Dataset) []v1.Provider {
+	providers := make([]v1.Provider, 0, len(d.Providers))
+	for p := range d.Providers {
+		providers = append(providers, p)
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i] < providers[j] })
+	return providers
+}
+
+func listProviders(ctx context.Context, out io.Writer, path, overrides string) error {
+	d, err := loadDataset(ctx, path, overrides)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Version: %s\n\n", d.Version)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tREGIONS\tMACHINE TYPES\tINSTANCE DATA")
+	for _, p := range sortedProviders(d) {
+		ef := d.Providers[p]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%t\n", p, len(ef.Coefficient), len(ef.Machines), d.Instances[p] != nil)
+	}
+	return w.Flush()
+}
+
+func listRegions(ctx context.Context, out io.Writer, path, overrides, provider string) error {
+	d, err := loadDataset(ctx, path, overrides)
+	if err != nil {
+		return err
+	}
+
+	providers := sortedProviders(d)
+	if provider != "" {
+		if _, ok := d.Providers[v1.Provider(provider)]; !ok {
+			return fmt.Errorf("error provider not found in dataset: %s", provider)
+		}
+		providers = []v1.Provider{v1.Provider(provider)}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tREGION\tGRID INTENSITY (gCO2eq/kWh)")
+	for _, p := range providers {
+		coefficients := d.Providers[p].Coefficient
+
+		regions := make([]string, 0, len(coefficients))
+		for r := range coefficients {
+			regions = append(regions, r)
+		}
+		sort.Strings(regions)
+
+		for _, r := range regions {
+			// the datasets are in metric tonnes
+			fmt.Fprintf(w, "%s\t%s\t%.1f\n", p, r, coefficients[r]*1000*1000)
+		}
+	}
+	return w.Flush()
+}
+
+func printMachine(ctx context.Context, out io.Writer, path, overrides string, provider v1.Provider, kind string) error {
+	d, err := loadDataset(ctx, path, overrides)
+	if err != nil {
+		return err
+	}
+
+	ef, ok := d.Providers[provider]
+	if !ok {
+		return fmt.Errorf("error provider not found in dataset: %s", provider)
+	}
+
+	m, ok := ef.Machines[kind]
+	if !ok {
+		return fmt.Errorf("error machine type not found for %s: %s", provider, kind)
+	}
+
+	fmt.Fprintf(out, "Provider:          %s\n", provider)
+	fmt.Fprintf(out, "Machine Type:      %s\n", kind)
+	fmt.Fprintf(out, "Version:           %s\n", d.Version)
+	fmt.Fprintf(out, "Architecture:      %s\n", m.Platform.Architecture)
+	fmt.Fprintf(out, "vCPU:              %d of %d\n", m.VCPU, m.Platform.VCPU)
+	fmt.Fprintf(out, "Memory (GB):       %g of %g\n", m.MemoryGB, m.Platform.MemoryGB)
+	fmt.Fprintf(out, "GPUs:              %d of %d\n", m.GPUCount, m.Platform.GPUCount)
+	fmt.Fprintf(out, "Embodied (kgCO2e): %g (CPU %g, Memory %g, Storage %g, GPU %g)\n",
+		m.Platform.TotalScope3, m.Platform.CPUScope3, m.Platform.MemoryScope3,
+		m.Platform.StorageScope3, m.Platform.GPUScope3)
+	fmt.Fprintf(out, "Min / Max Watts:   %g / %g\n", m.MinWatts, m.MaxWatts)
+	fmt.Fprintf(out, "PkgWatt:           %s\n", orDash(factors.FormatCurve(m.PkgWatt)))
+	fmt.Fprintf(out, "RAMWatt:           %s\n", orDash(factors.FormatCurve(m.RAMWatt)))
+	fmt.Fprintf(out, "GPUWatt:           %s\n", orDash(factors.FormatCurve(m.GPUWatt)))
+
+	if !m.HasWattageCurve() {
+		fmt.Fprintln(out, "\nThe machine type has no CPU wattage curve, the min and max wattage are used.")
+	}
+	return nil
+}
+
+func validateDataset(ctx context.Context, out io.Writer, path, overrides string) error {
+	d, err := loadDataset(ctx, path, overrides)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Validate(); err != nil {
+		return err
+	}
+
+	problems := d.Check()
+	for _, p := range problems {
+		fmt.Fprintln(out, p)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("error dataset %s has %d problems", d.Version, len(problems))
+	}
+
+	fmt.Fprintf(out, "dataset %s is valid\n", d.Version)
+	return nil
+}
+
+func diffDatasets(ctx context.Context, out io.Writer, from, to, overrides string) error {
+	// the datasets are read into memory, so the second
+	// dataset can be extracted to the same directory
+	old, err := loadDataset(ctx, from, overrides)
+	if err != nil {
+		return fmt.Errorf("error loading %s: %w", from, err)
+	}
+
+	updated, err := loadDataset(ctx, to, overrides)
+	if err != nil {
+		return fmt.Errorf("error loading %s: %w", to, err)
+	}
+
+	changes := factors.Diff(old, updated)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tDATASET\tENTRY\tFIELD\tOLD\tNEW")
+	for _, c := range changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.Provider, c.Dataset, orDash(c.Entry), orDash(c.Field), orDash(c.Old), orDash(c.New))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\n%d changes from %s to %s\n", len(changes), old.Version, updated.Version)
+	return nil
+}
+
+// orDash returns a dash for empty values, so the columns stay aligned
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"runtime"
@@ -19,7 +20,12 @@ import (
 	"github.com/re-cinq/aether/pkg/log"
 	"github.com/re-cinq/aether/pkg/plugin"
 	"github.com/re-cinq/aether/pkg/source"
+	"github.com/re-cinq/aether/pkg/telemetry"
+	"github.com/re-cinq/aether/pkg/transport"
 	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"github.com/re-cinq/aether/pkg/wal"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
 )
 
 const shutdownTTL = time.Second * 15
@@ -68,78 +74,192 @@ func main() {
 		return
 	}
 
+	// Inspect and validate the emission factor datasets and exit
+	if len(args) > 1 && args[1] == "factors" {
+		// only log the overrides that fail
+		setLogLevel(lvl, "warn")
+		os.Exit(runFactors(ctx, args[2:], os.Stdout, os.Stderr))
+	}
+
+	// Backfill the emissions of a past time range and exit
+	if len(args) > 1 && args[1] == "backfill" {
+		os.Exit(runBackfill(ctx, args[2:], os.Stdout, os.Stderr))
+	}
+
 	// At this point load the config
 	config.InitConfig(ctx)
 
 	setLogLevel(lvl, config.AppConfig().LogLevel)
 
+	// the metrics and spans of the bus handlers
+	shutdownTelemetry, err := telemetry.Setup(ctx, &config.AppConfig().Tracing)
+	if err != nil {
+		logger.Error("failed to setup telemetry", "error", err)
+		os.Exit(1)
+	}
+
+	// the role of the node, a node runs the sources, the calculator
+	// and exporters, or both
+	clusterConfig := config.AppConfig().Cluster
+	role, err := transport.ParseRole(clusterConfig.Role)
+	if err != nil {
+		logger.Error("failed to setup the node", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("starting node", "role", role)
+
 	// Load exporter plugins
-	logger.Info("loading exporters", "dir", config.AppConfig().Plugins.ExporterDir)
 	pluginsystem := &plugin.ExportPluginSystem{
 		Dir: config.AppConfig().Plugins.ExporterDir,
 	}
 
-	err := pluginsystem.Load(ctx)
-	if err != nil {
-		logger.Error("failed to load exporter plugin system", "error", err)
-		os.Exit(1)
+	if role.Calculates() {
+		logger.Info("loading exporters", "dir", pluginsystem.Dir)
+		err = pluginsystem.Load(ctx)
+		if err != nil {
+			logger.Error("failed to load exporter plugin system", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("finished loading exporters", "output",
+			fmt.Sprintf("%d loaded", len(pluginsystem.Plugins)))
 	}
-	logger.Info("finished loading exporters", "output",
-		fmt.Sprintf("%d loaded", len(pluginsystem.Plugins)))
 
 	// Load source plugins
-	logger.Info("loading sources", "dir", config.AppConfig().Plugins.SourceDir)
 	sourcePluginSystem := &plugin.SourcePluginSystem{
 		Dir: config.AppConfig().Plugins.SourceDir,
 	}
 
-	err = sourcePluginSystem.Load(ctx)
+	if role.Collects() {
+		logger.Info("loading sources", "dir", sourcePluginSystem.Dir)
+		err = sourcePluginSystem.Load(ctx)
+		if err != nil {
+			logger.Error("failed to load exporter plugin system", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("finished loading sources", "output",
+			fmt.Sprintf("%d loaded", len(sourcePluginSystem.Plugins)))
+	}
+
+	// Init the application bus
+	busConfig := config.AppConfig().Bus
+	overflow, err := bus.ParseOverflowPolicy(busConfig.Overflow)
 	if err != nil {
-		logger.Error("failed to load exporter plugin system", "error", err)
+		logger.Error("failed to setup the bus", "error", err)
 		os.Exit(1)
 	}
-	logger.Info("finished loading sources", "output",
-		fmt.Sprintf("%d loaded", len(sourcePluginSystem.Plugins)))
+	// the collected metrics are written to the log, so the metrics
+	// of a scrape are calculated again after a restart
+	var eventLog *wal.Log
+	if busConfig.Log.Enabled {
+		eventLog, err = wal.Open(busConfig.Log.Path,
+			wal.WithSegmentSize(busConfig.Log.SegmentSize<<20),
+			wal.WithRetention(busConfig.Log.Retention),
+			wal.WithSyncInterval(busConfig.Log.SyncInterval),
+		)
+		if err != nil {
+			logger.Error("failed to open the bus log", "path", busConfig.Log.Path, "error", err)
+			os.Exit(1)
+		}
+	}
 
-	// Init the application bus
-	b := bus.New()
+	middleware, err := busMiddleware()
+	if err != nil {
+		logger.Error("failed to setup the bus", "error", err)
+		os.Exit(1)
+	}
 
-	// Subscribe to the metrics collections
-	b.Subscribe(
-		v1.MetricsCollectedEvent,
-		calculator.NewHandler(ctx, b),
+	b := bus.New(
+		bus.WithBufferSize(busConfig.BufferSize),
+		bus.WithWorkers(busConfig.Workers),
+		bus.WithOverflowPolicy(overflow),
+		bus.WithLog(eventLog, v1.MetricsCollectedEvent),
+		bus.WithMiddleware(middleware...),
 	)
 
-	// Subscribe to update the prometheus exporter
-	b.Subscribe(
-		v1.EmissionsCalculatedEvent,
-		exporter.NewHandler(ctx, b),
-	)
+	if role.Calculates() {
+		if err := subscribeCalculator(ctx, b, pluginsystem); err != nil {
+			logger.Error("failed to setup the emissions calculator", "error", err)
+			os.Exit(1)
+		}
+	}
 
-	// subscribes all exporter plugins
-	b.Subscribe(
-		v1.EmissionsCalculatedEvent,
-		plugin.NewHandler(ctx, pluginsystem),
-	)
+	// a collector node forwards the collected metrics to the calculator
+	// node, an event is handled once the calculator node published it
+	if role == transport.RoleCollector {
+		if clusterConfig.Calculator == "" {
+			logger.Error("failed to setup the transport, the calculator node is not set in cluster.calculator")
+			os.Exit(1)
+		}
+
+		creds, err := transport.ClientCredentials(transportTLS(ctx, clusterConfig.TLS))
+		if err != nil {
+			logger.Error("failed to setup the transport", "error", err)
+			os.Exit(1)
+		}
+
+		forwarder, err := transport.Dial(clusterConfig.Calculator, creds)
+		if err != nil {
+			logger.Error("failed to setup the transport", "error", err)
+			os.Exit(1)
+		}
+
+		// the topic decodes the events redelivered from the log
+		b.Subscribe(v1.MetricsCollected(b).Type(), forwarder, bus.WithName("transport"))
+		logger.Info("forwarding metrics", "calculator", clusterConfig.Calculator)
+	}
 
 	// Start the bus
 	b.Start(ctx)
 	logger.Info("bus started")
 
+	// Source manager
+	var sourceManager *source.Manager
+	if role.Collects() {
+		sourceManager = source.New(ctx,
+			source.WithBus(b),
+			source.WithPlugins(sourcePluginSystem),
+		)
+
+		sourceManager.Start(ctx)
+		logger.Info("sources loaded")
+	}
+
 	// Create the API object
 	server := api.New(
+		api.WithBus(b),
 		api.WithExportPluginSystem(pluginsystem),
 		api.WithSourcePluginSystem(sourcePluginSystem),
+		api.WithSourceManager(sourceManager),
 	)
 
-	// Source manager
-	sourceManager := source.New(ctx,
-		source.WithBus(b),
-		source.WithPlugins(sourcePluginSystem),
-	)
-
-	sourceManager.Start(ctx)
-	logger.Info("sources loaded")
+	// a calculator node receives the collected metrics of the collector
+	// nodes, and publishes them on its bus
+	var transportServer *transport.Server
+	if role == transport.RoleCalculator {
+		if clusterConfig.Address == "" {
+			logger.Error("failed to setup the transport, the address is not set in cluster.address")
+			os.Exit(1)
+		}
+
+		creds, err := transport.ServerCredentials(transportTLS(ctx, clusterConfig.TLS))
+		if err != nil {
+			logger.Error("failed to setup the transport", "error", err)
+			os.Exit(1)
+		}
+
+		lis, err := net.Listen("tcp", clusterConfig.Address)
+		if err != nil {
+			logger.Error("failed to setup the transport", "address", clusterConfig.Address, "error", err)
+			os.Exit(1)
+		}
+
+		transportServer = transport.NewServer(b, v1.MetricsCollectedEvent)
+		go func() {
+			if err := transportServer.Serve(ctx, lis, grpc.Creds(creds)); err != nil {
+				logger.Error("transport server stopped", "error", err)
+			}
+		}()
+	}
 
 	// Start the API
 	go server.Start(ctx)
@@ -159,14 +279,99 @@ func main() {
 		// Shutdown the API server
 		server.Stop(cancelCtx)
 
+		// stop receiving the metrics of the collector nodes
+		if transportServer != nil {
+			transportServer.Stop(cancelCtx)
+		}
+
 		// deregister sources
-		sourceManager.Stop(ctx)
+		if sourceManager != nil {
+			sourceManager.Stop(cancelCtx)
+		}
 
 		// Shutdown the bus
-		b.Stop(ctx)
+		b.Stop(cancelCtx)
+
+		// the log is closed once all events are handled
+		if eventLog != nil {
+			if err := eventLog.Close(); err != nil {
+				logger.Error("failed to close the bus log", "error", err)
+			}
+		}
+
+		// export the spans of the last events
+		if err := shutdownTelemetry(cancelCtx); err != nil {
+			logger.Error("failed to shutdown telemetry", "error", err)
+		}
 	})
 }
 
+// transportTLS returns the certificates of the connections between the
+// nodes, sending the metrics in plain text is logged as a warning
+func transportTLS(ctx context.Context, cfg config.ClusterTLSConfig) transport.TLSConfig {
+	if cfg.Insecure {
+		log.FromContext(ctx).Warn("the metrics are sent between the nodes without TLS")
+	}
+
+	return transport.TLSConfig{
+		Insecure:   cfg.Insecure,
+		CertFile:   cfg.CertFile,
+		KeyFile:    cfg.KeyFile,
+		CAFile:     cfg.CAFile,
+		ServerName: cfg.ServerName,
+	}
+}
+
+// busMiddleware returns the middleware of the bus handlers. The spans and
+// logs of the handlers have the event IDs, and their panics are recovered
+// inside, so they are traced as errors and their duration is recorded.
+func busMiddleware() ([]bus.Middleware, error) {
+	metrics, err := bus.Metrics(otel.Meter("aether/bus"))
+	if err != nil {
+		return nil, err
+	}
+
+	return []bus.Middleware{
+		bus.Tracing(otel.Tracer("aether/bus")),
+		metrics,
+		bus.Logging(),
+		bus.Recover(),
+	}, nil
+}
+
+// subscribeCalculator subscribes the calculator to the collected metrics,
+// and the exporters to the calculated emissions
+func subscribeCalculator(ctx context.Context, b *bus.Bus, pluginsystem *plugin.ExportPluginSystem) error {
+	// Setup the calculator, which reads the emission factors
+	calculatorHandler, err := calculator.NewHandler(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	// Subscribe to the metrics collections
+	v1.MetricsCollected(b).Subscribe(
+		calculatorHandler.HandleInstance,
+		bus.WithName("calculator"),
+		bus.OnStop(calculatorHandler.Stop),
+	)
+
+	// Subscribe to update the prometheus exporter
+	v1.EmissionsCalculated(b).Subscribe(
+		exporter.NewHandler(ctx, b).HandleInstance,
+		bus.WithName("prometheus"),
+	)
+
+	// subscribes all exporter plugins, a degraded plugin drops its oldest
+	// events instead of blocking the calculator
+	v1.EmissionsCalculated(b).Subscribe(
+		plugin.NewHandler(ctx, pluginsystem).SendToExporters,
+		bus.WithName("plugins"),
+		bus.WithOverflow(bus.DropOldest),
+	)
+
+	return nil
+}
+
 // await for the signals and run the shutdown function
 func await(ctx context.Context, shutdown func()) {
 	logger := log.FromContext(ctx)
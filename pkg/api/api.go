@@ -11,9 +11,11 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/version"
+	"github.com/re-cinq/aether/pkg/bus"
 	"github.com/re-cinq/aether/pkg/config"
 	"github.com/re-cinq/aether/pkg/log"
 	"github.com/re-cinq/aether/pkg/plugin"
+	"github.com/re-cinq/aether/pkg/source"
 )
 
 const readHeaderTimeout = 2 * time.Second
@@ -28,6 +30,12 @@ type API struct {
 	// plugin systems
 	exporters *plugin.ExportPluginSystem
 	sources   *plugin.SourcePluginSystem
+
+	// the application bus
+	bus *bus.Bus
+
+	// the manager scraping the sources
+	manager *source.Manager
 }
 
 type option func(*API)
@@ -38,6 +46,20 @@ func WithExportPluginSystem(e *plugin.ExportPluginSystem) option {
 	}
 }
 
+// WithBus exposes the statistics of the bus
+func WithBus(b *bus.Bus) option {
+	return func(a *API) {
+		a.bus = b
+	}
+}
+
+// WithSourceManager exposes the health of the sources
+func WithSourceManager(m *source.Manager) option {
+	return func(a *API) {
+		a.manager = m
+	}
+}
+
 func WithSourcePluginSystem(e *plugin.SourcePluginSystem) option {
 	return func(a *API) {
 		a.sources = e
@@ -75,6 +97,18 @@ func (a *API) router() *mux.Router {
 	prometheus.MustRegister(version.NewCollector("cloud_carbon_exporter"))
 	r.Handle(a.metricsPath, promhttp.Handler()).Methods("GET")
 
+	// Bus statistics
+	if a.bus != nil {
+		prometheus.MustRegister(&busCollector{bus: a.bus})
+		r.HandleFunc("/bus", a.busStats).Methods("GET")
+		r.HandleFunc("/bus/replay", a.busReplay).Methods("POST")
+	}
+
+	// Source health
+	if a.manager != nil {
+		prometheus.MustRegister(&sourceCollector{sources: a.manager})
+	}
+
 	return r
 }
 
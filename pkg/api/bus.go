@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/re-cinq/aether/pkg/bus"
+	"github.com/re-cinq/aether/pkg/log"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+)
+
+var (
+	busPublishedDesc = prometheus.NewDesc(
+		"aether_bus_events_published_total",
+		"The events published on the bus",
+		[]string{"event"}, nil,
+	)
+	busDroppedDesc = prometheus.NewDesc(
+		"aether_bus_events_dropped_total",
+		"The events dropped because the bus queue was full",
+		[]string{"event"}, nil,
+	)
+	busQueuedDesc = prometheus.NewDesc(
+		"aether_bus_events_queued",
+		"The events in the bus queues",
+		[]string{"event"}, nil,
+	)
+	handlerHandledDesc = prometheus.NewDesc(
+		"aether_bus_handler_events_total",
+		"The events handled by a bus handler",
+		[]string{"event", "handler"}, nil,
+	)
+	handlerDroppedDesc = prometheus.NewDesc(
+		"aether_bus_handler_dropped_total",
+		"The events dropped because the queue of a bus handler was full",
+		[]string{"event", "handler"}, nil,
+	)
+	handlerPanicsDesc = prometheus.NewDesc(
+		"aether_bus_handler_panics_total",
+		"The panics recovered from a bus handler",
+		[]string{"event", "handler"}, nil,
+	)
+	handlerFailedDesc = prometheus.NewDesc(
+		"aether_bus_handler_failed_total",
+		"The events a bus handler failed to pass on",
+		[]string{"event", "handler"}, nil,
+	)
+	handlerQueuedDesc = prometheus.NewDesc(
+		"aether_bus_handler_queued",
+		"The events in the queue of a bus handler",
+		[]string{"event", "handler"}, nil,
+	)
+	handlerDurationDesc = prometheus.NewDesc(
+		"aether_bus_handler_duration_seconds",
+		"How long a bus handler took to handle an event",
+		[]string{"event", "handler"}, nil,
+	)
+)
+
+// busCollector exposes the statistics of the bus as prometheus metrics
+type busCollector struct {
+	bus *bus.Bus
+}
+
+func (c *busCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- busPublishedDesc
+	ch <- busDroppedDesc
+	ch <- busQueuedDesc
+	ch <- handlerHandledDesc
+	ch <- handlerDroppedDesc
+	ch <- handlerPanicsDesc
+	ch <- handlerFailedDesc
+	ch <- handlerQueuedDesc
+	ch <- handlerDurationDesc
+}
+
+func (c *busCollector) Collect(ch chan<- prometheus.Metric) {
+	for t, s := range c.bus.Stats() {
+		event := v1.EventName(t)
+		ch <- prometheus.MustNewConstMetric(busPublishedDesc, prometheus.CounterValue, float64(s.Published), event)
+		ch <- prometheus.MustNewConstMetric(busDroppedDesc, prometheus.CounterValue, float64(s.Dropped), event)
+		ch <- prometheus.MustNewConstMetric(busQueuedDesc, prometheus.GaugeValue, float64(s.Queued), event)
+	}
+
+	for _, s := range c.bus.SubscriberStats() {
+		labels := []string{v1.EventName(s.Event), s.Name}
+		ch <- prometheus.MustNewConstMetric(handlerHandledDesc, prometheus.CounterValue, float64(s.Handled), labels...)
+		ch <- prometheus.MustNewConstMetric(handlerDroppedDesc, prometheus.CounterValue, float64(s.Dropped), labels...)
+		ch <- prometheus.MustNewConstMetric(handlerPanicsDesc, prometheus.CounterValue, float64(s.Panics), labels...)
+		ch <- prometheus.MustNewConstMetric(handlerFailedDesc, prometheus.CounterValue, float64(s.Failed), labels...)
+		ch <- prometheus.MustNewConstMetric(handlerQueuedDesc, prometheus.GaugeValue, float64(s.Queued), labels...)
+
+		buckets := make(map[float64]uint64, len(s.Latency.Buckets))
+		for i, b := range s.Latency.Buckets {
+			buckets[b] = s.Latency.Counts[i]
+		}
+		ch <- prometheus.MustNewConstHistogram(handlerDurationDesc, s.Latency.Count, s.Latency.Sum, buckets, labels...)
+	}
+}
+
+// busStatistics are the statistics of the bus returned by the API
+type busStatistics struct {
+	Events   map[string]bus.Stats `json:"events"`
+	Handlers []handlerStatistics  `json:"handlers"`
+}
+
+type handlerStatistics struct {
+	bus.SubscriberStats
+	Event string `json:"event"`
+}
+
+// busStats returns the statistics of the bus per event type and handler
+func (a *API) busStats(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	stats := busStatistics{Events: make(map[string]bus.Stats)}
+	for t, s := range a.bus.Stats() {
+		stats.Events[v1.EventName(t)] = s
+	}
+	for _, s := range a.bus.SubscriberStats() {
+		stats.Handlers = append(stats.Handlers, handlerStatistics{
+			SubscriberStats: s,
+			Event:           v1.EventName(s.Event),
+		})
+	}
+
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// replayResult is the result of a replay returned by the API
+type replayResult struct {
+	Events int `json:"events"`
+}
+
+// busReplay replays the events in the log of the bus written between the
+// from and to query parameters, which are RFC 3339 timestamps. The end
+// defaults to now.
+func (a *API) busReplay(w http.ResponseWriter, req *http.Request) {
+	from, err := time.Parse(time.RFC3339, req.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "error invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now()
+	if v := req.URL.Query().Get("to"); v != "" {
+		to, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "error invalid to: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx := req.Context()
+	count, err := a.bus.Replay(ctx, from, to)
+	if errors.Is(err, bus.ErrNoLog) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.FromContext(ctx).Error("error replaying events", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(replayResult{Events: count}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
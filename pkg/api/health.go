@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/re-cinq/aether/pkg/source"
 )
 
 type health struct {
@@ -11,6 +13,10 @@ type health struct {
 	Exporters     string   `json:"exporters"`
 	Sources       string   `json:"sources"`
 	FailedPlugins []string `json:"failedPlugins,omitempty"`
+
+	// the health of every source, sources paused by the
+	// circuit breaker make the sources degraded
+	SourceStatus []source.Status `json:"sourceStatus,omitempty"`
 }
 
 // Return a 200 http status
@@ -41,6 +47,15 @@ func (a *API) healthProbe(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	if a.manager != nil {
+		h.SourceStatus = a.manager.Status()
+		for _, s := range h.SourceStatus {
+			if s.Paused() && h.Sources == "up" {
+				h.Sources = "degraded"
+			}
+		}
+	}
+
 	body, err := json.Marshal(h)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/re-cinq/aether/pkg/source"
+)
+
+var (
+	sourceScrapesDesc = prometheus.NewDesc(
+		"aether_source_scrapes_total",
+		"The scrapes of a source, including the failed ones",
+		[]string{"source"}, nil,
+	)
+	sourceFailuresDesc = prometheus.NewDesc(
+		"aether_source_failures_total",
+		"The failed scrapes of a source",
+		[]string{"source"}, nil,
+	)
+	sourceConsecutiveFailuresDesc = prometheus.NewDesc(
+		"aether_source_consecutive_failures",
+		"The failed scrapes of a source since its last success",
+		[]string{"source"}, nil,
+	)
+	sourceLastSuccessDesc = prometheus.NewDesc(
+		"aether_source_last_success_timestamp_seconds",
+		"When a source was scraped successfully the last time",
+		[]string{"source"}, nil,
+	)
+	sourceUpDesc = prometheus.NewDesc(
+		"aether_source_up",
+		"Whether a source is scraped, 0 when its scrapes are paused by the circuit breaker",
+		[]string{"source"}, nil,
+	)
+)
+
+// sourceCollector exposes the health of the sources as prometheus metrics
+type sourceCollector struct {
+	sources *source.Manager
+}
+
+func (c *sourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sourceScrapesDesc
+	ch <- sourceFailuresDesc
+	ch <- sourceConsecutiveFailuresDesc
+	ch <- sourceLastSuccessDesc
+	ch <- sourceUpDesc
+}
+
+func (c *sourceCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.sources.Status() {
+		up := 1.0
+		if s.Paused() {
+			up = 0
+		}
+
+		var lastSuccess float64
+		if !s.LastSuccess.IsZero() {
+			lastSuccess = float64(s.LastSuccess.Unix())
+		}
+
+		ch <- prometheus.MustNewConstMetric(sourceScrapesDesc, prometheus.CounterValue, float64(s.Scrapes), s.Name)
+		ch <- prometheus.MustNewConstMetric(sourceFailuresDesc, prometheus.CounterValue, float64(s.Failures), s.Name)
+		ch <- prometheus.MustNewConstMetric(sourceConsecutiveFailuresDesc, prometheus.GaugeValue, float64(s.ConsecutiveFailures), s.Name)
+		ch <- prometheus.MustNewConstMetric(sourceLastSuccessDesc, prometheus.GaugeValue, lastSuccess, s.Name)
+		ch <- prometheus.MustNewConstMetric(sourceUpDesc, prometheus.GaugeValue, up, s.Name)
+	}
+}
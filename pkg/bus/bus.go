@@ -3,44 +3,141 @@ package bus
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/log"
+	"github.com/re-cinq/aether/pkg/wal"
 )
 
 var (
-	// This is the default buffer size of the queue
+	// This is the default buffer size of the queue of each subscriber
 	defaultbuffer = 512
 
-	// the default amount of workers that the bus will start with
+	// the default amount of workers each subscriber is started with
 	defaultworkers = 10
+
+	// how often Stop checks whether the queues are drained
+	drainInterval = 10 * time.Millisecond
+)
+
+var (
+	// ErrStopped is returned when publishing on a bus that has shutdown
+	ErrStopped = errors.New("bus has shutdown")
+
+	// ErrQueueFull is returned by the Error overflow policy
+	// when the queue of a subscriber is full
+	ErrQueueFull = errors.New("bus queue is full")
 )
 
 // EventType is the identifier for the type of event you are dealing with
 type EventType int
 
-// Bus is the main structure used to keep track of all subscribers
+// OverflowPolicy defines what Publish does when the queue of a subscriber
+// is full
+type OverflowPolicy int
+
+const (
+	// Block waits until there is space in the queue, the context
+	// is done or the bus is stopped
+	Block OverflowPolicy = iota
+
+	// DropNewest drops the event that is published
+	DropNewest
+
+	// DropOldest drops the oldest event in the queue
+	// to make space for the event that is published
+	DropOldest
+
+	// Error returns ErrQueueFull to the publisher
+	Error
+)
+
+var overflowPolicies = map[OverflowPolicy]string{
+	Block:      "block",
+	DropNewest: "drop-newest",
+	DropOldest: "drop-oldest",
+	Error:      "error",
+}
+
+func (p OverflowPolicy) String() string {
+	return overflowPolicies[p]
+}
+
+// ParseOverflowPolicy returns the overflow policy by name:
+// block, drop-newest, drop-oldest or error
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	for p, name := range overflowPolicies {
+		if name == s {
+			return p, nil
+		}
+	}
+	return Block, fmt.Errorf("error overflow policy not supported: %s", s)
+}
+
+// Bus is the main structure used to keep track of all subscribers. Each
+// subscriber has its own queue and workers, so a slow or hanging handler
+// only delays its own events.
 type Bus struct {
 	// holds event subscribers
-	subs map[EventType][]EventHandler
+	subs map[EventType][]*subscription
+
+	// the defaults of the subscribers
+	bufferSize int
+	workers    int
+	policy     OverflowPolicy
 
-	// The queue
-	queue chan *Event
+	// wraps the handlers of all subscribers
+	middleware []Middleware
 
-	// amount of workers to start
-	workers int
+	// the context the workers are started with, set on Start
+	ctx     context.Context
+	started bool
 
-	// termination signal
+	// termination signal, new events are rejected on shutdown and done
+	// is closed once the queues are drained, to release the publishers
+	// waiting for a queue
 	shutdown bool
+	done     chan struct{}
+
+	// the events queued or being handled by the subscribers,
+	// Stop waits for them to be handled
+	pending atomic.Int64
+
+	// the subscriptions per handler, a handler is
+	// stopped once its last subscription ends
+	handlers map[interface{}]int
+
+	// the counters per event type
+	stats   map[EventType]*counters
+	statsMu sync.Mutex
+
+	// the write-ahead log of the logged event types, the events
+	// before the recovered offset are redelivered on Start
+	log       *wal.Log
+	logged    map[EventType]bool
+	recovered uint64
+
+	// how the data of the event types is read from the log
+	decoders   map[EventType]decoder
+	decodersMu sync.RWMutex
 
 	// syncing functionality
-	wg sync.WaitGroup
 	mu sync.RWMutex
 }
 
 // Event is the main type that gets sent thr9ough the bus
-// it is up to the callers to validate the data when received
+// it is up to the callers to validate the data when received,
+// which a Topic does for them
 type Event struct {
-	Type EventType
-	Data interface{}
+	Type     EventType
+	Data     interface{}
+	Metadata Metadata
+
+	// acknowledges the event in the log
+	ack *acker
 }
 
 // EventHandler is an interface that callers can use
@@ -49,28 +146,42 @@ type EventHandler interface {
 	// This function will be called when an event is received
 	// that the handler is subscribed to
 	Handle(ctx context.Context, e *Event)
-	// On Shutfown of the bus all Stop functyions will be called
-	// NOTE: Stop should be idempotent as it will be called once per
-	// subscribed event. so if a handler is subscribed to more than one event it
-	// will be called multiple times
+	// Stop is called once the handler is unsubscribed or the bus is
+	// stopped, after the events in its queue are handled. A handler
+	// subscribed to more than one event type is stopped once, when its
+	// last subscription ends.
 	Stop(ctx context.Context)
 }
 
 type option func(*Bus)
 
-// WithBufferSize is used to set the buffer size of each queue
+// WithBufferSize is used to set the default buffer size of each queue
 // each subscriber gets a channel that its listening on
-// without a high enough buffer size the channel will stall
+// without a high enough buffer size the channel will stall.
+// A size below 1 keeps the default.
 func WithBufferSize(s int) option {
 	return func(b *Bus) {
-		b.queue = make(chan *Event, s)
+		if s > 0 {
+			b.bufferSize = s
+		}
 	}
 }
 
-// WithWorkers sets the amount of workers that will be used
+// WithWorkers sets the default amount of workers of each subscriber,
+// a value below 1 keeps the default
 func WithWorkers(w int) option {
 	return func(b *Bus) {
-		b.workers = w
+		if w > 0 {
+			b.workers = w
+		}
+	}
+}
+
+// WithOverflowPolicy sets the default of what Publish does when the queue
+// of a subscriber is full, the default is to block
+func WithOverflowPolicy(p OverflowPolicy) option {
+	return func(b *Bus) {
+		b.policy = p
 	}
 }
 
@@ -78,10 +189,16 @@ func WithWorkers(w int) option {
 func New(opts ...option) *Bus {
 	// sets the defaults
 	b := &Bus{
-		subs:     make(map[EventType][]EventHandler),
-		queue:    make(chan *Event, defaultbuffer),
-		shutdown: false,
-		workers:  defaultworkers,
+		subs:       make(map[EventType][]*subscription),
+		bufferSize: defaultbuffer,
+		workers:    defaultworkers,
+		policy:     Block,
+		shutdown:   false,
+		done:       make(chan struct{}),
+		handlers:   make(map[interface{}]int),
+		stats:      make(map[EventType]*counters),
+		logged:     make(map[EventType]bool),
+		decoders:   make(map[EventType]decoder),
 	}
 
 	// sets any options
@@ -89,78 +206,349 @@ func New(opts ...option) *Bus {
 		o(b)
 	}
 
+	if b.log != nil {
+		b.recovered = b.log.Next()
+	}
+
 	return b
 }
 
-// Subscribe adds a handler to listen for a specific event type
-func (b *Bus) Subscribe(e EventType, h EventHandler) {
+// Subscribe adds a handler to listen for a specific event type. The
+// handler gets its own queue and workers, which are configured with the
+// options and otherwise use the defaults of the bus. Handlers subscribed
+// to a running bus are started right away, and the returned subscription
+// removes the handler again. Subscribing to a stopped bus has no effect.
+func (b *Bus) Subscribe(e EventType, h EventHandler, opts ...SubscribeOption) *Subscription {
+	s := &subscription{
+		event:    e,
+		name:     fmt.Sprintf("%T", h),
+		original: h,
+		closed:   make(chan struct{}),
+		size:     b.bufferSize,
+		workers:  b.workers,
+		policy:   b.policy,
+		latency:  newHistogram(),
+		events:   b.counters(e),
+		pending:  &b.pending,
+	}
+
+	for _, o := range opts {
+		o(s)
+	}
+
+	s.queue = make(chan *Event, s.size)
+	s.handler = chain(chain(h, s.middleware...), b.middleware...)
+
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if _, ok := b.subs[e]; !ok {
-		b.subs[e] = []EventHandler{}
+	if b.shutdown {
+		return &Subscription{bus: b, s: s}
+	}
+
+	b.subs[e] = append(b.subs[e], s)
+	b.handlers[s.key()]++
+	s.registered = true
+
+	// subscribers added to a running bus are started right away
+	if b.started {
+		b.startWorkers(s)
+	}
+
+	return &Subscription{bus: b, s: s}
+}
+
+// Publish a new event to the queues of the subscribers of the event type,
+// when a queue is full the overflow policy of the subscriber is applied.
+// Events dropped by the policy are only counted, and not returned as an
+// error. It returns ErrStopped once the bus has shutdown, and the error of
+// the context when it is done before the event is queued. The handlers can
+// still publish while the queues are drained on shutdown, and the event
+// a handler failed to publish for is not acknowledged in the log.
+func (b *Bus) Publish(ctx context.Context, e *Event) error {
+	err := b.publish(ctx, e)
+	if err != nil {
+		Fail(ctx)
+	}
+	return err
+}
+
+// publish logs the event and queues it for the subscribers
+func (b *Bus) publish(ctx context.Context, e *Event) error {
+	subs, err := b.acquire(ctx, e.Type)
+	if err != nil {
+		return err
 	}
-	b.subs[e] = append(b.subs[e], h)
+	defer release(subs)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	setMetadata(ctx, e)
+
+	// the event is logged before it is queued, so it
+	// can be delivered again after a restart
+	e.ack, err = b.append(e, len(subs))
+	if err != nil {
+		return err
+	}
+
+	return b.deliver(ctx, e, subs)
 }
 
-// Publish a new event
-func (b *Bus) Publish(e *Event) error {
+// acquire returns the subscribers of the event type and registers the
+// caller as sending on their queues, which are only closed once release
+// is called. It returns ErrStopped once the bus has shutdown, except for
+// the handlers until the queues are drained.
+func (b *Bus) acquire(ctx context.Context, t EventType) ([]*subscription, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
 	// if shutdown we no longer allow publishing
-	if b.shutdown {
-		return errors.New("bus has shutdown")
+	if b.shutdown && !isHandling(ctx) {
+		return nil, ErrStopped
 	}
 
-	b.queue <- e
+	// the queues are drained and about to be closed
+	select {
+	case <-b.done:
+		return nil, ErrStopped
+	default:
+	}
 
-	return nil
+	subs := b.subs[t]
+	for _, s := range subs {
+		s.sending.Add(1)
+	}
+	return subs, nil
 }
 
-// Start Bus by starting the workers
-func (b *Bus) Start(ctx context.Context) {
-	for i := 0; i < b.workers; i++ {
-		b.wg.Add(1)
-		go b.worker(ctx)
+// release the subscribers returned by acquire
+func release(subs []*subscription) {
+	for _, s := range subs {
+		s.sending.Done()
 	}
 }
 
-// worker processes events from the queue
-// on shutdown signal it will return
-// we do allow the ability to pass trhough a context to force the worker to
-// shutdown
-func (b *Bus) worker(ctx context.Context) {
-	defer b.wg.Done()
-	for {
+// deliver queues the event for the subscribers
+func (b *Bus) deliver(ctx context.Context, e *Event, subs []*subscription) error {
+	b.counters(e.Type).published.Add(1)
+
+	var errs []error
+	for _, s := range subs {
+		// We make a copy of the event to reduce
+		// the likelihood of race conditions
+		event := *e
+
+		if err := b.enqueue(ctx, s, &event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// setMetadata sets the ID and timestamp of the event, and the source and
+// scrape ID from the context when the publisher did not set them
+func setMetadata(ctx context.Context, e *Event) {
+	m := MetadataFromContext(ctx)
+
+	if e.Metadata.ID == "" {
+		e.Metadata.ID = NewID()
+	}
+	if e.Metadata.Timestamp.IsZero() {
+		e.Metadata.Timestamp = time.Now()
+	}
+	if e.Metadata.Source == "" {
+		e.Metadata.Source = m.Source
+	}
+	if e.Metadata.ScrapeID == "" {
+		e.Metadata.ScrapeID = m.ScrapeID
+	}
+	if e.Metadata.Window == 0 {
+		e.Metadata.Window = m.Window
+	}
+	if m.Replayed {
+		e.Metadata.Replayed = true
+	}
+}
+
+// enqueue adds the event to the queue of the subscriber using its
+// overflow policy
+func (b *Bus) enqueue(ctx context.Context, s *subscription, e *Event) error {
+	// counted as queued before sending, so the
+	// worker never sees a negative amount
+	s.queued.Add(1)
+	s.events.queued.Add(1)
+	s.active.Add(1)
+	b.pending.Add(1)
+
+	err := s.send(ctx, e, b.done)
+	if err == nil {
+		return nil
+	}
+
+	s.queued.Add(-1)
+	s.events.queued.Add(-1)
+	s.active.Add(-1)
+	b.pending.Add(-1)
+
+	// the event stays unacknowledged in the log, so
+	// it is delivered again after a restart
+	if errors.Is(err, ErrStopped) {
+		return err
+	}
+
+	// the subscriber was removed while waiting for its queue
+	if errors.Is(err, errUnsubscribed) {
 		select {
-		case event, ok := <-b.queue:
-			if !ok {
-				// The queue has been closed, so the worker exits
-				return
-			}
+		case <-b.done:
+			return ErrStopped
+		default:
+		}
+		e.acknowledge()
+		return nil
+	}
 
-			b.mu.RLock()
-			subs := b.subs[event.Type]
-			b.mu.RUnlock()
+	s.dropped.Add(1)
+	s.events.dropped.Add(1)
+	e.acknowledge()
 
-			for _, sub := range subs {
-				// We make a copy of the event to reduce
-				// the likelihood of race conditions
-				e := *event
+	if errors.Is(err, errDropped) {
+		return nil
+	}
+	return err
+}
 
-				sub.Handle(ctx, &e)
-			}
-		case <-ctx.Done():
-			// if context is canceled
-			// we should stop the worker
-			return
+// counters returns the counters of the event type
+func (b *Bus) counters(t EventType) *counters {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	c, ok := b.stats[t]
+	if !ok {
+		c = &counters{}
+		b.stats[t] = c
+	}
+	return c
+}
+
+// Start Bus by starting the workers of the subscribers, and queue the
+// events in the log that were not acknowledged before a restart
+func (b *Bus) Start(ctx context.Context) {
+	b.mu.Lock()
+	if b.started || b.shutdown {
+		b.mu.Unlock()
+		return
+	}
+	b.ctx = ctx
+	b.started = true
+
+	for _, subs := range b.subs {
+		for _, s := range subs {
+			b.startWorkers(s)
 		}
 	}
+	b.mu.Unlock()
+
+	b.redeliver(ctx)
+}
+
+// startWorkers starts the workers of the subscriber, the lock must be held
+func (b *Bus) startWorkers(s *subscription) {
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.worker(b.ctx)
+		}()
+	}
 }
 
-// Stop the Bus gracefully by waiting for workers to exit
+// Stop the Bus gracefully by waiting for workers to exit. New events are
+// rejected, and the events already in the queues are handled along with the
+// events the handlers publish while handling them, until the context is
+// done. Then the handlers are stopped.
 func (b *Bus) Stop(ctx context.Context) {
 	b.mu.Lock()
+	if b.shutdown {
+		b.mu.Unlock()
+		return
+	}
 	b.shutdown = true
-	close(b.queue)
+	started := b.started
 	b.mu.Unlock()
-	b.wg.Wait()
+
+	// without workers the queues are never drained
+	if started {
+		b.drain(ctx)
+	}
+
+	b.mu.Lock()
+	close(b.done)
+
+	var subs []*subscription
+	for _, s := range b.subs {
+		subs = append(subs, s...)
+	}
+	b.mu.Unlock()
+
+	// the queues are closed first, so they are drained at the same time
+	for _, s := range subs {
+		s.close()
+	}
+	for _, s := range subs {
+		s.wg.Wait()
+	}
+
+	for _, s := range subs {
+		b.stopHandler(ctx, s)
+	}
+}
+
+// drain waits until the events in the queues are handled, including the
+// events published downstream by the handlers, which are counted as pending
+// before the event they handle is done
+func (b *Bus) drain(ctx context.Context) {
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	for b.pending.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// stopHandler stops the handler of the subscription once, unless
+// the handler has other subscriptions
+func (b *Bus) stopHandler(ctx context.Context, s *subscription) {
+	s.stopOnce.Do(func() {
+		b.mu.Lock()
+		key := s.key()
+		b.handlers[key]--
+		last := b.handlers[key] <= 0
+		if last {
+			delete(b.handlers, key)
+		}
+		b.mu.Unlock()
+
+		defer func() {
+			if r := recover(); r != nil {
+				log.FromContext(ctx).Error("recovered panic stopping bus handler", "handler", s.name, "panic", r)
+			}
+		}()
+
+		if last {
+			s.handler.Stop(ctx)
+		}
+		if s.onStop != nil {
+			s.onStop(ctx)
+		}
+	})
 }
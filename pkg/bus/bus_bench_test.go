@@ -48,7 +48,7 @@ func BenchmarkBus(b *testing.B) {
 			Type: EventType(i % 10),
 			Data: i,
 		}
-		err := bus.Publish(event)
+		err := bus.Publish(ctx, event)
 		if err != nil {
 			b.Fatalf("failed on publish: %v", err)
 		}
@@ -3,7 +3,9 @@ package bus
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -46,7 +48,7 @@ func TestBus(t *testing.T) {
 		Data: "test",
 	}
 
-	err := b.Publish(e)
+	err := b.Publish(ctx, e)
 	assert.NoError(err)
 
 	h1.wg.Wait()
@@ -62,7 +64,7 @@ func TestBus(t *testing.T) {
 
 	h1.wg.Add(1)
 	h2.wg.Add(1)
-	err = b.Publish(e)
+	err = b.Publish(ctx, e2)
 	assert.NoError(err)
 
 	h1.wg.Wait()
@@ -73,3 +75,471 @@ func TestBus(t *testing.T) {
 
 	b.Stop(ctx)
 }
+
+func TestPublishOverflow(t *testing.T) {
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		policy OverflowPolicy
+		err    error
+		// the data of the events left in the queue
+		queue []interface{}
+	}{
+		{DropNewest, nil, []interface{}{1, 2}},
+		{DropOldest, nil, []interface{}{2, 3}},
+		{Error, ErrQueueFull, []interface{}{1, 2}},
+	} {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			// the bus is not started, so the queue fills up
+			b := New(WithBufferSize(2), WithOverflowPolicy(test.policy))
+			b.Subscribe(1, &mockHandler{})
+
+			assert.NoError(b.Publish(ctx, &Event{Type: 1, Data: 1}))
+			assert.NoError(b.Publish(ctx, &Event{Type: 1, Data: 2}))
+			assert.ErrorIs(b.Publish(ctx, &Event{Type: 1, Data: 3}), test.err)
+
+			assert.Equal(Stats{Published: 3, Dropped: 1, Queued: 2}, b.Stats()[1])
+
+			queue := b.subs[1][0].queue
+			var data []interface{}
+			for len(queue) > 0 {
+				data = append(data, (<-queue).Data)
+			}
+			assert.Equal(test.queue, data)
+		})
+	}
+}
+
+func TestQueueDefaults(t *testing.T) {
+	assert := require.New(t)
+
+	// sizes and workers below 1 keep the defaults, as an empty
+	// queue or no workers would block the publishers forever
+	b := New(WithBufferSize(0), WithWorkers(-1), WithOverflowPolicy(DropOldest))
+	assert.Equal(defaultbuffer, b.bufferSize)
+	assert.Equal(defaultworkers, b.workers)
+
+	b = New(WithBufferSize(2), WithWorkers(3), WithOverflowPolicy(DropOldest))
+	b.Subscribe(1, &mockHandler{}, WithQueueSize(0), WithConcurrency(0))
+	s := b.subs[1][0]
+	assert.Equal(2, s.size)
+	assert.Equal(2, cap(s.queue))
+	assert.Equal(3, s.workers)
+
+	// publishing on the full queue drops the oldest event
+	for i := 0; i < 3; i++ {
+		assert.NoError(b.Publish(context.Background(), &Event{Type: 1, Data: i}))
+	}
+	assert.Equal(Stats{Published: 3, Dropped: 1, Queued: 2}, b.Stats()[1])
+}
+
+func TestPublishBlock(t *testing.T) {
+	assert := require.New(t)
+
+	b := New(WithBufferSize(1))
+	b.Subscribe(1, &mockHandler{})
+	assert.NoError(b.Publish(context.Background(), &Event{Type: 1}))
+
+	// a full queue blocks until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(b.Publish(ctx, &Event{Type: 1}), context.DeadlineExceeded)
+
+	// or until the bus is stopped
+	errs := make(chan error)
+	go func() {
+		errs <- b.Publish(context.Background(), &Event{Type: 1})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	b.Start(context.Background())
+	b.Stop(context.Background())
+
+	// the publisher either got into the queue before the stop or was released
+	err := <-errs
+	if err != nil {
+		assert.ErrorIs(err, ErrStopped)
+	}
+	assert.ErrorIs(b.Publish(context.Background(), &Event{Type: 1}), ErrStopped)
+	assert.Equal(int64(0), b.Stats()[1].Queued)
+}
+
+func TestStopWhilePublishing(t *testing.T) {
+	ctx := context.Background()
+	h := &mockHandler{}
+
+	b := New(WithBufferSize(1), WithWorkers(1))
+	b.Subscribe(1, h)
+	b.Start(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if err := b.Publish(ctx, &Event{Type: 1}); err != nil {
+					require.ErrorIs(t, err, ErrStopped)
+					return
+				}
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	// stopping must not panic by closing the queue under the publishers
+	b.Stop(ctx)
+	b.Stop(ctx)
+	wg.Wait()
+
+	require.Equal(t, int64(0), b.Stats()[1].Queued)
+	require.Equal(t, int(b.SubscriberStats()[0].Handled), h.count)
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	for _, p := range []OverflowPolicy{Block, DropNewest, DropOldest, Error} {
+		parsed, err := ParseOverflowPolicy(p.String())
+		require.NoError(t, err)
+		require.Equal(t, p, parsed)
+	}
+
+	_, err := ParseOverflowPolicy("unknown")
+	require.Error(t, err)
+}
+
+// blockingHandler blocks until it is released
+type blockingHandler struct {
+	release chan struct{}
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, e *Event) {
+	<-h.release
+}
+
+func (h *blockingHandler) Stop(ctx context.Context) {}
+
+// panicHandler panics on every event
+type panicHandler struct{}
+
+func (panicHandler) Handle(ctx context.Context, e *Event) {
+	panic("handler failed")
+}
+
+func (panicHandler) Stop(ctx context.Context) {}
+
+func TestSlowSubscriber(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	slow := &blockingHandler{release: make(chan struct{})}
+	fast := &testHandler{wg: &sync.WaitGroup{}}
+
+	b := New(WithBufferSize(10))
+	b.Subscribe(1, slow, WithName("slow"), WithQueueSize(1), WithConcurrency(1), WithOverflow(DropOldest))
+	b.Subscribe(1, fast, WithName("fast"))
+	b.Start(ctx)
+
+	// the hanging handler does not block the publisher or the other handler
+	for i := 0; i < 5; i++ {
+		fast.wg.Add(1)
+		assert.NoError(b.Publish(ctx, &Event{Type: 1, Data: i}))
+		fast.wg.Wait()
+		assert.Equal(i, fast.received.Data)
+	}
+
+	close(slow.release)
+	b.Stop(ctx)
+
+	stats := b.SubscriberStats()
+	assert.Len(stats, 2)
+	assert.Equal("fast", stats[0].Name)
+	assert.Equal(uint64(5), stats[0].Handled)
+	assert.Equal(uint64(5), stats[0].Latency.Count)
+	assert.Equal("slow", stats[1].Name)
+	assert.Equal(uint64(5), stats[1].Handled+stats[1].Dropped)
+	assert.NotZero(stats[1].Dropped)
+	assert.Equal(int64(0), stats[1].Queued)
+}
+
+func TestHandlerPanic(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h := &testHandler{wg: &sync.WaitGroup{}}
+
+	b := New(WithWorkers(1))
+	b.Subscribe(1, panicHandler{}, WithName("panic"))
+	b.Subscribe(1, h, WithName("test"))
+	b.Start(ctx)
+
+	// the worker of the panicking handler keeps running
+	for i := 0; i < 3; i++ {
+		h.wg.Add(1)
+		assert.NoError(b.Publish(ctx, &Event{Type: 1}))
+		h.wg.Wait()
+	}
+	b.Stop(ctx)
+
+	stats := b.SubscriberStats()
+	assert.Equal("panic", stats[0].Name)
+	assert.Equal(uint64(3), stats[0].Panics)
+	assert.Equal(uint64(0), stats[0].Handled)
+	assert.Equal(uint64(3), stats[1].Handled)
+
+	// the handlers are named after their type by default
+	b = New()
+	b.Subscribe(1, panicHandler{})
+	assert.Equal("bus.panicHandler", b.SubscriberStats()[0].Name)
+}
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram()
+	h.observe(time.Millisecond)
+	h.observe(20 * time.Millisecond)
+	h.observe(time.Minute)
+
+	s := h.snapshot()
+	require.Equal(t, uint64(3), s.Count)
+	require.InDelta(t, 60.021, s.Sum, 1e-9)
+	require.Equal(t, uint64(1), s.Counts[0])
+	require.Equal(t, uint64(2), s.Counts[2])
+	require.Equal(t, uint64(2), s.Counts[len(s.Counts)-1])
+}
+
+func TestTopic(t *testing.T) {
+	assert := require.New(t)
+
+	type data struct {
+		Name string
+	}
+
+	b := New(WithWorkers(1))
+	topic := NewTopic[data](b, 1)
+	assert.Equal(EventType(1), topic.Type())
+
+	received := make(chan data)
+	metadata := make(chan Metadata)
+	topic.Subscribe(func(ctx context.Context, d data) {
+		received <- d
+		metadata <- MetadataFromContext(ctx)
+	})
+	b.Start(context.Background())
+	defer b.Stop(context.Background())
+
+	// the source and scrape ID are taken from the context
+	ctx := WithMetadata(context.Background(), Metadata{Source: "gcp", ScrapeID: "scrape"})
+	assert.NoError(topic.Publish(ctx, data{Name: "test"}))
+
+	assert.Equal(data{Name: "test"}, <-received)
+	m := <-metadata
+	assert.Len(m.ID, 32)
+	assert.False(m.Timestamp.IsZero())
+	assert.Equal("gcp", m.Source)
+	assert.Equal("scrape", m.ScrapeID)
+
+	// data of the wrong type is not passed to the subscriber
+	assert.NoError(b.Publish(context.Background(), &Event{Type: 1, Data: &data{Name: "pointer"}}))
+	assert.NoError(topic.Publish(context.Background(), data{Name: "value"}))
+	assert.Equal(data{Name: "value"}, <-received)
+
+	m = <-metadata
+	assert.Empty(m.Source)
+	assert.NotEmpty(m.ID)
+}
+
+func TestMetadataPropagation(t *testing.T) {
+	assert := require.New(t)
+
+	b := New(WithWorkers(1))
+	collected := NewTopic[int](b, 1)
+	calculated := NewTopic[int](b, 2)
+
+	// the events published by a subscriber keep the source and scrape ID
+	collected.Subscribe(func(ctx context.Context, v int) {
+		assert.NoError(calculated.Publish(ctx, v*2))
+	})
+
+	metadata := make(chan Metadata, 1)
+	calculated.Subscribe(func(ctx context.Context, v int) {
+		assert.Equal(4, v)
+		metadata <- MetadataFromContext(ctx)
+	})
+	b.Start(context.Background())
+	defer b.Stop(context.Background())
+
+	ctx := WithMetadata(context.Background(), Metadata{Source: "aws", ScrapeID: "scrape"})
+	assert.NoError(collected.Publish(ctx, 2))
+
+	m := <-metadata
+	assert.Equal("aws", m.Source)
+	assert.Equal("scrape", m.ScrapeID)
+}
+
+// countingHandler counts the events it handles and how often it is stopped
+type countingHandler struct {
+	handled atomic.Int64
+	stopped atomic.Int64
+}
+
+func (h *countingHandler) Handle(ctx context.Context, e *Event) {
+	time.Sleep(time.Millisecond)
+	h.handled.Add(1)
+}
+
+func (h *countingHandler) Stop(ctx context.Context) {
+	h.stopped.Add(1)
+}
+
+func TestStopHandlers(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h := &countingHandler{}
+	var stops atomic.Int64
+
+	b := New(WithWorkers(1))
+	b.Subscribe(1, h)
+	b.Subscribe(2, h)
+	NewTopic[int](b, 1).Subscribe(func(ctx context.Context, v int) {}, OnStop(func(ctx context.Context) {
+		stops.Add(1)
+	}))
+	b.Start(ctx)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(b.Publish(ctx, &Event{Type: 1}))
+		assert.NoError(b.Publish(ctx, &Event{Type: 2}))
+	}
+
+	// the queues are drained before the handlers are stopped
+	b.Stop(ctx)
+	b.Stop(ctx)
+	assert.Equal(int64(10), h.handled.Load())
+	assert.Equal(int64(1), h.stopped.Load())
+	assert.Equal(int64(1), stops.Load())
+
+	// subscribing to a stopped bus has no effect
+	sub := b.Subscribe(1, h)
+	sub.Unsubscribe(ctx)
+	assert.Equal(int64(1), h.stopped.Load())
+	assert.Len(b.SubscriberStats(), 3)
+}
+
+func TestStopDrainsDownstream(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h := &countingHandler{}
+
+	b := New(WithWorkers(1))
+	b.Subscribe(2, h)
+	NewTopic[int](b, 1).Subscribe(func(ctx context.Context, v int) {
+		time.Sleep(time.Millisecond)
+		assert.NoError(b.Publish(ctx, &Event{Type: 2}))
+	})
+	b.Start(ctx)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(NewTopic[int](b, 1).Publish(ctx, i))
+	}
+
+	// the events the handlers publish while the queues are
+	// drained are handled, other events are rejected
+	b.Stop(ctx)
+	assert.Equal(int64(5), h.handled.Load())
+	assert.ErrorIs(b.Publish(ctx, &Event{Type: 2}), ErrStopped)
+}
+
+func TestSubscriptionWait(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h := &countingHandler{}
+
+	b := New(WithWorkers(1))
+	downstream := b.Subscribe(2, h)
+	upstream := NewTopic[int](b, 1).Subscribe(func(ctx context.Context, v int) {
+		assert.NoError(b.Publish(ctx, &Event{Type: 2}))
+	})
+	b.Start(ctx)
+	defer b.Stop(ctx)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(NewTopic[int](b, 1).Publish(ctx, i))
+	}
+
+	// the upstream handler is done before the downstream one
+	assert.NoError(upstream.Wait(ctx))
+	assert.Equal(uint64(5), upstream.Stats().Handled)
+	assert.NoError(downstream.Wait(ctx))
+	assert.Equal(int64(5), h.handled.Load())
+
+	// a blocked handler is waited for until the context is done
+	blocking := &blockingHandler{release: make(chan struct{})}
+	sub := b.Subscribe(3, blocking)
+	defer close(blocking.release)
+	assert.NoError(b.Publish(ctx, &Event{Type: 3}))
+
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(sub.Wait(waitCtx), context.DeadlineExceeded)
+}
+
+func TestUnsubscribe(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h := &countingHandler{}
+	other := &testHandler{wg: &sync.WaitGroup{}}
+
+	b := New(WithWorkers(1))
+	b.Start(ctx)
+
+	// handlers can be added to a running bus
+	sub := b.Subscribe(1, h, WithName("counting"))
+	b.Subscribe(1, other)
+	assert.Equal("counting", sub.Name())
+	assert.Equal(EventType(1), sub.Event())
+
+	for i := 0; i < 5; i++ {
+		other.wg.Add(1)
+		assert.NoError(b.Publish(ctx, &Event{Type: 1}))
+	}
+
+	// the queued events are handled before the handler is stopped
+	sub.Unsubscribe(ctx)
+	sub.Unsubscribe(ctx)
+	assert.Equal(int64(5), h.handled.Load())
+	assert.Equal(int64(1), h.stopped.Load())
+	other.wg.Wait()
+
+	// the other handler still receives events
+	other.wg.Add(1)
+	assert.NoError(b.Publish(ctx, &Event{Type: 1}))
+	other.wg.Wait()
+
+	b.Stop(ctx)
+	assert.Equal(int64(5), h.handled.Load())
+	assert.Equal(int64(1), h.stopped.Load())
+	assert.Len(b.SubscriberStats(), 1)
+}
+
+func TestUnsubscribeWhilePublishing(t *testing.T) {
+	ctx := context.Background()
+
+	// the queue is full, so the publisher is waiting for it
+	b := New(WithBufferSize(1))
+	sub := b.Subscribe(1, &blockingHandler{release: make(chan struct{})})
+	require.NoError(t, b.Publish(ctx, &Event{Type: 1}))
+
+	errs := make(chan error)
+	go func() {
+		errs <- b.Publish(ctx, &Event{Type: 1})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// the bus is not started, so the queue is closed without being handled
+	sub.Unsubscribe(ctx)
+	require.NoError(t, <-errs)
+}
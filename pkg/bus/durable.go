@@ -0,0 +1,225 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/log"
+	"github.com/re-cinq/aether/pkg/wal"
+)
+
+// ErrNoLog is returned when replaying events on a bus without a log
+var ErrNoLog = errors.New("bus has no event log")
+
+// WithLog writes the events of the event types to the write-ahead log
+// before they are queued. An event is acknowledged once all subscribers
+// handled or dropped it, the events that were not acknowledged before a
+// restart are delivered again on Start. The data of the events is stored
+// as JSON and read back with the type of its Topic. A nil log disables it.
+func WithLog(l *wal.Log, events ...EventType) option {
+	return func(b *Bus) {
+		b.log = l
+		for _, e := range events {
+			b.logged[e] = true
+		}
+	}
+}
+
+// acker acknowledges the record of an event in the log once
+// all subscribers are done with it
+type acker struct {
+	log     *wal.Log
+	offset  uint64
+	pending atomic.Int32
+}
+
+func (a *acker) done() {
+	if a.pending.Add(-1) == 0 {
+		a.log.Ack(a.offset)
+	}
+}
+
+// acknowledge marks the event as done by a subscriber
+func (e *Event) acknowledge() {
+	if e.ack != nil {
+		e.ack.done()
+	}
+}
+
+// record is an event as it is stored in the log
+type record struct {
+	Type     EventType       `json:"type"`
+	Metadata Metadata        `json:"metadata"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// decoder reads the data of an event from the log
+type decoder func(data []byte) (interface{}, error)
+
+// registerDecoder sets how the data of the event type is read from the log,
+// it is set by the topic of the event type
+func registerDecoder[T any](b *Bus, e EventType) {
+	b.decodersMu.RLock()
+	_, ok := b.decoders[e]
+	b.decodersMu.RUnlock()
+	if ok {
+		return
+	}
+
+	b.decodersMu.Lock()
+	defer b.decodersMu.Unlock()
+
+	b.decoders[e] = func(data []byte) (interface{}, error) {
+		var v T
+		err := json.Unmarshal(data, &v)
+		return v, err
+	}
+}
+
+// append writes the event to the log when its type is logged, and returns
+// the acker the subscribers acknowledge it with
+func (b *Bus) append(e *Event, subscribers int) (*acker, error) {
+	if b.log == nil || !b.logged[e.Type] {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding event for the log: %w", err)
+	}
+
+	r, err := json.Marshal(record{Type: e.Type, Metadata: e.Metadata, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding event for the log: %w", err)
+	}
+
+	offset, err := b.log.Append(e.Metadata.Timestamp, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscribers == 0 {
+		b.log.Ack(offset)
+		return nil, nil
+	}
+
+	a := &acker{log: b.log, offset: offset}
+	a.pending.Store(int32(subscribers))
+	return a, nil
+}
+
+// decode reads the event of the record in the log
+func (b *Bus) decode(r wal.Record) (*Event, error) {
+	var rec record
+	if err := json.Unmarshal(r.Data, &rec); err != nil {
+		return nil, fmt.Errorf("error decoding event from the log: %w", err)
+	}
+
+	data, err := b.Decode(rec.Type, rec.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{Type: rec.Type, Data: data, Metadata: rec.Metadata}, nil
+}
+
+// Decode reads the data of an event type encoded as JSON, with the type
+// of the Topic of the event type. It is used to receive events from the
+// log and from other nodes.
+func (b *Bus) Decode(t EventType, data []byte) (interface{}, error) {
+	b.decodersMu.RLock()
+	decode, ok := b.decoders[t]
+	b.decodersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("error no topic for event type %d", t)
+	}
+
+	v, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding event: %w", err)
+	}
+	return v, nil
+}
+
+// redeliver queues the events in the log that were not acknowledged before
+// the bus was created, they are acknowledged like published events
+func (b *Bus) redeliver(ctx context.Context) {
+	if b.log == nil {
+		return
+	}
+	logger := log.FromContext(ctx)
+
+	var count int
+	err := b.log.Read(b.log.Committed(), b.recovered, func(r wal.Record) error {
+		e, err := b.decode(r)
+		if err != nil {
+			// the event can never be delivered, so it
+			// does not block the log from being cleaned up
+			logger.Error("error redelivering event", "offset", r.Offset, "error", err)
+			b.log.Ack(r.Offset)
+			return nil
+		}
+
+		subs, err := b.acquire(ctx, e.Type)
+		if err != nil {
+			return err
+		}
+		defer release(subs)
+
+		if len(subs) == 0 {
+			b.log.Ack(r.Offset)
+			return nil
+		}
+
+		e.ack = &acker{log: b.log, offset: r.Offset}
+		e.ack.pending.Store(int32(len(subs)))
+
+		count++
+		return b.deliver(ctx, e, subs)
+	})
+	if err != nil {
+		logger.Error("error redelivering events from the log", "error", err)
+	}
+
+	if count > 0 {
+		logger.Info("redelivered unacknowledged events from the log", "events", count)
+	}
+}
+
+// Replay publishes the events in the log written between from and to
+// again, without writing them to the log. The replayed events are marked
+// in their metadata, and returned is the amount of events replayed.
+func (b *Bus) Replay(ctx context.Context, from, to time.Time) (int, error) {
+	if b.log == nil {
+		return 0, ErrNoLog
+	}
+
+	var count int
+	err := b.log.ReadAll(func(r wal.Record) error {
+		if r.Time.Before(from) || !r.Time.Before(to) {
+			return nil
+		}
+
+		e, err := b.decode(r)
+		if err != nil {
+			log.FromContext(ctx).Error("error replaying event", "offset", r.Offset, "error", err)
+			return nil
+		}
+		e.Metadata.Replayed = true
+
+		subs, err := b.acquire(ctx, e.Type)
+		if err != nil {
+			return err
+		}
+		defer release(subs)
+
+		count++
+		return b.deliver(ctx, e, subs)
+	})
+
+	return count, err
+}
@@ -0,0 +1,150 @@
+package bus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/wal"
+	"github.com/stretchr/testify/require"
+)
+
+type logged struct {
+	Name string
+}
+
+// collect subscribes to the topic and returns the received data
+func collect(b *Bus) chan logged {
+	received := make(chan logged, 10)
+	NewTopic[logged](b, 1).Subscribe(func(ctx context.Context, d logged) {
+		received <- d
+	})
+	return received
+}
+
+func TestLogRedelivery(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	l, err := wal.Open(dir, wal.WithSyncInterval(0))
+	assert.NoError(err)
+
+	// the bus is never started, like a crash before the events are handled
+	b := New(WithLog(l, 1))
+	collect(b)
+	topic := NewTopic[logged](b, 1)
+	assert.NoError(topic.Publish(ctx, logged{Name: "a"}))
+	assert.NoError(topic.Publish(ctx, logged{Name: "b"}))
+
+	// events of types that are not logged are not redelivered
+	assert.NoError(NewTopic[logged](b, 2).Publish(ctx, logged{Name: "c"}))
+	assert.NoError(l.Close())
+
+	l, err = wal.Open(dir, wal.WithSyncInterval(0))
+	assert.NoError(err)
+
+	// a single worker handles the events in order
+	b = New(WithLog(l, 1), WithWorkers(1))
+	received := collect(b)
+	b.Start(ctx)
+
+	assert.Equal(logged{Name: "a"}, <-received)
+	assert.Equal(logged{Name: "b"}, <-received)
+
+	b.Stop(ctx)
+	assert.Empty(received)
+	assert.Equal(uint64(2), l.Committed())
+	assert.NoError(l.Close())
+
+	// the handled events are not redelivered again
+	l, err = wal.Open(dir, wal.WithSyncInterval(0))
+	assert.NoError(err)
+	defer l.Close()
+
+	b = New(WithLog(l, 1))
+	received = collect(b)
+	b.Start(ctx)
+	b.Stop(ctx)
+	assert.Empty(received)
+}
+
+func TestFailedPublishNotAcked(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	l, err := wal.Open(t.TempDir(), wal.WithSyncInterval(0))
+	assert.NoError(err)
+	defer l.Close()
+
+	blocking := &blockingHandler{release: make(chan struct{})}
+
+	b := New(WithLog(l, 1), WithWorkers(1))
+	b.Subscribe(2, blocking, WithQueueSize(1), WithOverflow(Error))
+	NewTopic[logged](b, 1).Subscribe(func(ctx context.Context, d logged) {
+		assert.ErrorIs(b.Publish(ctx, &Event{Type: 2}), ErrQueueFull)
+	})
+	b.Start(ctx)
+	defer b.Stop(ctx)
+	defer close(blocking.release)
+
+	// the downstream queue is full, so the handler fails to publish
+	assert.NoError(b.Publish(ctx, &Event{Type: 2}))
+	assert.Eventually(func() bool {
+		return b.Stats()[2].Queued == 0
+	}, time.Second, time.Millisecond)
+	assert.NoError(b.Publish(ctx, &Event{Type: 2}))
+
+	topic := NewTopic[logged](b, 1)
+	assert.NoError(topic.Publish(ctx, logged{Name: "a"}))
+	assert.Eventually(func() bool {
+		return b.SubscriberStats()[0].Handled == 1
+	}, time.Second, time.Millisecond)
+
+	// the event stays in the log, so it is delivered again after a restart
+	assert.Equal(uint64(0), l.Committed())
+	assert.Equal(uint64(1), b.SubscriberStats()[0].Failed)
+}
+
+func TestReplay(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	l, err := wal.Open(t.TempDir(), wal.WithSyncInterval(0))
+	assert.NoError(err)
+	defer l.Close()
+
+	b := New(WithLog(l, 1))
+	received := make(chan Metadata, 10)
+	topic := NewTopic[logged](b, 1)
+	topic.Subscribe(func(ctx context.Context, d logged) {
+		received <- MetadataFromContext(ctx)
+	})
+	b.Start(ctx)
+	defer b.Stop(ctx)
+
+	start := time.Now()
+	for _, name := range []string{"a", "b"} {
+		assert.NoError(topic.Publish(ctx, logged{Name: name}))
+		m := <-received
+		assert.False(m.Replayed)
+	}
+
+	// events outside of the range are not replayed
+	count, err := b.Replay(ctx, start.Add(-time.Hour), start)
+	assert.NoError(err)
+	assert.Zero(count)
+
+	count, err = b.Replay(ctx, start, time.Now().Add(time.Second))
+	assert.NoError(err)
+	assert.Equal(2, count)
+
+	for i := 0; i < 2; i++ {
+		m := <-received
+		assert.True(m.Replayed)
+		assert.NotEmpty(m.ID)
+	}
+
+	_, err = New().Replay(ctx, start, time.Now())
+	assert.ErrorIs(err, ErrNoLog)
+}
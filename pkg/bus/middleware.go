@@ -0,0 +1,238 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	api "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware wraps the handler of a subscriber, so cross-cutting concerns
+// like logging and tracing are not implemented in every handler. The
+// handler returned should call Stop of the next handler, which Wrap does.
+type Middleware func(next EventHandler) EventHandler
+
+// WithMiddleware adds middleware to the handlers of all subscribers, the
+// first middleware is the outermost and sees the event first
+func WithMiddleware(mw ...Middleware) option {
+	return func(b *Bus) {
+		b.middleware = append(b.middleware, mw...)
+	}
+}
+
+// WithSubscriberMiddleware adds middleware to the handler of the
+// subscriber, it runs inside the middleware of the bus
+func WithSubscriberMiddleware(mw ...Middleware) SubscribeOption {
+	return func(s *subscription) {
+		s.middleware = append(s.middleware, mw...)
+	}
+}
+
+// chain wraps the handler with the middleware, the first is the outermost
+func chain(h EventHandler, mw ...Middleware) EventHandler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// wrapped is a handler returned by a middleware
+type wrapped struct {
+	next   EventHandler
+	handle func(ctx context.Context, e *Event)
+}
+
+func (w *wrapped) Handle(ctx context.Context, e *Event) {
+	w.handle(ctx, e)
+}
+
+func (w *wrapped) Stop(ctx context.Context) {
+	w.next.Stop(ctx)
+}
+
+// Wrap returns a handler that handles the events with the function and
+// stops the next handler, it is used to implement middleware
+func Wrap(next EventHandler, handle func(ctx context.Context, e *Event)) EventHandler {
+	return &wrapped{next: next, handle: handle}
+}
+
+// handling is the state of a subscriber handling an event
+type handling struct {
+	name     string
+	panicked bool
+
+	// set when the handler failed to publish an event
+	failed atomic.Bool
+}
+
+type handlingKey struct{}
+
+// HandlerName returns the name of the subscriber handling the event,
+// it is set in the context of the middleware and handlers
+func HandlerName(ctx context.Context) string {
+	if h, ok := ctx.Value(handlingKey{}).(*handling); ok {
+		return h.name
+	}
+	return ""
+}
+
+// panicked returns whether the Recover middleware recovered a panic
+// in the handler
+func panicked(ctx context.Context) bool {
+	h, ok := ctx.Value(handlingKey{}).(*handling)
+	return ok && h.panicked
+}
+
+// isHandling returns whether the context is of a subscriber handling an event
+func isHandling(ctx context.Context) bool {
+	_, ok := ctx.Value(handlingKey{}).(*handling)
+	return ok
+}
+
+// Fail marks the event the subscriber is handling as failed, so it is
+// not acknowledged in the log and is delivered again after a restart,
+// until it is dropped after the retention of the log. It is called by
+// handlers that could not pass the event on.
+func Fail(ctx context.Context) {
+	if h, ok := ctx.Value(handlingKey{}).(*handling); ok {
+		h.failed.Store(true)
+	}
+}
+
+// logPanic logs the recovered panic with its stack
+func logPanic(ctx context.Context, name string, e *Event, r interface{}) {
+	log.FromContext(ctx).Error("recovered panic in bus handler",
+		"handler", name,
+		"event", e.Type,
+		"event_id", e.Metadata.ID,
+		"panic", r,
+		"stack", string(debug.Stack()),
+	)
+}
+
+// Recover recovers a panic in the next handler and logs it, the panic
+// is counted in the statistics of the subscriber. The bus recovers panics
+// as well, Recover allows the outer middleware to see the handler return.
+func Recover() Middleware {
+	return func(next EventHandler) EventHandler {
+		return Wrap(next, func(ctx context.Context, e *Event) {
+			defer func() {
+				if r := recover(); r != nil {
+					if h, ok := ctx.Value(handlingKey{}).(*handling); ok {
+						h.panicked = true
+					}
+					logPanic(ctx, HandlerName(ctx), e, r)
+				}
+			}()
+
+			next.Handle(ctx, e)
+		})
+	}
+}
+
+// Logging adds the event ID, type, source and scrape ID to the logger in
+// the context of the next handler, and logs how long it took to handle
+// the event at debug level
+func Logging() Middleware {
+	return func(next EventHandler) EventHandler {
+		return Wrap(next, func(ctx context.Context, e *Event) {
+			logger := log.FromContext(ctx).With(
+				"event", e.Type,
+				"event_id", e.Metadata.ID,
+				"handler", HandlerName(ctx),
+				"source", e.Metadata.Source,
+				"scrape_id", e.Metadata.ScrapeID,
+			)
+
+			start := time.Now()
+			next.Handle(log.WithContext(ctx, logger), e)
+
+			logger.Debug("handled event", "duration", time.Since(start), "panicked", panicked(ctx))
+		})
+	}
+}
+
+// Tracing starts a span for every event a handler handles, the span is
+// named after the handler. Spans started by the handler are its children.
+func Tracing(tracer trace.Tracer) Middleware {
+	return func(next EventHandler) EventHandler {
+		return Wrap(next, func(ctx context.Context, e *Event) {
+			ctx, span := tracer.Start(ctx, "bus "+HandlerName(ctx),
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.Int("bus.event", int(e.Type)),
+					attribute.String("bus.event_id", e.Metadata.ID),
+					attribute.String("bus.handler", HandlerName(ctx)),
+					attribute.String("bus.source", e.Metadata.Source),
+					attribute.String("bus.scrape_id", e.Metadata.ScrapeID),
+					attribute.Bool("bus.replayed", e.Metadata.Replayed),
+				),
+			)
+
+			defer func() {
+				r := recover()
+				if r != nil {
+					span.SetStatus(codes.Error, fmt.Sprint(r))
+				} else if panicked(ctx) {
+					span.SetStatus(codes.Error, "handler panicked")
+				}
+				span.End()
+
+				// the panic is recovered by the bus
+				// or the outer middleware
+				if r != nil {
+					panic(r)
+				}
+			}()
+
+			next.Handle(ctx, e)
+		})
+	}
+}
+
+// Metrics records how long the handlers take to handle an event in the
+// bus.handler.duration histogram of the meter, by event type and handler
+func Metrics(meter api.Meter) (Middleware, error) {
+	duration, err := meter.Float64Histogram(
+		"bus.handler.duration",
+		api.WithDescription("How long a bus handler took to handle an event"),
+		api.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next EventHandler) EventHandler {
+		return Wrap(next, func(ctx context.Context, e *Event) {
+			start := time.Now()
+
+			defer func() {
+				duration.Record(ctx, time.Since(start).Seconds(), api.WithAttributes(
+					attribute.Int("event", int(e.Type)),
+					attribute.String("handler", HandlerName(ctx)),
+				))
+			}()
+
+			next.Handle(ctx, e)
+		})
+	}, nil
+}
+
+// Filter only passes the events to the next handler that the function
+// keeps, the other events are acknowledged without being handled
+func Filter(keep func(ctx context.Context, e *Event) bool) Middleware {
+	return func(next EventHandler) EventHandler {
+		return Wrap(next, func(ctx context.Context, e *Event) {
+			if keep(ctx, e) {
+				next.Handle(ctx, e)
+			}
+		})
+	}
+}
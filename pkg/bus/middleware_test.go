@@ -0,0 +1,183 @@
+package bus
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/re-cinq/aether/pkg/log"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recorded returns a middleware that appends its name to the calls
+func recorded(name string, calls *[]string, mu *sync.Mutex) Middleware {
+	return func(next EventHandler) EventHandler {
+		return Wrap(next, func(ctx context.Context, e *Event) {
+			mu.Lock()
+			*calls = append(*calls, name)
+			mu.Unlock()
+			next.Handle(ctx, e)
+		})
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	var calls []string
+	var mu sync.Mutex
+
+	h := &testHandler{wg: &sync.WaitGroup{}}
+	b := New(WithWorkers(1), WithMiddleware(recorded("first", &calls, &mu), recorded("second", &calls, &mu)))
+	b.Subscribe(1, h, WithSubscriberMiddleware(recorded("subscriber", &calls, &mu)))
+	b.Start(ctx)
+
+	h.wg.Add(1)
+	assert.NoError(b.Publish(ctx, &Event{Type: 1}))
+	h.wg.Wait()
+	b.Stop(ctx)
+
+	assert.Equal([]string{"first", "second", "subscriber"}, calls)
+
+	// the name is the type of the handler, not of the middleware
+	assert.Equal("*bus.testHandler", b.SubscriberStats()[0].Name)
+}
+
+func TestRecover(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h := &testHandler{wg: &sync.WaitGroup{}}
+	b := New(WithWorkers(1), WithMiddleware(Recover()))
+	b.Subscribe(1, panicHandler{}, WithName("panic"))
+	b.Subscribe(1, h, WithName("test"))
+	b.Start(ctx)
+
+	for i := 0; i < 2; i++ {
+		h.wg.Add(1)
+		assert.NoError(b.Publish(ctx, &Event{Type: 1}))
+		h.wg.Wait()
+	}
+	b.Stop(ctx)
+
+	stats := b.SubscriberStats()
+	assert.Equal(uint64(2), stats[0].Panics)
+	assert.Equal(uint64(0), stats[0].Handled)
+	assert.Equal(uint64(2), stats[1].Handled)
+}
+
+func TestFilter(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	received := make(chan int, 10)
+	b := New(WithWorkers(1))
+	NewTopic[int](b, 1).Subscribe(func(ctx context.Context, v int) {
+		received <- v
+	}, WithSubscriberMiddleware(Filter(func(ctx context.Context, e *Event) bool {
+		return e.Data.(int)%2 == 0
+	})))
+	b.Start(ctx)
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(NewTopic[int](b, 1).Publish(ctx, i))
+	}
+	b.Stop(ctx)
+
+	close(received)
+	var values []int
+	for v := range received {
+		values = append(values, v)
+	}
+	assert.Equal([]int{0, 2}, values)
+}
+
+func TestLogging(t *testing.T) {
+	assert := require.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := log.WithContext(context.Background(), logger)
+
+	b := New(WithWorkers(1), WithMiddleware(Logging()))
+	NewTopic[int](b, 1).Subscribe(func(ctx context.Context, v int) {
+		log.FromContext(ctx).Info("from handler")
+	}, WithName("logged"))
+	b.Start(ctx)
+
+	event := &Event{Type: 1, Data: 1}
+	assert.NoError(b.Publish(ctx, event))
+	b.Stop(ctx)
+
+	// the logs of the handler have the event ID
+	out := buf.String()
+	assert.Contains(out, `"msg":"from handler"`)
+	assert.Contains(out, `"msg":"handled event"`)
+	assert.Contains(out, `"event_id":"`+event.Metadata.ID+`"`)
+	assert.Contains(out, `"handler":"logged"`)
+}
+
+func TestTracing(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	h := &testHandler{wg: &sync.WaitGroup{}}
+	b := New(WithWorkers(1), WithMiddleware(Tracing(tracer), Recover()))
+	b.Subscribe(1, h, WithName("test"))
+	b.Subscribe(1, panicHandler{}, WithName("panic"))
+	b.Start(ctx)
+
+	h.wg.Add(1)
+	assert.NoError(b.Publish(ctx, &Event{Type: 1}))
+	h.wg.Wait()
+	b.Stop(ctx)
+
+	spans := recorder.Ended()
+	assert.Len(spans, 2)
+
+	status := make(map[string]codes.Code)
+	for _, s := range spans {
+		status[s.Name()] = s.Status().Code
+	}
+	assert.Equal(map[string]codes.Code{"bus test": codes.Unset, "bus panic": codes.Error}, status)
+}
+
+func TestMetrics(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	reader := metric.NewManualReader()
+	mw, err := Metrics(metric.NewMeterProvider(metric.WithReader(reader)).Meter("test"))
+	assert.NoError(err)
+
+	h := &testHandler{wg: &sync.WaitGroup{}}
+	b := New(WithWorkers(1), WithMiddleware(mw))
+	b.Subscribe(1, h)
+	b.Start(ctx)
+
+	for i := 0; i < 3; i++ {
+		h.wg.Add(1)
+		assert.NoError(b.Publish(ctx, &Event{Type: 1}))
+		h.wg.Wait()
+	}
+	b.Stop(ctx)
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(reader.Collect(ctx, &rm))
+	assert.Len(rm.ScopeMetrics, 1)
+
+	m := rm.ScopeMetrics[0].Metrics[0]
+	assert.Equal("bus.handler.duration", m.Name)
+	assert.Equal(uint64(3), m.Data.(metricdata.Histogram[float64]).DataPoints[0].Count)
+}
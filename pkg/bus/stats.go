@@ -0,0 +1,161 @@
+package bus
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// the upper bounds of the handler latency buckets in seconds
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Stats are the counters of an event type
+type Stats struct {
+	// The events that were published
+	Published uint64 `json:"published"`
+
+	// The events that were dropped for a subscriber because its queue
+	// was full, counted once per subscriber
+	Dropped uint64 `json:"dropped"`
+
+	// The events in the queues of the subscribers
+	Queued int64 `json:"queued"`
+}
+
+// SubscriberStats are the counters of a subscriber
+type SubscriberStats struct {
+	// The event type the subscriber is subscribed to
+	Event EventType `json:"event"`
+
+	// The name of the subscriber
+	Name string `json:"name"`
+
+	// The events that were handled
+	Handled uint64 `json:"handled"`
+
+	// The events that were dropped because the queue was full
+	Dropped uint64 `json:"dropped"`
+
+	// The panics recovered from the handler
+	Panics uint64 `json:"panics"`
+
+	// The events the handler failed to pass on, which are not
+	// acknowledged in the log
+	Failed uint64 `json:"failed"`
+
+	// The events in the queue
+	Queued int64 `json:"queued"`
+
+	// How long the handler took to handle the events
+	Latency Histogram `json:"latency"`
+}
+
+// Histogram is a snapshot of the distribution of durations
+type Histogram struct {
+	// The upper bounds of the buckets in seconds
+	Buckets []float64 `json:"buckets"`
+
+	// The cumulative amount of observations per bucket
+	Counts []uint64 `json:"counts"`
+
+	// The amount of observations
+	Count uint64 `json:"count"`
+
+	// The sum of the observations in seconds
+	Sum float64 `json:"sum"`
+}
+
+type counters struct {
+	published atomic.Uint64
+	dropped   atomic.Uint64
+	queued    atomic.Int64
+}
+
+// histogram records durations in the latency buckets
+type histogram struct {
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Int64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]atomic.Uint64, len(latencyBuckets))}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+
+	i := sort.SearchFloat64s(latencyBuckets, d.Seconds())
+	if i < len(h.counts) {
+		h.counts[i].Add(1)
+	}
+}
+
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{
+		Buckets: latencyBuckets,
+		Counts:  make([]uint64, len(latencyBuckets)),
+		Count:   h.count.Load(),
+		Sum:     time.Duration(h.sum.Load()).Seconds(),
+	}
+
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		s.Counts[i] = cumulative
+	}
+	return s
+}
+
+// Stats returns the counters per event type
+func (b *Bus) Stats() map[EventType]Stats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	stats := make(map[EventType]Stats, len(b.stats))
+	for t, c := range b.stats {
+		stats[t] = Stats{
+			Published: c.published.Load(),
+			Dropped:   c.dropped.Load(),
+			Queued:    c.queued.Load(),
+		}
+	}
+	return stats
+}
+
+// SubscriberStats returns the counters of the subscribers,
+// sorted by event type and name
+func (b *Bus) SubscriberStats() []SubscriberStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var stats []SubscriberStats
+	for _, subs := range b.subs {
+		for _, s := range subs {
+			stats = append(stats, s.stats())
+		}
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Event != stats[j].Event {
+			return stats[i].Event < stats[j].Event
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// stats returns the counters of the subscriber
+func (s *subscription) stats() SubscriberStats {
+	return SubscriberStats{
+		Event:   s.event,
+		Name:    s.name,
+		Handled: s.handled.Load(),
+		Dropped: s.dropped.Load(),
+		Panics:  s.panics.Load(),
+		Failed:  s.failed.Load(),
+		Queued:  s.queued.Load(),
+		Latency: s.latency.snapshot(),
+	}
+}
@@ -0,0 +1,324 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// errDropped is returned by send when the DropNewest
+	// overflow policy dropped the event
+	errDropped = errors.New("event dropped")
+
+	// errUnsubscribed is returned by send when the subscriber
+	// was removed while waiting for space in its queue
+	errUnsubscribed = errors.New("handler unsubscribed")
+)
+
+// subscription is a handler subscribed to an event type,
+// with its own queue and workers
+type subscription struct {
+	event EventType
+	name  string
+
+	// the handler wrapped in the middleware
+	handler  EventHandler
+	original EventHandler
+
+	// called once the handler is stopped
+	onStop func(ctx context.Context)
+
+	queue   chan *Event
+	size    int
+	workers int
+	policy  OverflowPolicy
+
+	// wraps the handler inside the middleware of the bus
+	middleware []Middleware
+
+	// the counters of the subscriber
+	handled atomic.Uint64
+	dropped atomic.Uint64
+	panics  atomic.Uint64
+	failed  atomic.Uint64
+	queued  atomic.Int64
+	latency *histogram
+
+	// the events queued or being handled by the
+	// subscriber, and by all subscribers of the bus
+	active  atomic.Int64
+	pending *atomic.Int64
+
+	// the counters of the event type
+	events *counters
+
+	// closed is closed when the subscriber is removed, to release the
+	// publishers waiting for its queue, which is closed once the
+	// publishers sending on it have returned
+	closed    chan struct{}
+	closeOnce sync.Once
+	sending   sync.WaitGroup
+
+	// the workers of the subscriber, registered is set
+	// once the subscriber is added to the bus
+	wg         sync.WaitGroup
+	stopOnce   sync.Once
+	registered bool
+}
+
+// Subscription is a handler subscribed to an event type
+type Subscription struct {
+	bus *Bus
+	s   *subscription
+}
+
+// Name returns the name of the subscriber
+func (sub *Subscription) Name() string {
+	return sub.s.name
+}
+
+// Event returns the event type the handler is subscribed to
+func (sub *Subscription) Event() EventType {
+	return sub.s.event
+}
+
+// Stats returns the counters of the subscriber
+func (sub *Subscription) Stats() SubscriberStats {
+	return sub.s.stats()
+}
+
+// Wait blocks until the queue of the subscriber is empty and its handlers
+// returned, including the events published to it in the meantime. It
+// returns the error of the context when it is done before.
+func (sub *Subscription) Wait(ctx context.Context) error {
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	for sub.s.active.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Unsubscribe removes the handler from the bus, the events in its queue
+// are handled before the handler is stopped. It must not be called by the
+// handler itself, as it waits for the handler to return.
+func (sub *Subscription) Unsubscribe(ctx context.Context) {
+	b, s := sub.bus, sub.s
+
+	b.mu.Lock()
+	subs := b.subs[s.event]
+	registered := s.registered
+	for i := range subs {
+		if subs[i] != s {
+			continue
+		}
+
+		// the slice is copied, as publishers may still be
+		// sending to the subscribers in the old one
+		removed := make([]*subscription, 0, len(subs)-1)
+		removed = append(removed, subs[:i]...)
+		b.subs[s.event] = append(removed, subs[i+1:]...)
+		break
+	}
+	b.mu.Unlock()
+
+	// the bus was already stopped when subscribing
+	if !registered {
+		return
+	}
+
+	s.close()
+	s.wg.Wait()
+	b.stopHandler(ctx, s)
+}
+
+// key returns what identifies the handler, a handler that cannot be
+// compared is identified by its subscription
+func (s *subscription) key() interface{} {
+	if reflect.TypeOf(s.original).Comparable() {
+		return s.original
+	}
+	return s
+}
+
+// close releases the publishers waiting for the queue and closes it once
+// no publisher is sending on it, so the workers exit once it is drained
+func (s *subscription) close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.sending.Wait()
+		close(s.queue)
+	})
+}
+
+// SubscribeOption configures the queue and workers of a subscriber
+type SubscribeOption func(*subscription)
+
+// WithName sets the name of the subscriber used in the statistics,
+// the default is the type of the handler
+func WithName(name string) SubscribeOption {
+	return func(s *subscription) {
+		s.name = name
+	}
+}
+
+// WithQueueSize sets the buffer size of the queue of the subscriber,
+// a size below 1 keeps the default of the bus
+func WithQueueSize(size int) SubscribeOption {
+	return func(s *subscription) {
+		if size > 0 {
+			s.size = size
+		}
+	}
+}
+
+// WithConcurrency sets the amount of workers handling the events of the
+// subscriber, a single worker handles the events in order. A value below
+// 1 keeps the default of the bus.
+func WithConcurrency(workers int) SubscribeOption {
+	return func(s *subscription) {
+		if workers > 0 {
+			s.workers = workers
+		}
+	}
+}
+
+// OnStop sets a function that is called once the handler is stopped,
+// used to clean up after handlers that are functions
+func OnStop(fn func(ctx context.Context)) SubscribeOption {
+	return func(s *subscription) {
+		s.onStop = fn
+	}
+}
+
+// WithOverflow sets what Publish does when the queue of the subscriber is
+// full, so a degraded handler can drop events instead of blocking the
+// publishers
+func WithOverflow(p OverflowPolicy) SubscribeOption {
+	return func(s *subscription) {
+		s.policy = p
+	}
+}
+
+// send adds the event to the queue using the overflow policy
+func (s *subscription) send(ctx context.Context, e *Event, done <-chan struct{}) error {
+	switch s.policy {
+	case DropNewest, Error:
+		select {
+		case s.queue <- e:
+			return nil
+		default:
+		}
+
+		if s.policy == Error {
+			return ErrQueueFull
+		}
+		return errDropped
+
+	case DropOldest:
+		for {
+			select {
+			case s.queue <- e:
+				return nil
+			default:
+			}
+
+			// make space by dropping the oldest event, unless
+			// a worker took it in the meantime
+			select {
+			case old := <-s.queue:
+				old.acknowledge()
+				s.queued.Add(-1)
+				s.dropped.Add(1)
+				s.events.queued.Add(-1)
+				s.events.dropped.Add(1)
+				s.active.Add(-1)
+				s.pending.Add(-1)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case s.queue <- e:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			return ErrStopped
+		case <-s.closed:
+			return errUnsubscribed
+		}
+	}
+}
+
+// worker handles the events from the queue until it is closed
+// we do allow the ability to pass trhough a context to force the worker to
+// shutdown
+func (s *subscription) worker(ctx context.Context) {
+	for {
+		select {
+		case e, ok := <-s.queue:
+			if !ok {
+				// The queue has been closed, so the worker exits
+				return
+			}
+			s.queued.Add(-1)
+			s.events.queued.Add(-1)
+
+			s.handle(ctx, e)
+			s.active.Add(-1)
+			s.pending.Add(-1)
+		case <-ctx.Done():
+			// if context is canceled
+			// we should stop the worker
+			return
+		}
+	}
+}
+
+// handle calls the handler, a panic in the handler is recovered
+// so the worker keeps handling the next events
+func (s *subscription) handle(ctx context.Context, e *Event) {
+	start := time.Now()
+
+	state := &handling{name: s.name}
+
+	defer func() {
+		s.latency.observe(time.Since(start))
+
+		// the event is delivered again after a restart
+		// when its downstream events were not published
+		if state.failed.Load() {
+			s.failed.Add(1)
+		} else {
+			e.acknowledge()
+		}
+
+		if r := recover(); r != nil {
+			s.panics.Add(1)
+			logPanic(ctx, s.name, e, r)
+			return
+		}
+
+		// recovered by the Recover middleware
+		if state.panicked {
+			s.panics.Add(1)
+			return
+		}
+		s.handled.Add(1)
+	}()
+
+	// the events published by the handler keep the source and scrape ID
+	ctx = context.WithValue(WithMetadata(ctx, e.Metadata), handlingKey{}, state)
+	s.handler.Handle(ctx, e)
+}
@@ -0,0 +1,116 @@
+package bus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/log"
+)
+
+// Metadata describes where an event comes from
+type Metadata struct {
+	// The unique ID of the event, set on Publish
+	ID string
+
+	// When the event was published, set on Publish
+	Timestamp time.Time
+
+	// The name of the source the data was collected from
+	Source string
+
+	// The ID of the scrape the data was collected in, shared by all
+	// events derived from the same scrape
+	ScrapeID string
+
+	// The window the data was collected over, which is the time since
+	// the previous scrape of the source, or the step of a backfill
+	Window time.Duration `json:",omitempty"`
+
+	// Set on events replayed from the log and the events derived from
+	// them, which were handled before
+	Replayed bool `json:",omitempty"`
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a new context with the metadata added, the source,
+// scrape ID and window are set on the events published with the context
+func WithMetadata(ctx context.Context, m Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, m)
+}
+
+// MetadataFromContext returns the metadata in the context. Handlers are
+// called with the metadata of the event they handle, so the events they
+// publish keep the source and scrape ID.
+func MetadataFromContext(ctx context.Context) Metadata {
+	m, _ := ctx.Value(metadataKey{}).(Metadata)
+	return m
+}
+
+// NewID returns a random ID for events and scrapes
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// the time is unique enough when there is no randomness
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// Topic is an event type with typed data, so publishers and subscribers
+// agree on the type of the data at compile time
+type Topic[T any] struct {
+	bus   *Bus
+	event EventType
+}
+
+// NewTopic returns the topic of the event type on the bus, all data
+// published on the event type should be of type T. The events of the
+// topic are read from the log of the bus as T.
+func NewTopic[T any](b *Bus, e EventType) *Topic[T] {
+	registerDecoder[T](b, e)
+	return &Topic[T]{bus: b, event: e}
+}
+
+// Type returns the event type of the topic
+func (t *Topic[T]) Type() EventType {
+	return t.event
+}
+
+// Publish the data on the topic
+func (t *Topic[T]) Publish(ctx context.Context, data T) error {
+	return t.bus.Publish(ctx, &Event{Type: t.event, Data: data})
+}
+
+// Subscribe calls the function with the data of every event on the topic,
+// the metadata of the event is in the context. Use OnStop to clean up once
+// the subscription ends.
+func (t *Topic[T]) Subscribe(fn func(ctx context.Context, data T), opts ...SubscribeOption) *Subscription {
+	return t.bus.Subscribe(t.event, &typedHandler[T]{fn: fn}, opts...)
+}
+
+// typedHandler is the EventHandler of a topic subscriber
+type typedHandler[T any] struct {
+	fn func(ctx context.Context, data T)
+}
+
+func (h *typedHandler[T]) Handle(ctx context.Context, e *Event) {
+	data, ok := e.Data.(T)
+	if !ok {
+		var expected T
+		log.FromContext(ctx).Error("error unexpected data on topic",
+			"event", e.Type,
+			"id", e.Metadata.ID,
+			"expected", fmt.Sprintf("%T", expected),
+			"got", fmt.Sprintf("%T", e.Data),
+		)
+		return
+	}
+
+	h.fn(ctx, data)
+}
+
+func (h *typedHandler[T]) Stop(ctx context.Context) {}
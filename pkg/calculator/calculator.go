@@ -10,36 +10,104 @@ import (
 	"github.com/cnkei/gospline"
 	"github.com/re-cinq/aether/pkg/log"
 	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
 	data "github.com/re-cinq/emissions-data/pkg/types/v2"
 )
 
 type parameters struct {
-	grid           float64
-	pue            float64
-	metric         *v1.Metric
-	factors        *data.Instance
-	embodiedFactor float64
+	grid     float64
+	pue      float64
+	metric   *v1.Metric
+	machine  *factors.Machine
+	defaults *factors.ProviderDefaults
+	gpus     factors.GPUData
+	model    EnergyModel
+	embodied embodiedFactors
+
+	// the relative uncertainties of the inputs, where the energy
+	// uncertainty is set by the energy model for each metric
+	energyUncertainty float64
+	gridUncertainty   float64
+	pueUncertainty    float64
+
+	// set when the CPU wattage curve is made up of the
+	// min and max wattage of the machine
+	fallbackWattage bool
+
+	// set when the instance kind is not in the datasets and the
+	// factors are approximated from a sibling or the defaults
+	approximated bool
+
+	// the provenance shared by all the metrics of the instance,
+	// and the data quality flags set for each metric
+	provenance v1.Provenance
+	flags      []string
 }
 
-// operationalEmissions determines the correct function to run to calculate the
-// operational emissions for the metric type which stores the energy consumption
-// and the carbon emissions in the metric
+// operationalEmissions estimates the energy consumption of the metric with
+// the energy model and converts it into carbon emissions, both of which are
+// stored in the metric.
+//
+// Metrics with measured energy skip the estimation and only get converted.
 func operationalEmissions(ctx context.Context, interval time.Duration, p *parameters) error {
-	switch p.metric.Name {
-	case v1.CPU.String():
-		return cpu(ctx, interval, p)
-	case v1.Memory.String():
-		return memory(ctx, interval, p)
-	case v1.Storage.String():
-		return errors.New("error storage is not yet being calculated")
-	case v1.Network.String():
-		return errors.New("error networking is not yet being calculated")
-	default:
-		return fmt.Errorf("error metric not supported: %+v", p.metric.Name)
+	model := p.model
+	if p.metric.MeasuredEnergy {
+		model = measuredModel{}
+	}
+
+	if model == nil {
+		return errors.New("error energy model not set")
+	}
+
+	p.energyUncertainty = 0
+	p.flags = nil
+	err := model.Energy(ctx, interval, p)
+	if err != nil {
+		return err
+	}
+
+	if model.Name() != MeasuredModel {
+		p.flags = append(p.flags, v1.FlagEstimated)
+
+		if p.approximated && usageBased(p.metric.Name) {
+			p.energyUncertainty = combineUncertainty(p.energyUncertainty, approximatedUncertainty)
+			p.flags = append(p.flags, v1.FlagApproximated)
+		}
 	}
+
+	if p.metric.Usage == 0 && !p.metric.MeasuredEnergy && usageBased(p.metric.Name) {
+		p.flags = append(p.flags, v1.FlagMissingUsage)
+	}
+
+	p.metric.Provenance = p.provenance
+	p.metric.Provenance.EnergyModel = model.Name()
+	p.metric.Provenance.Flags = p.flags
+
+	// Operational Emissions are calculated by multiplying the energy, PUE,
+	// and region grid. The PUE is collected from the providers. The CO2e grid data
+	// is the grid carbon intensity coefficient for the region at the specified time.
+	p.metric.PUE = p.pue
+	p.metric.Emissions = v1.NewResourceEmission(
+		p.metric.Energy*p.pue*p.grid,
+		v1.GCO2eq,
+	).WithUncertainty(combineUncertainty(
+		p.energyUncertainty,
+		p.pueUncertainty,
+		p.gridUncertainty,
+	))
+
+	return nil
+}
+
+// usageBased returns true for the metrics the energy of
+// which is estimated from the utilization
+func usageBased(name string) bool {
+	return name == v1.CPU.String() ||
+		name == v1.Memory.String() ||
+		name == v1.GPU.String()
 }
 
-// cpu calculates the CO2e operational emissions for the CPU utilization of
+// cpu calculates the energy consumption for the CPU utilization of
 // a Cloud VM instance over an interval of time.
 //
 // The initial calculation uses the wattage conversion factor based on the turbostat and
@@ -49,7 +117,14 @@ func cpu(ctx context.Context, interval time.Duration, p *parameters) error {
 	logger := log.FromContext(ctx)
 
 	// TODO: remove casting once the type is changed in the factors data
-	vCPU := float64(p.factors.VCPU)
+	vCPU := float64(p.machine.VCPU)
+
+	p.energyUncertainty = splineUncertainty
+	if p.fallbackWattage {
+		p.energyUncertainty = linearUncertainty
+		p.flags = append(p.flags, v1.FlagFallbackWattage)
+	}
+
 	// vCPU are virtual CPUs that are mapped to physical cores (a core is a physical
 	// component to the CPU the VM is running on). If vCPU from the dataset (p.vCPU)
 	// is not found, get the number of vCPUs from the metric collected from the query
@@ -58,6 +133,8 @@ func cpu(ctx context.Context, interval time.Duration, p *parameters) error {
 			return errors.New("error vCPU set to 0")
 		}
 		vCPU = p.metric.UnitAmount
+		p.energyUncertainty = combineUncertainty(p.energyUncertainty, missingVCPUUncertainty)
+		p.flags = append(p.flags, v1.FlagMissingVCPU)
 	}
 
 	// vCPUHours represents the count of virtual CPUs within a specific time frame.
@@ -70,22 +147,14 @@ func cpu(ctx context.Context, interval time.Duration, p *parameters) error {
 	// energy is the CPU energy consumption in kilowatts.
 	// If pkgWatt values exist from the dataset, then use cubic spline interpolation
 	// to calculate the wattage based on utilization.
-	usage, err := cubicSplineInterpolation(p.factors.PkgWatt, p.metric.Usage)
+	usage, err := cubicSplineInterpolation(p.machine.PkgWatt, p.metric.Usage)
 	if err != nil {
 		return err
 	}
 
 	p.metric.Energy = usage * vCPUHours
 
-	// Operational Emissions are calculated by multiplying the energy, vCPUHours, PUE,
-	// and region grid. The PUE is collected from the providers. The CO2e grid data
-	// is the grid carbon intensity coefficient for the region at the specified time.
-	p.metric.Emissions = v1.NewResourceEmission(
-		p.metric.Energy*p.pue*p.grid,
-		v1.GCO2eq,
-	)
-
-	logger.Debug("CPU calculation", "energy usage", p.metric.Energy, "emissions", p.metric.Emissions)
+	logger.Debug("CPU calculation", "energy usage", p.metric.Energy)
 	return nil
 }
 
@@ -96,7 +165,7 @@ func memory(ctx context.Context, interval time.Duration, p *parameters) error {
 	logger := log.FromContext(ctx)
 	var err error
 
-	if reflect.DeepEqual(p.factors.RAMWatt, emptyWattage) {
+	if len(p.machine.RAMWatt) == 0 || reflect.DeepEqual(p.machine.RAMWatt, emptyWattage) {
 		return fmt.Errorf("not calculating memory - RAM wattage data not found")
 	}
 
@@ -104,25 +173,223 @@ func memory(ctx context.Context, interval time.Duration, p *parameters) error {
 		return fmt.Errorf("no memory usage found")
 	}
 
-	p.metric.Energy, err = cubicSplineInterpolation(p.factors.RAMWatt, p.metric.Usage)
+	// the memory of the instance from the dataset or, when the dataset
+	// does not have it, from the metric collected from the query
+	memoryGB := p.machine.MemoryGB
+	if memoryGB == 0 {
+		memoryGB = p.metric.UnitAmount
+	}
+	if memoryGB == 0 {
+		return errors.New("error memory set to 0")
+	}
+
+	p.metric.Energy, err = cubicSplineInterpolation(p.machine.RAMWatt, p.metric.Usage)
 	if err != nil {
 		return err
 	}
 
 	// RAMWatt is the energy consumption for a single GB of memory, so multiply
 	// the energy usage by the total instance memoryHours to get the energy consumption
-	memoryHours := (interval.Minutes() / float64(60)) * p.factors.MemoryGB
+	memoryHours := (interval.Minutes() / float64(60)) * memoryGB
 	p.metric.Energy *= memoryHours
+	p.energyUncertainty = splineUncertainty
 
-	p.metric.Emissions = v1.NewResourceEmission(
-		p.metric.Energy*p.pue*p.grid,
-		v1.GCO2eq,
+	logger.Debug("Memory calculation", "energy usage", p.metric.Energy)
+	return nil
+}
+
+// gpu calculates the energy consumption for the GPU utilization of
+// a Cloud VM instance over an interval of time.
+//
+// When the dataset has measured GPU wattage for the instance type, the
+// wattage is interpolated with a cubic spline like the CPU. Otherwise it
+// falls back to the linear GPU calculation.
+func gpu(ctx context.Context, interval time.Duration, p *parameters) error {
+	logger := log.FromContext(ctx)
+
+	if p.machine == nil || len(p.machine.GPUWatt) == 0 || reflect.DeepEqual(p.machine.GPUWatt, emptyWattage) {
+		return gpuLinear(ctx, interval, p)
+	}
+
+	count, err := gpuCount(p)
+	if err != nil {
+		return err
+	}
+
+	// the wattage in the dataset is for all the GPUs of the
+	// instance type, so it's scaled to the GPUs in the metric
+	usage, err := cubicSplineInterpolation(p.machine.GPUWatt, p.metric.Usage)
+	if err != nil {
+		return err
+	}
+
+	if p.machine.GPUCount > 0 {
+		usage = usage / float64(p.machine.GPUCount) * count
+	}
+	p.metric.Energy = usage * (interval.Minutes() / float64(60))
+	p.energyUncertainty = splineUncertainty
+
+	logger.Debug("GPU calculation", "energy usage", p.metric.Energy)
+	return nil
+}
+
+// gpuLinear calculates the energy consumption for the GPU utilization by
+// linearly interpolating between the idle and max wattage of the GPU model,
+// which is read from the metric labels or the dataset.
+func gpuLinear(ctx context.Context, interval time.Duration, p *parameters) error {
+	logger := log.FromContext(ctx)
+
+	count, err := gpuCount(p)
+	if err != nil {
+		return err
+	}
+
+	model := p.metric.Labels[v1.GPUModelLabel]
+	if model == "" && p.machine != nil {
+		model = p.machine.GPUName
+	}
+
+	specs, ok := p.gpus.Specs(model)
+	if !ok {
+		return fmt.Errorf("error GPU model not found: %q", model)
+	}
+
+	watts := specs.MinWatts + (p.metric.Usage/100)*(specs.MaxWatts-specs.MinWatts)
+
+	// divide by 1000 to get kilowatts
+	p.metric.Energy = watts / 1000 * count * (interval.Minutes() / float64(60))
+	p.energyUncertainty = linearUncertainty
+
+	logger.Debug("GPU calculation", "energy usage", p.metric.Energy)
+	return nil
+}
+
+// gpuCount returns the amount of GPUs attached to the instance, falling
+// back to the dataset if the source did not collect it
+func gpuCount(p *parameters) (float64, error) {
+	count := p.metric.UnitAmount
+	if count == 0 && p.machine != nil {
+		count = float64(p.machine.GPUCount)
+	}
+	if count == 0 {
+		return 0, errors.New("error GPU count set to 0")
+	}
+	return count, nil
+}
+
+// storage calculates the energy consumption of a disk over an
+// interval of time.
+//
+// Following the CCF methodology, disks draw a constant amount of power
+// per terabyte of provisioned capacity, regardless of their utilization.
+// The wattage differs between HDDs and SSDs, so the media of the disk is
+// read from the metric labels. When it is not set we fall back to SSD,
+// being the most common disk media offered by cloud providers.
+func storage(ctx context.Context, interval time.Duration, p *parameters) error {
+	logger := log.FromContext(ctx)
+
+	if p.defaults == nil {
+		return errors.New("error provider defaults not set for storage calculation")
+	}
+
+	if p.metric.UnitAmount == 0 {
+		return errors.New("error storage capacity set to 0")
+	}
+
+	tb, err := terabytes(p.metric.UnitAmount, p.metric.Unit)
+	if err != nil {
+		return err
+	}
+
+	var watts float64
+	switch p.metric.Labels[v1.DiskMediaLabel] {
+	case v1.DiskMediaHDD:
+		watts = p.defaults.HDDStorageWatts
+	case v1.DiskMediaSSD:
+		watts = p.defaults.SSDStorageWatts
+	default:
+		logger.Debug("disk media not found, falling back to SSD", "metric", p.metric.Name)
+		watts = p.defaults.SSDStorageWatts
+	}
+
+	// The storage wattage is given per terabyte, multiply it by the
+	// capacity and the interval in hours and divide by 1000 to get the
+	// energy consumption in kilowatt hours
+	p.metric.Energy = watts * tb * (interval.Minutes() / float64(60)) / 1000
+	p.energyUncertainty = coefficientUncertainty
+
+	logger.Debug("Storage calculation", "energy usage", p.metric.Energy)
+	return nil
+}
+
+// terabytes converts an amount of storage in the given unit to terabytes
+func terabytes(amount float64, unit v1.ResourceUnit) (float64, error) {
+	switch unit {
+	case v1.KB:
+		return amount / 1024 / 1024 / 1024, nil
+	case v1.MB:
+		return amount / 1024 / 1024, nil
+	case v1.GB:
+		return amount / 1024, nil
+	case v1.TB:
+		return amount, nil
+	case v1.PB:
+		return amount * 1024, nil
+	default:
+		return 0, fmt.Errorf("error storage unit not supported: %s", unit)
+	}
+}
+
+// network calculates the energy consumption of the data transferred
+// over an interval of time.
+//
+// The metric holds the average transfer rate over the interval, which is
+// converted into the amount of gigabytes transferred and multiplied by the
+// networking coefficient of the provider. Where the source labels the
+// network scope (intra-zone, inter-region or internet egress) the
+// coefficient for that scope is used.
+func network(ctx context.Context, interval time.Duration, p *parameters) error {
+	logger := log.FromContext(ctx)
+
+	if p.defaults == nil {
+		return errors.New("error provider defaults not set for network calculation")
+	}
+
+	rate, err := gigabytesPerSecond(p.metric.UnitAmount, p.metric.Unit)
+	if err != nil {
+		return err
+	}
+
+	transferred := rate * interval.Seconds()
+
+	p.metric.Energy = transferred * p.defaults.NetworkingCoefficient(
+		p.metric.Labels[v1.NetworkScopeLabel],
 	)
+	p.energyUncertainty = coefficientUncertainty
 
-	logger.Debug("Memory calculation", "energy usage", p.metric.Energy, "emissions", p.metric.Emissions)
+	logger.Debug("Network calculation", "energy usage", p.metric.Energy)
 	return nil
 }
 
+// gigabytesPerSecond converts a transfer rate in the given unit, which
+// are bits per second, to gigabytes per second
+func gigabytesPerSecond(rate float64, unit v1.ResourceUnit) (float64, error) {
+	bytes := rate / 8
+
+	switch unit {
+	case v1.KBs:
+		return bytes / 1024 / 1024, nil
+	case v1.MBs:
+		return bytes / 1024, nil
+	case v1.GBs:
+		return bytes, nil
+	case v1.TBs:
+		return bytes * 1024, nil
+	default:
+		return 0, fmt.Errorf("error network unit not supported: %s", unit)
+	}
+}
+
 // cubicSplineInterpolation is a piecewise cubic polynomials that takes the
 // four measured wattage data points at 0%, 10%, 50%, and 100% utilization
 // and interpolates a value for the usage (%) value and returns the energy
@@ -145,11 +412,3 @@ func cubicSplineInterpolation(wattage []data.Wattage, value float64) (float64, e
 	// divide by 1000 to get kilowatts.
 	return s.At(value) / 1000, nil
 }
-
-// EmbodiedEmissions are the released emissions of production and destruction of the
-// hardware
-func embodiedEmissions(interval time.Duration, hourlyEmbodied float64) float64 {
-	// The embodied emissions need to be calculated for the measurement interval, so the
-	// hourly emissions further divided to the interval minutes.
-	return hourlyEmbodied / float64(60) * float64(interval.Minutes())
-}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
 	data "github.com/re-cinq/emissions-data/pkg/types/v2"
 	"github.com/stretchr/testify/assert"
 )
@@ -20,47 +21,54 @@ func params() *parameters {
 		pue:    1.2,
 		metric: m,
 		// using t3.micro AWS instance as default
-		factors: &data.Instance{
-			PkgWatt: []data.Wattage{
-				{
-					Percentage: 0,
-					Wattage:    1.21,
-				},
-				{
-					Percentage: 10,
-					Wattage:    3.05,
-				},
-				{
-					Percentage: 50,
-					Wattage:    7.16,
+		machine: &factors.Machine{
+			Instance: data.Instance{
+				PkgWatt: []data.Wattage{
+					{
+						Percentage: 0,
+						Wattage:    1.21,
+					},
+					{
+						Percentage: 10,
+						Wattage:    3.05,
+					},
+					{
+						Percentage: 50,
+						Wattage:    7.16,
+					},
+					{
+						Percentage: 100,
+						Wattage:    9.96,
+					},
 				},
-				{
-					Percentage: 100,
-					Wattage:    9.96,
+				RAMWatt: []data.Wattage{
+					{
+						Percentage: 0,
+						Wattage:    0.15,
+					},
+					{
+						Percentage: 10,
+						Wattage:    0.24,
+					},
+					{
+						Percentage: 50,
+						Wattage:    0.62,
+					},
+					{
+						Percentage: 100,
+						Wattage:    1.00,
+					},
 				},
+				VCPU:     2.0,
+				MemoryGB: 1,
 			},
-			RAMWatt: []data.Wattage{
-				{
-					Percentage: 0,
-					Wattage:    0.15,
-				},
-				{
-					Percentage: 10,
-					Wattage:    0.24,
-				},
-				{
-					Percentage: 50,
-					Wattage:    0.62,
-				},
-				{
-					Percentage: 100,
-					Wattage:    1.00,
-				},
-			},
-			VCPU:     2.0,
-			MemoryGB: 1,
 		},
-		embodiedFactor: 1000,
+		defaults: &factors.ProviderDefaults{
+			HDDStorageWatts:          0.65,
+			SSDStorageWatts:          1.22,
+			NetworkingKilloWattHours: 0.001,
+		},
+		model: splineModel{},
 	}
 }
 
@@ -92,7 +100,7 @@ func TestCalculateCPU(t *testing.T) {
 		func() *testcase {
 			// vCPUs not set in params, but set in metric
 			p := params()
-			p.factors.VCPU = 0
+			p.machine.VCPU = 0
 			p.metric.UnitAmount = 2
 			p.metric.Unit = v1.VCPU
 			return &testcase{
@@ -109,7 +117,7 @@ func TestCalculateCPU(t *testing.T) {
 		func() *testcase {
 			// vCPUs not set
 			p := params()
-			p.factors.VCPU = 0
+			p.machine.VCPU = 0
 			return &testcase{
 				name:     "vCPU not set",
 				interval: 5 * time.Minute,
@@ -158,7 +166,7 @@ func TestCalculateCPU(t *testing.T) {
 		func() *testcase {
 			// calculate with 4 vCPUs
 			p := params()
-			p.factors.VCPU = 4
+			p.machine.VCPU = 4
 			return &testcase{
 				name:     "4 vCPU",
 				interval: 5 * time.Minute,
@@ -225,9 +233,12 @@ func TestCalculateCPU(t *testing.T) {
 		}(),
 	} {
 		t.Run(test.name, func(t *testing.T) {
-			err := cpu(context.TODO(), test.interval, test.params)
+			test.params.metric.Name = v1.CPU.String()
+			err := operationalEmissions(context.TODO(), test.interval, test.params)
 			actualEnergy := test.params.metric.Energy
+			// the bounds are tested separately
 			actualEmissions := test.params.metric.Emissions
+			actualEmissions.Low, actualEmissions.High = 0, 0
 
 			assert.Equalf(t, test.energy, actualEnergy, "Result should be: %v, got: %v", test.energy, actualEnergy)
 			assert.Equalf(t, test.emissions, actualEmissions, "Result should be: %v, got: %v", test.emissions, actualEmissions)
@@ -343,7 +354,7 @@ func TestCalculateMemory(t *testing.T) {
 		func() *testcase {
 			// fail: powerRAM wattage not set
 			p := params()
-			p.factors.RAMWatt = []data.Wattage{}
+			p.machine.RAMWatt = []data.Wattage{}
 			return &testcase{
 				name:      "fail: wattage RAM data not set",
 				params:    p,
@@ -353,9 +364,35 @@ func TestCalculateMemory(t *testing.T) {
 				expErr:    "RAM wattage data not found for memory calculation",
 			}
 		}(),
+		func() *testcase {
+			// pass: memory of the instance from the metric
+			p := params()
+			p.machine.MemoryGB = 0
+			p.metric.UnitAmount = 1
+			return &testcase{
+				name:      "memory from the metric",
+				params:    p,
+				interval:  5 * time.Minute,
+				energy:    3.353343394308943e-05,
+				emissions: 0.0002816808451219512,
+			}
+		}(),
+		func() *testcase {
+			// fail: memory of the instance not known
+			p := params()
+			p.machine.MemoryGB = 0
+			return &testcase{
+				name:     "fail: memory set to 0",
+				params:   p,
+				interval: 5 * time.Minute,
+				hasErr:   true,
+				expErr:   "error memory set to 0",
+			}
+		}(),
 	} {
 		t.Run(test.name, func(t *testing.T) {
-			err := memory(context.TODO(), test.interval, test.params)
+			test.params.metric.Name = v1.Memory.String()
+			err := operationalEmissions(context.TODO(), test.interval, test.params)
 			actualEmissions := test.params.metric.Emissions.Value
 			actualEnergy := test.params.metric.Energy
 
@@ -369,3 +406,307 @@ func TestCalculateMemory(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateStorage(t *testing.T) {
+	type testcase struct {
+		name      string
+		params    *parameters
+		interval  time.Duration // this is nanoseconds
+		emissions float64
+		energy    float64
+		hasErr    bool
+		expErr    string
+	}
+	for _, test := range []*testcase{
+		func() *testcase {
+			// pass: 100GB SSD disk
+			p := params()
+			p.metric.UnitAmount = 100
+			p.metric.Unit = v1.GB
+			p.metric.Labels = v1.Labels{v1.DiskMediaLabel: v1.DiskMediaSSD}
+			return &testcase{
+				name:      "100GB SSD over 5m",
+				params:    p,
+				interval:  5 * time.Minute,
+				energy:    9.928385416666666e-06,
+				emissions: 8.339843749999999e-05,
+			}
+		}(),
+		func() *testcase {
+			// pass: 2TB HDD disk
+			p := params()
+			p.metric.UnitAmount = 2
+			p.metric.Unit = v1.TB
+			p.metric.Labels = v1.Labels{v1.DiskMediaLabel: v1.DiskMediaHDD}
+			return &testcase{
+				name:      "2TB HDD over 1h",
+				params:    p,
+				interval:  1 * time.Hour,
+				energy:    0.0013,
+				emissions: 0.01092,
+			}
+		}(),
+		func() *testcase {
+			// pass: disk media not set falls back to SSD
+			p := params()
+			p.metric.UnitAmount = 100
+			p.metric.Unit = v1.GB
+			return &testcase{
+				name:      "disk media not set",
+				params:    p,
+				interval:  5 * time.Minute,
+				energy:    9.928385416666666e-06,
+				emissions: 8.339843749999999e-05,
+			}
+		}(),
+		func() *testcase {
+			// fail: disk capacity not set
+			p := params()
+			p.metric.Unit = v1.GB
+			return &testcase{
+				name:     "fail: capacity not set",
+				params:   p,
+				interval: 5 * time.Minute,
+				hasErr:   true,
+				expErr:   "error storage capacity set to 0",
+			}
+		}(),
+		func() *testcase {
+			// fail: unit is not a storage unit
+			p := params()
+			p.metric.UnitAmount = 100
+			p.metric.Unit = v1.VCPU
+			return &testcase{
+				name:     "fail: unsupported unit",
+				params:   p,
+				interval: 5 * time.Minute,
+				hasErr:   true,
+				expErr:   "error storage unit not supported: vCPU",
+			}
+		}(),
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.params.metric.Name = v1.Storage.String()
+			err := operationalEmissions(context.TODO(), test.interval, test.params)
+			actualEmissions := test.params.metric.Emissions.Value
+			actualEnergy := test.params.metric.Energy
+
+			assert.Equalf(t, test.energy, actualEnergy, "Result should be: %v, got: %v", test.energy, actualEnergy)
+			assert.Equalf(t, test.emissions, actualEmissions, "Result should be: %v, got: %v", test.emissions, actualEmissions)
+			if test.hasErr {
+				assert.EqualError(t, err, test.expErr)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestGigabytesPerSecond(t *testing.T) {
+	// the units are bits per second
+	for unit, rate := range map[v1.ResourceUnit]float64{
+		v1.KBs: 8 * 1024 * 1024,
+		v1.MBs: 8 * 1024,
+		v1.GBs: 8,
+		v1.TBs: 8.0 / 1024,
+	} {
+		gb, err := gigabytesPerSecond(rate, unit)
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, gb, unit)
+	}
+}
+
+func TestCalculateNetwork(t *testing.T) {
+	type testcase struct {
+		name      string
+		params    *parameters
+		interval  time.Duration // this is nanoseconds
+		emissions float64
+		energy    float64
+		hasErr    bool
+		expErr    string
+	}
+	for _, test := range []*testcase{
+		func() *testcase {
+			// pass: 1Mb/s without a network scope
+			p := params()
+			p.metric.UnitAmount = 1
+			p.metric.Unit = v1.MBs
+			return &testcase{
+				name:      "1Mb/s over 5m",
+				params:    p,
+				interval:  5 * time.Minute,
+				energy:    0.000036621093750,
+				emissions: 0.000307617187500,
+			}
+		}(),
+		func() *testcase {
+			// pass: internet egress uses its own coefficient
+			p := params()
+			p.metric.UnitAmount = 1
+			p.metric.Unit = v1.MBs
+			p.metric.Labels = v1.Labels{v1.NetworkScopeLabel: v1.NetworkScopeInternet}
+			p.defaults.NetworkingInternetKilloWattHours = 0.002
+			return &testcase{
+				name:      "1Mb/s internet egress over 5m",
+				params:    p,
+				interval:  5 * time.Minute,
+				energy:    0.000073242187500,
+				emissions: 0.000615234375000,
+			}
+		}(),
+		func() *testcase {
+			// pass: scope without a coefficient falls back to the default
+			p := params()
+			p.metric.UnitAmount = 1
+			p.metric.Unit = v1.MBs
+			p.metric.Labels = v1.Labels{v1.NetworkScopeLabel: v1.NetworkScopeInterRegion}
+			p.defaults.NetworkingInternetKilloWattHours = 0.002
+			return &testcase{
+				name:      "inter-region falls back to default coefficient",
+				params:    p,
+				interval:  5 * time.Minute,
+				energy:    0.000036621093750,
+				emissions: 0.000307617187500,
+			}
+		}(),
+		func() *testcase {
+			// fail: unit is not a bandwidth unit
+			p := params()
+			p.metric.UnitAmount = 1
+			p.metric.Unit = v1.GB
+			return &testcase{
+				name:     "fail: unsupported unit",
+				params:   p,
+				interval: 5 * time.Minute,
+				hasErr:   true,
+				expErr:   "error network unit not supported: GB",
+			}
+		}(),
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.params.metric.Name = v1.Network.String()
+			err := operationalEmissions(context.TODO(), test.interval, test.params)
+			actualEmissions := test.params.metric.Emissions.Value
+			actualEnergy := test.params.metric.Energy
+
+			assert.Equalf(t, test.energy, actualEnergy, "Result should be: %v, got: %v", test.energy, actualEnergy)
+			assert.Equalf(t, test.emissions, actualEmissions, "Result should be: %v, got: %v", test.emissions, actualEmissions)
+			if test.hasErr {
+				assert.EqualError(t, err, test.expErr)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestCalculateGPU(t *testing.T) {
+	type testcase struct {
+		name      string
+		params    *parameters
+		interval  time.Duration // this is nanoseconds
+		emissions float64
+		energy    float64
+		hasErr    bool
+		expErr    string
+	}
+	for _, test := range []*testcase{
+		func() *testcase {
+			// pass: wattage from the dataset
+			p := params()
+			p.metric.UnitAmount = 1
+			p.machine.GPUCount = 1
+			p.machine.GPUWatt = []data.Wattage{
+				{
+					Percentage: 0,
+					Wattage:    10,
+				},
+				{
+					Percentage: 10,
+					Wattage:    25,
+				},
+				{
+					Percentage: 50,
+					Wattage:    50,
+				},
+				{
+					Percentage: 100,
+					Wattage:    70,
+				},
+			}
+			return &testcase{
+				name:      "dataset wattage at 27% over 5m",
+				params:    p,
+				interval:  5 * time.Minute,
+				energy:    0.003398045350609756,
+				emissions: 0.028543580945121945,
+			}
+		}(),
+		func() *testcase {
+			// pass: GPU model specs from the metric label
+			p := params()
+			p.metric.UnitAmount = 2
+			p.metric.Labels = v1.Labels{v1.GPUModelLabel: "nvidia-tesla-t4"}
+			return &testcase{
+				name:      "2 T4 GPUs at 27% over 5m",
+				params:    p,
+				interval:  5 * time.Minute,
+				energy:    0.004366666666666667,
+				emissions: 0.036680000000000004,
+			}
+		}(),
+		func() *testcase {
+			// pass: GPU count and model from the dataset
+			p := params()
+			p.machine.GPUCount = 2
+			p.machine.GPUName = "T4"
+			return &testcase{
+				name:      "GPU count and model from dataset",
+				params:    p,
+				interval:  5 * time.Minute,
+				energy:    0.004366666666666667,
+				emissions: 0.036680000000000004,
+			}
+		}(),
+		func() *testcase {
+			// fail: no GPUs
+			p := params()
+			return &testcase{
+				name:     "fail: GPU count not set",
+				params:   p,
+				interval: 5 * time.Minute,
+				hasErr:   true,
+				expErr:   "error GPU count set to 0",
+			}
+		}(),
+		func() *testcase {
+			// fail: unknown GPU model
+			p := params()
+			p.metric.UnitAmount = 1
+			p.metric.Labels = v1.Labels{v1.GPUModelLabel: "unknown"}
+			return &testcase{
+				name:     "fail: unknown GPU model",
+				params:   p,
+				interval: 5 * time.Minute,
+				hasErr:   true,
+				expErr:   `error GPU model not found: "unknown"`,
+			}
+		}(),
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.params.metric.Name = v1.GPU.String()
+			err := operationalEmissions(context.TODO(), test.interval, test.params)
+			actualEmissions := test.params.metric.Emissions.Value
+			actualEnergy := test.params.metric.Energy
+
+			assert.Equalf(t, test.energy, actualEnergy, "Result should be: %v, got: %v", test.energy, actualEnergy)
+			assert.Equalf(t, test.emissions, actualEmissions, "Result should be: %v, got: %v", test.emissions, actualEmissions)
+			if test.hasErr {
+				assert.EqualError(t, err, test.expErr)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package calculator
+
+import (
+	"strings"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/config"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+)
+
+// AWS, GCP and Azure have increased their server lifespan to 6 years (2024)
+// https://sustainability.aboutamazon.com/products-services/the-cloud?energyType=true
+// https://www.theregister.com/2024/01/31/alphabet_q4_2023/
+// https://www.theregister.com/2022/08/02/microsoft_server_life_extension/
+const serverLifespan = 6
+
+// embodiedFactors are the hourly embodied emissions of an instance
+// per hardware component
+type embodiedFactors struct {
+	cpu     float64
+	memory  float64
+	storage float64
+	gpu     float64
+}
+
+// total returns the hourly embodied emissions of all components
+func (e embodiedFactors) total() float64 {
+	return e.cpu + e.memory + e.storage + e.gpu
+}
+
+// hourlyEmbodiedEmissions splits the embodied emissions of the platform
+// into its components and amortizes each of them by the share of the
+// component reserved by the instance over the lifespan of the server.
+//
+// This is based on CCF's calculation:
+//
+// M = TE * (TR/EL) * (RR/TR)
+//
+// TE = Total Embodied Emissions
+// TR = Time Reserved (in years)
+// EL = Expected Lifespan
+// RR = Resources Reserved
+// TR = Total Resources, the total number of resources available.
+func hourlyEmbodiedEmissions(m *factors.Machine, lifespan float64) embodiedFactors {
+	if lifespan <= 0 {
+		lifespan = serverLifespan
+	}
+
+	// 1 hour normalized to a year
+	hourly := (1.0 / 24.0 / 365.0) / lifespan
+
+	// amount of vCPUS for instance versus total vCPUS for platform,
+	// which is used for the components we do not know the share of
+	var vCPURatio float64
+	if m.Platform.VCPU > 0 {
+		vCPURatio = float64(m.VCPU) / float64(m.Platform.VCPU)
+	}
+
+	// GPUs are reserved as a whole by an instance, so when the GPU
+	// counts are known the GPU emissions are amortized by the amount
+	// of GPUs for the instance versus total GPUs for the platform
+	gpuRatio := vCPURatio
+	if m.Platform.GPUCount > 0 {
+		gpuRatio = float64(m.GPUCount) / float64(m.Platform.GPUCount)
+	}
+
+	memoryRatio := vCPURatio
+	if m.Platform.MemoryGB > 0 {
+		memoryRatio = m.MemoryGB / m.Platform.MemoryGB
+	}
+
+	// the base platform and the additional CPUs are attributed to the CPU
+	base := m.Platform.TotalScope3 -
+		m.Platform.MemoryScope3 -
+		m.Platform.StorageScope3 -
+		m.Platform.GPUScope3
+
+	return embodiedFactors{
+		cpu:     base * hourly * vCPURatio,
+		memory:  m.Platform.MemoryScope3 * hourly * memoryRatio,
+		storage: m.Platform.StorageScope3 * hourly * vCPURatio,
+		gpu:     m.Platform.GPUScope3 * hourly * gpuRatio,
+	}
+}
+
+// embodiedEmissions are the released emissions of production and destruction
+// of the hardware over the interval, per hardware component
+func embodiedEmissions(interval time.Duration, hourly embodiedFactors) v1.EmbodiedBreakdown {
+	// The embodied emissions need to be calculated for the measurement interval, so the
+	// hourly emissions further divided to the interval minutes.
+	forInterval := func(h float64) v1.ResourceEmissions {
+		return v1.NewResourceEmission(
+			h/float64(60)*interval.Minutes(),
+			v1.GCO2eq,
+		).WithUncertainty(embodiedUncertainty)
+	}
+
+	return v1.EmbodiedBreakdown{
+		CPU:     forInterval(hourly.cpu),
+		Memory:  forInterval(hourly.memory),
+		Storage: forInterval(hourly.storage),
+		GPU:     forInterval(hourly.gpu),
+	}
+}
+
+// lifespan returns the server lifespan in years configured for the
+// machine family of the instance kind, where a lifespan configured
+// for the family takes precedence over one configured for the whole
+// provider. If neither is configured the default lifespan is used.
+func lifespan(cfg *config.CalculatorConfig, provider v1.Provider, kind string) float64 {
+	years := float64(serverLifespan)
+	if cfg == nil {
+		return years
+	}
+
+	if cfg.ServerLifespan > 0 {
+		years = cfg.ServerLifespan
+	}
+
+	family := machineFamily(kind)
+
+	// a lifespan configured for the provider overrides the default
+	for _, l := range cfg.Lifespans {
+		if l.Provider == provider && l.Family == "" && l.Years > 0 {
+			years = l.Years
+		}
+	}
+
+	// a lifespan configured for the family overrides the provider
+	for _, l := range cfg.Lifespans {
+		if l.Provider == provider && l.Family != "" && l.Family == family && l.Years > 0 {
+			years = l.Years
+		}
+	}
+
+	return years
+}
+
+// machineFamily returns the family of an instance kind, for example
+// n2 for n2-standard-2 (GCP) or m6i for m6i.2xlarge (AWS)
+func machineFamily(kind string) string {
+	if i := strings.Index(kind, "."); i > 0 {
+		return kind[:i]
+	}
+
+	if i := strings.Index(kind, "-"); i > 0 {
+		return kind[:i]
+	}
+
+	return kind
+}
@@ -0,0 +1,97 @@
+package calculator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/config"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHourlyEmbodiedBreakdown(t *testing.T) {
+	// a quarter of the vCPUs, half of the memory and one of the GPUs
+	specs := &factors.Embodied{
+		TotalEmbodiedKiloWattCO2e:     8760 * 6 * 4,
+		AdditionalMemoryKiloWattCO2e:  8760 * 6,
+		AdditionalStorageKiloWattCO2e: 8760 * 6,
+		AdditionalGPUsKiloWattCO2e:    8760 * 6,
+		VCPU:                          8,
+		TotalVCPU:                     32,
+		MemoryGB:                      64,
+		TotalMemoryGB:                 128,
+		GPU:                           1,
+		TotalGPU:                      4,
+	}
+
+	m := factors.MachineFromEmbodied(specs)
+	e := hourlyEmbodiedEmissions(&m, 6)
+	assert.InDelta(t, 0.25, e.cpu, 1e-12)
+	assert.InDelta(t, 0.5, e.memory, 1e-12)
+	assert.InDelta(t, 0.25, e.storage, 1e-12)
+	assert.InDelta(t, 0.25, e.gpu, 1e-12)
+
+	// a longer lifespan amortizes the emissions over more hours
+	e = hourlyEmbodiedEmissions(&m, 12)
+	assert.InDelta(t, 0.625, e.total(), 1e-12)
+
+	// unknown memory and GPU shares fall back to the vCPU share
+	specs.TotalMemoryGB = 0
+	specs.TotalGPU = 0
+	m = factors.MachineFromEmbodied(specs)
+	e = hourlyEmbodiedEmissions(&m, 6)
+	assert.InDelta(t, 0.25, e.memory, 1e-12)
+	assert.InDelta(t, 0.25, e.gpu, 1e-12)
+
+	// no vCPU data does not produce NaN
+	m = factors.MachineFromEmbodied(&factors.Embodied{TotalEmbodiedKiloWattCO2e: 1000})
+	e = hourlyEmbodiedEmissions(&m, 6)
+	assert.Equal(t, 0.0, e.total())
+}
+
+func TestEmbodiedEmissions(t *testing.T) {
+	b := embodiedEmissions(5*time.Minute, embodiedFactors{
+		cpu:     12,
+		memory:  6,
+		storage: 1.2,
+		gpu:     24,
+	})
+
+	assert.Equal(t, v1.NewResourceEmission(1, v1.GCO2eq).WithUncertainty(embodiedUncertainty), b.CPU)
+	assert.Equal(t, v1.NewResourceEmission(0.5, v1.GCO2eq).WithUncertainty(embodiedUncertainty), b.Memory)
+	assert.InDelta(t, 0.1, b.Storage.Value, 1e-12)
+	assert.Equal(t, v1.NewResourceEmission(2, v1.GCO2eq).WithUncertainty(embodiedUncertainty), b.GPU)
+	assert.InDelta(t, 3.6, b.Total(), 1e-12)
+
+	// the bounds of the total are the sum of the bounds
+	total := b.TotalEmissions()
+	assert.InDelta(t, 3.6, total.Value, 1e-12)
+	assert.InDelta(t, 3.6*0.7, total.Low, 1e-12)
+	assert.InDelta(t, 3.6*1.3, total.High, 1e-12)
+	assert.Equal(t, v1.GCO2eq, total.Unit)
+}
+
+func TestLifespan(t *testing.T) {
+	cfg := &config.CalculatorConfig{
+		ServerLifespan: 5,
+		Lifespans: []config.LifespanConfig{
+			{Provider: v1.GCP, Family: "n2", Years: 3},
+			{Provider: v1.GCP, Years: 4},
+			{Provider: v1.AWS, Family: "p4d", Years: 2},
+		},
+	}
+
+	assert.Equal(t, 6.0, lifespan(nil, v1.GCP, "n2-standard-2"))
+	assert.Equal(t, 6.0, lifespan(&config.CalculatorConfig{}, v1.GCP, "n2-standard-2"))
+	assert.Equal(t, 3.0, lifespan(cfg, v1.GCP, "n2-standard-2"))
+	assert.Equal(t, 4.0, lifespan(cfg, v1.GCP, "e2-standard-2"))
+	assert.Equal(t, 2.0, lifespan(cfg, v1.AWS, "p4d.24xlarge"))
+	assert.Equal(t, 5.0, lifespan(cfg, v1.AWS, "m6i.2xlarge"))
+}
+
+func TestMachineFamily(t *testing.T) {
+	assert.Equal(t, "n2", machineFamily("n2-standard-2"))
+	assert.Equal(t, "m6i", machineFamily("m6i.2xlarge"))
+	assert.Equal(t, "custom", machineFamily("custom"))
+}
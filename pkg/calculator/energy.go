@@ -0,0 +1,195 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/config"
+	"github.com/re-cinq/aether/pkg/log"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+)
+
+const (
+	// SplineModel interpolates the wattage measured at different
+	// utilizations with a cubic spline, as inspired by Teads
+	SplineModel = "spline"
+
+	// LinearModel linearly interpolates between the min and max
+	// wattage of the machine, as done by the CCF
+	LinearModel = "linear"
+
+	// MeasuredModel uses the energy reported by the source as is
+	MeasuredModel = "measured"
+)
+
+// EnergyModel estimates the energy consumption of a metric in kilowatt hours
+// and stores it in the metric. Converting the energy into carbon emissions
+// (PUE and grid intensity) is done by the calculator afterwards, so the
+// models only need to concern themselves with energy.
+type EnergyModel interface {
+	// Name returns the name the model is configured with
+	Name() string
+
+	// Energy calculates the energy consumption of the metric
+	// in the parameters over the interval of time
+	Energy(ctx context.Context, interval time.Duration, p *parameters) error
+}
+
+// energyModels is a lookup table of all the supported energy models
+var energyModels = map[string]EnergyModel{
+	SplineModel:   splineModel{},
+	LinearModel:   linearModel{},
+	MeasuredModel: measuredModel{},
+}
+
+// splineModel uses the Teads wattage curves for the CPU, memory and GPU
+// and the provider coefficients for storage and networking
+type splineModel struct{}
+
+func (splineModel) Name() string { return SplineModel }
+
+func (splineModel) Energy(ctx context.Context, interval time.Duration, p *parameters) error {
+	switch p.metric.Name {
+	case v1.CPU.String():
+		return cpu(ctx, interval, p)
+	case v1.Memory.String():
+		return memory(ctx, interval, p)
+	case v1.GPU.String():
+		return gpu(ctx, interval, p)
+	case v1.Storage.String():
+		return storage(ctx, interval, p)
+	case v1.Network.String():
+		return network(ctx, interval, p)
+	default:
+		return fmt.Errorf("error metric not supported: %+v", p.metric.Name)
+	}
+}
+
+// linearModel uses the min and max wattage of the machine for the CPU,
+// the memory coefficient of the provider and the idle and max wattage of
+// the GPU model. Storage and networking use the provider coefficients.
+type linearModel struct{}
+
+func (linearModel) Name() string { return LinearModel }
+
+func (linearModel) Energy(ctx context.Context, interval time.Duration, p *parameters) error {
+	switch p.metric.Name {
+	case v1.CPU.String():
+		return cpuLinear(ctx, interval, p)
+	case v1.Memory.String():
+		return memoryLinear(ctx, interval, p)
+	case v1.GPU.String():
+		return gpuLinear(ctx, interval, p)
+	case v1.Storage.String():
+		return storage(ctx, interval, p)
+	case v1.Network.String():
+		return network(ctx, interval, p)
+	default:
+		return fmt.Errorf("error metric not supported: %+v", p.metric.Name)
+	}
+}
+
+// measuredModel is used for sources that already report the energy
+// consumption, so there is nothing to estimate
+type measuredModel struct{}
+
+func (measuredModel) Name() string { return MeasuredModel }
+
+func (measuredModel) Energy(ctx context.Context, interval time.Duration, p *parameters) error {
+	p.energyUncertainty = measuredUncertainty
+	return nil
+}
+
+// cpuLinear calculates the energy consumption for the CPU utilization by
+// linearly interpolating between the min and max wattage per vCPU of the
+// machine architecture, as described by the CCF:
+//
+// Average Watts = Min Watts + Avg vCPU Utilization * (Max Watts - Min Watts)
+func cpuLinear(ctx context.Context, interval time.Duration, p *parameters) error {
+	logger := log.FromContext(ctx)
+
+	if p.machine == nil {
+		return errors.New("error machine not set for linear CPU calculation")
+	}
+
+	vCPU := p.metric.UnitAmount
+	if p.machine.VCPU != 0 {
+		vCPU = float64(p.machine.VCPU)
+	}
+	if vCPU == 0 {
+		return errors.New("error vCPU set to 0")
+	}
+
+	vCPUHours := (interval.Minutes() / float64(60)) * vCPU
+
+	watts := p.machine.MinWatts + (p.metric.Usage/100)*(p.machine.MaxWatts-p.machine.MinWatts)
+
+	// divide by 1000 to get kilowatts
+	p.metric.Energy = watts / 1000 * vCPUHours
+	p.energyUncertainty = linearUncertainty
+
+	logger.Debug("CPU calculation", "energy usage", p.metric.Energy)
+	return nil
+}
+
+// memoryLinear calculates the energy consumption of the memory by
+// multiplying the memory in GB with the memory coefficient of the provider,
+// which is given in kilowatt hours per GB
+func memoryLinear(ctx context.Context, interval time.Duration, p *parameters) error {
+	logger := log.FromContext(ctx)
+
+	if p.defaults == nil {
+		return errors.New("error provider defaults not set for linear memory calculation")
+	}
+
+	memoryGB := p.metric.UnitAmount
+	if p.machine != nil && p.machine.MemoryGB != 0 {
+		memoryGB = p.machine.MemoryGB
+	}
+	if memoryGB == 0 {
+		return errors.New("error memory set to 0")
+	}
+
+	memoryHours := (interval.Minutes() / float64(60)) * memoryGB
+	p.metric.Energy = p.defaults.MemoryKilloWattHours * memoryHours
+	p.energyUncertainty = linearUncertainty
+
+	logger.Debug("Memory calculation", "energy usage", p.metric.Energy)
+	return nil
+}
+
+// energyModel returns the energy model configured for the provider and
+// service, where a model configured for the service takes precedence over
+// one configured for the whole provider. If neither is configured the
+// default model is used. Metrics with MeasuredEnergy set always use the
+// measured model, regardless of the configuration.
+func energyModel(cfg *config.CalculatorConfig, provider v1.Provider, service string) (EnergyModel, error) {
+	name := SplineModel
+	if cfg != nil {
+		if cfg.EnergyModel != "" {
+			name = cfg.EnergyModel
+		}
+
+		// a model configured for the provider overrides the default
+		for _, m := range cfg.EnergyModels {
+			if m.Provider == provider && m.Service == "" {
+				name = m.Model
+			}
+		}
+
+		// a model configured for the service overrides the provider
+		for _, m := range cfg.EnergyModels {
+			if m.Provider == provider && m.Service != "" && m.Service == service {
+				name = m.Model
+			}
+		}
+	}
+
+	model, ok := energyModels[name]
+	if !ok {
+		return nil, fmt.Errorf("error energy model not supported: %s", name)
+	}
+	return model, nil
+}
@@ -0,0 +1,153 @@
+package calculator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/config"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnergyModel(t *testing.T) {
+	cfg := &config.CalculatorConfig{
+		EnergyModel: LinearModel,
+		EnergyModels: []config.EnergyModelConfig{
+			{
+				Provider: v1.Prometheus,
+				Service:  "kepler",
+				Model:    MeasuredModel,
+			},
+			{
+				Provider: v1.AWS,
+				Model:    SplineModel,
+			},
+		},
+	}
+
+	for _, test := range []struct {
+		name     string
+		cfg      *config.CalculatorConfig
+		provider v1.Provider
+		service  string
+		expModel string
+		expErr   string
+	}{
+		{
+			name:     "no config uses spline",
+			provider: v1.GCP,
+			expModel: SplineModel,
+		},
+		{
+			name:     "default model",
+			cfg:      cfg,
+			provider: v1.GCP,
+			service:  "GCE",
+			expModel: LinearModel,
+		},
+		{
+			name:     "provider model",
+			cfg:      cfg,
+			provider: v1.AWS,
+			service:  "AWS/EC2",
+			expModel: SplineModel,
+		},
+		{
+			name:     "service model",
+			cfg:      cfg,
+			provider: v1.Prometheus,
+			service:  "kepler",
+			expModel: MeasuredModel,
+		},
+		{
+			name:     "no default service model",
+			provider: v1.Prometheus,
+			service:  "kepler",
+			expModel: SplineModel,
+		},
+		{
+			name: "service model over provider",
+			cfg: &config.CalculatorConfig{
+				EnergyModels: []config.EnergyModelConfig{
+					{
+						Provider: v1.Prometheus,
+						Service:  "kepler",
+						Model:    MeasuredModel,
+					},
+					{
+						Provider: v1.Prometheus,
+						Model:    LinearModel,
+					},
+				},
+			},
+			provider: v1.Prometheus,
+			service:  "kepler",
+			expModel: MeasuredModel,
+		},
+		{
+			name: "unsupported model",
+			cfg: &config.CalculatorConfig{
+				EnergyModel: "unknown",
+			},
+			provider: v1.AWS,
+			expErr:   "error energy model not supported: unknown",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			model, err := energyModel(test.cfg, test.provider, test.service)
+			if test.expErr != "" {
+				assert.EqualError(t, err, test.expErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expModel, model.Name())
+		})
+	}
+}
+
+func TestLinearModel(t *testing.T) {
+	p := params()
+	p.model = linearModel{}
+	p.machine.MinWatts = 0.71
+	p.machine.MaxWatts = 3.5
+	p.defaults.MemoryKilloWattHours = 0.000392
+
+	// CPU: 0.71 + 0.27 * (3.5 - 0.71) = 1.4633W per vCPU for 2 vCPUs
+	p.metric.Name = v1.CPU.String()
+	err := operationalEmissions(context.TODO(), 1*time.Hour, p)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.0029266, p.metric.Energy, 1e-9)
+	assert.InDelta(t, 0.0029266*1.2*7, p.metric.Emissions.Value, 1e-9)
+	assert.Equal(t, 1.2, p.metric.PUE)
+
+	// Memory: 0.000392kWh per GB for 1GB
+	p.metric = &v1.Metric{Name: v1.Memory.String(), Usage: 27}
+	err = operationalEmissions(context.TODO(), 1*time.Hour, p)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.000392, p.metric.Energy, 1e-9)
+}
+
+func TestMeasuredEnergy(t *testing.T) {
+	// measured energy is used as is, regardless of the model
+	p := params()
+	p.metric.Name = v1.CPU.String()
+	p.metric.Energy = 0.5
+	p.metric.MeasuredEnergy = true
+
+	err := operationalEmissions(context.TODO(), 5*time.Minute, p)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, p.metric.Energy)
+	assert.InDelta(t, 0.5*1.2*7, p.metric.Emissions.Value, 1e-9)
+
+	// the measured model is used for all metrics of the service
+	p = params()
+	p.model = measuredModel{}
+	p.metric.Name = v1.Memory.String()
+	p.metric.Energy = 0.25
+
+	err = operationalEmissions(context.TODO(), 5*time.Minute, p)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.25, p.metric.Energy)
+	assert.InDelta(t, 0.25*1.2*7, p.metric.Emissions.Value, 1e-9)
+}
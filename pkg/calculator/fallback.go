@@ -0,0 +1,170 @@
+package calculator
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+)
+
+// azureKind matches Azure sizes such as Standard_D4s_v3, where D is the
+// family, 4 the vCPU count and s_v3 the features and version
+var azureKind = regexp.MustCompile(`^(?:Standard_|Basic_)?([A-Za-z]+)(\d+)(.*)$`)
+
+// awsSizes are the vCPU counts of the AWS sizes that are not a multiple of
+// xlarge, the vCPU count of NxLarge is N times the vCPU count of xlarge
+var awsSizes = map[string]float64{
+	"nano":   1,
+	"micro":  1,
+	"small":  1,
+	"medium": 1,
+	"large":  2,
+	"xlarge": 4,
+}
+
+// machineType is an instance kind parsed into its family and size
+type machineType struct {
+	// the family the instance kind belongs to, instance kinds in the same
+	// family run on the same platform and only differ in their size
+	family string
+
+	// the vCPU count of the size, zero when it could not be parsed
+	vCPU float64
+}
+
+// parseMachineType parses the instance kind of the provider into its family
+// and size, for example:
+//
+//	m7i.4xlarge     -> m7i, 16 vCPUs (AWS)
+//	n2d-highmem-16  -> n2d-highmem, 16 vCPUs (GCP)
+//	Standard_D4s_v3 -> Ds_v3, 4 vCPUs (Azure)
+func parseMachineType(provider v1.Provider, kind string) (machineType, bool) {
+	switch provider {
+	case v1.AWS:
+		family, size, ok := strings.Cut(kind, ".")
+		if !ok || family == "" {
+			return machineType{}, false
+		}
+		return machineType{family: family, vCPU: awsVCPU(size)}, true
+
+	case v1.GCP:
+		parts := strings.Split(kind, "-")
+		if len(parts) < 2 || parts[0] == "" {
+			return machineType{}, false
+		}
+
+		// the size is the last number of the kind, for example
+		// c3-standard-4-lssd is a c3-standard with 4 vCPUs
+		for i := len(parts) - 1; i > 0; i-- {
+			if n, err := strconv.ParseFloat(parts[i], 64); err == nil {
+				return machineType{family: strings.Join(parts[:i], "-"), vCPU: n}, true
+			}
+		}
+
+		// shared core kinds, such as e2-medium, have no vCPU count
+		return machineType{family: parts[0]}, true
+
+	case v1.Azure:
+		m := azureKind.FindStringSubmatch(kind)
+		if m == nil {
+			return machineType{}, false
+		}
+		n, _ := strconv.ParseFloat(m[2], 64)
+		return machineType{family: m[1] + m[3], vCPU: n}, true
+	}
+
+	return machineType{}, false
+}
+
+// awsVCPU returns the vCPU count of an AWS size, or zero
+// for sizes such as metal that do not have a fixed count
+func awsVCPU(size string) float64 {
+	if v, ok := awsSizes[size]; ok {
+		return v
+	}
+
+	n, ok := strings.CutSuffix(size, "xlarge")
+	if !ok {
+		return 0
+	}
+
+	m, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return 0
+	}
+
+	return m * awsSizes["xlarge"]
+}
+
+// sibling returns the machine type of the same family that is known to
+// the emission factors and is the closest in size to the machine type
+func sibling(provider v1.Provider, m machineType, machines factors.MachineData) (string, bool) {
+	var (
+		found string
+		diff  = math.Inf(1)
+	)
+
+	for kind := range machines {
+		s, ok := parseMachineType(provider, kind)
+		if !ok || s.family != m.family {
+			continue
+		}
+
+		d := math.Abs(float64(machines[kind].VCPU) - m.vCPU)
+		// prefer the smaller kind on a tie, so the result is deterministic
+		if d < diff || (d == diff && kind < found) {
+			found, diff = kind, d
+		}
+	}
+
+	return found, found != ""
+}
+
+// approximateMachine approximates the emission factors of an instance kind
+// that is not in the datasets. The machine of the closest sibling in the
+// same family is scaled to the size of the instance. When the kind can not
+// be parsed or has no sibling, the min and max wattage of the provider
+// defaults are used, which leaves the embodied emissions unknown.
+//
+// It returns the kind of the sibling the machine was approximated from,
+// which is empty when the provider defaults are used.
+func approximateMachine(provider v1.Provider, kind string, ef *factors.EmissionFactors) (factors.Machine, string) {
+	m, ok := parseMachineType(provider, kind)
+	if ok {
+		if s, ok := sibling(provider, m, ef.Machines); ok {
+			machine := ef.Machines[s]
+
+			// keep the size of the sibling when the size of the kind is unknown
+			ratio := 1.0
+			if m.vCPU > 0 && machine.VCPU > 0 {
+				ratio = m.vCPU / float64(machine.VCPU)
+			}
+
+			// the CPU energy is calculated per vCPU and the memory energy
+			// per GB, so only the vCPU count and memory size are scaled
+			machine.Kind = kind
+			machine.VCPU = int(math.Round(float64(machine.VCPU) * ratio))
+			machine.MemoryGB *= ratio
+
+			return machine, s
+		}
+	}
+
+	machine := factors.Machine{
+		Instance: data.Instance{
+			Kind:    kind,
+			VCPU:    int(m.vCPU),
+			RAMWatt: emptyWattage,
+		},
+	}
+	if ef.ProviderDefaults != nil {
+		machine.MinWatts = ef.ProviderDefaults.MinWatts
+		machine.MaxWatts = ef.ProviderDefaults.MaxWatts
+	}
+
+	return machine, ""
+}
@@ -0,0 +1,116 @@
+package calculator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMachineType(t *testing.T) {
+	for _, test := range []struct {
+		provider v1.Provider
+		kind     string
+		exp      machineType
+		ok       bool
+	}{
+		{v1.AWS, "m7i.4xlarge", machineType{family: "m7i", vCPU: 16}, true},
+		{v1.AWS, "t3.micro", machineType{family: "t3", vCPU: 1}, true},
+		{v1.AWS, "c5.large", machineType{family: "c5", vCPU: 2}, true},
+		{v1.AWS, "m5.metal", machineType{family: "m5"}, true},
+		{v1.AWS, "unknown", machineType{}, false},
+		{v1.GCP, "n2d-highmem-16", machineType{family: "n2d-highmem", vCPU: 16}, true},
+		{v1.GCP, "c3-standard-4-lssd", machineType{family: "c3-standard", vCPU: 4}, true},
+		{v1.GCP, "e2-medium", machineType{family: "e2"}, true},
+		{v1.GCP, "custom", machineType{}, false},
+		{v1.Azure, "Standard_D4s_v3", machineType{family: "Ds_v3", vCPU: 4}, true},
+		{v1.Azure, "Standard_", machineType{}, false},
+		{v1.Prometheus, "m7i.4xlarge", machineType{}, false},
+	} {
+		t.Run(test.kind, func(t *testing.T) {
+			m, ok := parseMachineType(test.provider, test.kind)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.exp, m)
+		})
+	}
+}
+
+func TestApproximateMachine(t *testing.T) {
+	ef := &factors.EmissionFactors{
+		Machines: factors.MachineData{
+			"m7i.xlarge": {
+				Instance: data.Instance{
+					VCPU:     4,
+					MemoryGB: 16,
+					PkgWatt:  []data.Wattage{{Percentage: 0, Wattage: 10}, {Percentage: 100, Wattage: 40}},
+					RAMWatt:  []data.Wattage{{Percentage: 0, Wattage: 1}, {Percentage: 100, Wattage: 4}},
+					Platform: data.Platform{VCPU: 192, TotalScope3: 2000},
+				},
+				MinWatts: 0.7,
+				MaxWatts: 3.5,
+			},
+			"m7i.8xlarge": {
+				Instance: data.Instance{
+					VCPU:     32,
+					MemoryGB: 128,
+					Platform: data.Platform{VCPU: 192, TotalScope3: 2000},
+				},
+			},
+			"c7i.4xlarge": {Instance: data.Instance{VCPU: 16}},
+		},
+		ProviderDefaults: &factors.ProviderDefaults{MinWatts: 0.74, MaxWatts: 3.5},
+	}
+
+	// the closest sibling is scaled to the size of the instance
+	m, s := approximateMachine(v1.AWS, "m7i.2xlarge", ef)
+	assert.Equal(t, "m7i.xlarge", s)
+	assert.Equal(t, "m7i.2xlarge", m.Kind)
+	assert.Equal(t, 8, m.VCPU)
+	assert.Equal(t, 32.0, m.MemoryGB)
+	assert.Equal(t, 192, m.Platform.VCPU)
+	assert.Equal(t, 3.5, m.MaxWatts)
+	assert.Equal(t, ef.Machines["m7i.xlarge"].PkgWatt, m.PkgWatt)
+	assert.Equal(t, ef.Machines["m7i.xlarge"].RAMWatt, m.RAMWatt)
+
+	// the sibling is not modified
+	assert.Equal(t, 4, ef.Machines["m7i.xlarge"].VCPU)
+	assert.Equal(t, 4.0, ef.Machines["m7i.xlarge"].RAMWatt[1].Wattage)
+
+	m, s = approximateMachine(v1.AWS, "m7i.12xlarge", ef)
+	assert.Equal(t, "m7i.8xlarge", s)
+	assert.Equal(t, 48, m.VCPU)
+	assert.False(t, m.HasWattageCurve())
+
+	// without a sibling the provider defaults are used
+	m, s = approximateMachine(v1.AWS, "r8g.2xlarge", ef)
+	assert.Equal(t, "", s)
+	assert.Equal(t, 8, m.VCPU)
+	assert.Equal(t, 0.74, m.MinWatts)
+	assert.Equal(t, 3.5, m.MaxWatts)
+	assert.False(t, m.HasWattageCurve())
+	assert.Equal(t, 0.0, hourlyEmbodiedEmissions(&m, serverLifespan).total())
+
+	_, s = approximateMachine(v1.AWS, "unknown", &factors.EmissionFactors{})
+	assert.Equal(t, "", s)
+}
+
+func TestApproximatedFlag(t *testing.T) {
+	p := params()
+	p.approximated = true
+	p.metric.Name = v1.CPU.String()
+
+	err := operationalEmissions(context.TODO(), 5*time.Minute, p)
+	assert.Nil(t, err)
+	assert.True(t, p.metric.Provenance.HasFlag(v1.FlagApproximated))
+	assert.InDelta(t, combineUncertainty(splineUncertainty, approximatedUncertainty), p.metric.Emissions.Uncertainty(), 1e-12)
+
+	// the storage coefficients do not depend on the instance kind
+	p.metric = &v1.Metric{Name: v1.Storage.String(), UnitAmount: 1, Unit: v1.TB}
+	err = operationalEmissions(context.TODO(), 5*time.Minute, p)
+	assert.Nil(t, err)
+	assert.False(t, p.metric.Provenance.HasFlag(v1.FlagApproximated))
+}
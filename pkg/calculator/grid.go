@@ -0,0 +1,428 @@
+package calculator
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/re-cinq/aether/pkg/config"
+	"github.com/re-cinq/aether/pkg/log"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+)
+
+const (
+	// StaticGrid uses the annual average grid intensity of the
+	// emissions dataset
+	StaticGrid = "static"
+
+	// FileGrid reads a time series of grid intensities from a local
+	// CSV or YAML file
+	FileGrid = "file"
+
+	// HTTPGrid queries an Electricity Maps style API for the grid
+	// intensity at the time of the measurement
+	HTTPGrid = "http"
+
+	defaultGridCacheExpiry = time.Hour
+	defaultGridTimeout     = 10 * time.Second
+
+	// the wait before a region the provider failed for is queried
+	// again, doubling for every failure up to the max
+	gridFailureBackoff    = time.Minute
+	maxGridFailureBackoff = time.Hour
+)
+
+// GridIntensityProvider returns the carbon intensity of the electricity
+// grid in gCO2eq/kWh for a region at a specific point in time
+type GridIntensityProvider interface {
+	// Name returns the name of the source the provider is configured with
+	Name() string
+
+	GridIntensity(ctx context.Context, region string, t time.Time) (float64, error)
+}
+
+// gridIntensity returns the grid intensity for the region at the time from
+// the provider. If no provider is configured, or it fails to return an
+// intensity, it falls back to the annual average of the emissions dataset.
+// It also returns the name of the source the grid intensity came from.
+func gridIntensity(
+	ctx context.Context,
+	p GridIntensityProvider,
+	annual factors.CoefficientData,
+	region string,
+	t time.Time,
+) (float64, string, error) {
+	logger := log.FromContext(ctx)
+
+	if p != nil {
+		grid, err := p.GridIntensity(ctx, region, t)
+		if err == nil {
+			return grid, p.Name(), nil
+		}
+		logger.Debug("falling back to annual grid intensity", "region", region, "error", err)
+	}
+
+	s := staticGrid{data: annual}
+	grid, err := s.GridIntensity(ctx, region, t)
+	return grid, s.Name(), err
+}
+
+// newGridIntensityProvider returns the grid intensity provider configured,
+// wrapped in a cache. The static provider returns nil, as the annual
+// averages are specific to the cloud provider of the instance.
+func newGridIntensityProvider(cfg *config.GridIntensityConfig) (GridIntensityProvider, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	var p GridIntensityProvider
+	switch cfg.Source {
+	case "", StaticGrid:
+		return nil, nil
+	case FileGrid:
+		f, err := newFileGrid(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		f.maxAge = cfg.MaxAge
+		p = f
+	case HTTPGrid:
+		if cfg.URL == "" {
+			return nil, errors.New("error grid intensity url not set")
+		}
+		p = &httpGrid{
+			url:    cfg.URL,
+			token:  cfg.Token,
+			zones:  cfg.Zones,
+			client: &http.Client{Timeout: defaultGridTimeout},
+		}
+	default:
+		return nil, fmt.Errorf("error grid intensity source not supported: %s", cfg.Source)
+	}
+
+	expiry := cfg.CacheExpiry
+	if expiry == 0 {
+		expiry = defaultGridCacheExpiry
+	}
+
+	return newCachedGrid(p, expiry), nil
+}
+
+// staticGrid uses the annual average grid intensity per region
+type staticGrid struct {
+	data factors.CoefficientData
+}
+
+func (staticGrid) Name() string { return StaticGrid }
+
+func (s staticGrid) GridIntensity(ctx context.Context, region string, t time.Time) (float64, error) {
+	grid, ok := s.data[region]
+	if !ok {
+		return 0, fmt.Errorf("error region not found in grid data: %s", region)
+	}
+
+	// TODO: hotfix until updated in emissions data
+	// convert gridCO2e from metric tonnes to grams
+	return grid * (1000 * 1000), nil
+}
+
+// gridPoint is the grid intensity of a region from a point in time
+// until the next point in the time series
+type gridPoint struct {
+	Region    string    `yaml:"region"`
+	Timestamp time.Time `yaml:"timestamp"`
+	Intensity float64   `yaml:"intensity"`
+}
+
+// fileGrid is a time series of grid intensities per region
+type fileGrid struct {
+	series map[string][]gridPoint
+
+	// how old a point may be to be used, so the last point of a
+	// series that is no longer updated is not used forever
+	maxAge time.Duration
+}
+
+// newFileGrid reads the time series from a YAML file containing a list of
+// points, or a CSV file with the columns region, timestamp (RFC3339) and
+// intensity (gCO2eq/kWh)
+func newFileGrid(path string) (*fileGrid, error) {
+	if path == "" {
+		return nil, errors.New("error grid intensity file not set")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var points []gridPoint
+	switch filepath.Ext(path) {
+	case ".csv":
+		points, err = readGridCSV(f)
+	case ".yaml", ".yml":
+		err = yaml.NewDecoder(f).Decode(&points)
+	default:
+		err = fmt.Errorf("error grid intensity file type not supported: %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	g := &fileGrid{series: make(map[string][]gridPoint)}
+	for _, p := range points {
+		g.series[p.Region] = append(g.series[p.Region], p)
+	}
+
+	for _, s := range g.series {
+		sort.Slice(s, func(i, j int) bool {
+			return s[i].Timestamp.Before(s[j].Timestamp)
+		})
+	}
+
+	return g, nil
+}
+
+func readGridCSV(r io.Reader) ([]gridPoint, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var points []gridPoint
+	for i, rec := range records {
+		if len(rec) != 3 {
+			return nil, fmt.Errorf("error grid intensity line %d: expected 3 columns, got %d", i+1, len(rec))
+		}
+
+		// skip the header
+		if i == 0 && rec[0] == "region" {
+			continue
+		}
+
+		ts, err := time.Parse(time.RFC3339, rec[1])
+		if err != nil {
+			return nil, fmt.Errorf("error grid intensity line %d: %w", i+1, err)
+		}
+
+		intensity, err := strconv.ParseFloat(rec[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("error grid intensity line %d: %w", i+1, err)
+		}
+
+		points = append(points, gridPoint{
+			Region:    rec[0],
+			Timestamp: ts,
+			Intensity: intensity,
+		})
+	}
+
+	return points, nil
+}
+
+func (*fileGrid) Name() string { return FileGrid }
+
+// GridIntensity returns the intensity of the latest point at or before
+// the time, unless it is older than the max age
+func (f *fileGrid) GridIntensity(ctx context.Context, region string, t time.Time) (float64, error) {
+	s, ok := f.series[region]
+	if !ok {
+		return 0, fmt.Errorf("error region not found in grid data: %s", region)
+	}
+
+	// first point after the time
+	i := sort.Search(len(s), func(i int) bool {
+		return s[i].Timestamp.After(t)
+	})
+	if i == 0 {
+		return 0, fmt.Errorf("error no grid intensity for %s at %s", region, t)
+	}
+
+	p := s[i-1]
+	if f.maxAge > 0 && t.Sub(p.Timestamp) > f.maxAge {
+		return 0, fmt.Errorf("error grid intensity for %s at %s is stale: %s", region, t, p.Timestamp)
+	}
+
+	return p.Intensity, nil
+}
+
+// httpGrid queries an Electricity Maps compatible API for the grid
+// intensity of a zone at a point in time
+type httpGrid struct {
+	url    string
+	token  string
+	zones  map[string]string
+	client *http.Client
+}
+
+// carbonIntensity is the response of the API
+type carbonIntensity struct {
+	Zone            string  `json:"zone"`
+	CarbonIntensity float64 `json:"carbonIntensity"`
+}
+
+func (*httpGrid) Name() string { return HTTPGrid }
+
+func (h *httpGrid) GridIntensity(ctx context.Context, region string, t time.Time) (float64, error) {
+	// the API uses its own zones, so the cloud regions are mapped to
+	// them. Regions without a mapping are used as the zone
+	zone := region
+	if z, ok := h.zones[region]; ok {
+		zone = z
+	}
+
+	q := url.Values{}
+	q.Set("zone", zone)
+	q.Set("datetime", t.UTC().Format(time.RFC3339))
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("%s/carbon-intensity/past?%s", h.url, q.Encode()),
+		http.NoBody,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if h.token != "" {
+		req.Header.Set("auth-token", h.token)
+	}
+
+	r, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("error getting grid intensity for %s: %s", zone, r.Status)
+	}
+
+	var ci carbonIntensity
+	if err := json.NewDecoder(r.Body).Decode(&ci); err != nil {
+		return 0, err
+	}
+
+	return ci.CarbonIntensity, nil
+}
+
+type gridCacheEntry struct {
+	intensity float64
+	expires   time.Time
+}
+
+// gridFailure is a region the provider failed for, which
+// is not queried again until the backoff passed
+type gridFailure struct {
+	err      error
+	failures int
+	until    time.Time
+}
+
+// cachedGrid caches the intensities of a provider per region and expiry
+// period, e.g. per hour with the default expiry of an hour, so that the instances of a region do not all query the provider. The
+// regions the provider fails for are backed off, so an unmapped region or
+// an unavailable API does not block the calculator with a request for
+// every instance.
+type cachedGrid struct {
+	provider GridIntensityProvider
+	expiry   time.Duration
+
+	lock     sync.Mutex
+	entries  map[string]gridCacheEntry
+	failures map[string]gridFailure
+}
+
+func newCachedGrid(p GridIntensityProvider, expiry time.Duration) *cachedGrid {
+	return &cachedGrid{
+		provider: p,
+		expiry:   expiry,
+		entries:  make(map[string]gridCacheEntry),
+		failures: make(map[string]gridFailure),
+	}
+}
+
+func (c *cachedGrid) Name() string { return c.provider.Name() }
+
+func (c *cachedGrid) GridIntensity(ctx context.Context, region string, t time.Time) (float64, error) {
+	// measurements within the same period share the intensity
+	at := t
+	if c.expiry > 0 {
+		at = t.Truncate(c.expiry)
+	}
+	key := fmt.Sprintf("%s-%d", region, at.Unix())
+	now := time.Now()
+
+	c.lock.Lock()
+	e, ok := c.entries[key]
+	f, failed := c.failures[region]
+	c.lock.Unlock()
+
+	if ok && now.Before(e.expires) {
+		return e.intensity, nil
+	}
+
+	if failed && now.Before(f.until) {
+		return 0, f.err
+	}
+
+	intensity, err := c.provider.GridIntensity(ctx, region, t)
+	if err != nil {
+		c.failure(region, err, now)
+		return 0, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.failures, region)
+
+	// remove expired entries so the cache does not grow forever
+	for k, v := range c.entries {
+		if now.After(v.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = gridCacheEntry{
+		intensity: intensity,
+		expires:   now.Add(c.expiry),
+	}
+
+	return intensity, nil
+}
+
+// failure backs off the region, doubling the backoff
+// for every consecutive failure
+func (c *cachedGrid) failure(region string, err error, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	f := c.failures[region]
+	f.failures++
+
+	backoff := gridFailureBackoff
+	for i := 1; i < f.failures && backoff < maxGridFailureBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxGridFailureBackoff)
+
+	f.err = err
+	f.until = now.Add(backoff)
+	c.failures[region] = f
+}
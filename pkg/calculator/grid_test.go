@@ -0,0 +1,278 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/config"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var annual = factors.CoefficientData{
+	"europe-west4": 0.000282,
+}
+
+// fakeGrid counts the calls made to it
+type fakeGrid struct {
+	intensity float64
+	err       error
+	calls     int
+}
+
+func (f *fakeGrid) Name() string { return "fake" }
+
+func (f *fakeGrid) GridIntensity(ctx context.Context, region string, t time.Time) (float64, error) {
+	f.calls++
+	return f.intensity, f.err
+}
+
+func writeGridFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestGridIntensityFallback(t *testing.T) {
+	ctx := context.TODO()
+	now := time.Now()
+
+	// no provider uses the annual average in grams
+	grid, source, err := gridIntensity(ctx, nil, annual, "europe-west4", now)
+	assert.Nil(t, err)
+	assert.Equal(t, 282.0, grid)
+	assert.Equal(t, StaticGrid, source)
+
+	// the provider is used when it returns an intensity
+	grid, source, err = gridIntensity(ctx, &fakeGrid{intensity: 120}, annual, "europe-west4", now)
+	assert.Nil(t, err)
+	assert.Equal(t, 120.0, grid)
+	assert.Equal(t, "fake", source)
+
+	// falls back to the annual average when the provider fails
+	grid, source, err = gridIntensity(ctx, &fakeGrid{err: errors.New("down")}, annual, "europe-west4", now)
+	assert.Nil(t, err)
+	assert.Equal(t, 282.0, grid)
+	assert.Equal(t, StaticGrid, source)
+
+	// errors when the region is in neither
+	_, _, err = gridIntensity(ctx, &fakeGrid{err: errors.New("down")}, annual, "mars-1", now)
+	assert.EqualError(t, err, "error region not found in grid data: mars-1")
+}
+
+func TestFileGrid(t *testing.T) {
+	ctx := context.TODO()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	csv := writeGridFile(t, "grid.csv", `region,timestamp,intensity
+europe-west4,2024-03-01T18:00:00Z,410
+europe-west4,2024-03-01T03:00:00Z,180
+us-central1,2024-03-01T00:00:00Z,500
+`)
+
+	yml := writeGridFile(t, "grid.yaml", `
+- region: europe-west4
+  timestamp: 2024-03-01T18:00:00Z
+  intensity: 410
+- region: europe-west4
+  timestamp: 2024-03-01T03:00:00Z
+  intensity: 180
+- region: us-central1
+  timestamp: 2024-03-01T00:00:00Z
+  intensity: 500
+`)
+
+	for _, path := range []string{csv, yml} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			g, err := newFileGrid(path)
+			require.Nil(t, err)
+
+			for _, test := range []struct {
+				region string
+				t      time.Time
+				exp    float64
+				expErr string
+			}{
+				{region: "europe-west4", t: day.Add(3 * time.Hour), exp: 180},
+				{region: "europe-west4", t: day.Add(12 * time.Hour), exp: 180},
+				{region: "europe-west4", t: day.Add(19 * time.Hour), exp: 410},
+				{region: "us-central1", t: day.Add(19 * time.Hour), exp: 500},
+				{
+					region: "europe-west4",
+					t:      day.Add(1 * time.Hour),
+					expErr: fmt.Sprintf("error no grid intensity for europe-west4 at %s", day.Add(1*time.Hour)),
+				},
+				{region: "mars-1", t: day, expErr: "error region not found in grid data: mars-1"},
+			} {
+				grid, err := g.GridIntensity(ctx, test.region, test.t)
+				if test.expErr != "" {
+					assert.EqualError(t, err, test.expErr)
+					continue
+				}
+				assert.Nil(t, err)
+				assert.Equal(t, test.exp, grid)
+			}
+		})
+	}
+
+	// points older than the max age are not used
+	g, err := newFileGrid(csv)
+	require.Nil(t, err)
+	g.maxAge = 24 * time.Hour
+
+	grid, err := g.GridIntensity(ctx, "europe-west4", day.Add(42*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 410.0, grid)
+
+	_, err = g.GridIntensity(ctx, "europe-west4", day.Add(43*time.Hour))
+	assert.EqualError(t, err, fmt.Sprintf(
+		"error grid intensity for europe-west4 at %s is stale: %s",
+		day.Add(43*time.Hour), day.Add(18*time.Hour),
+	))
+
+	_, err = newFileGrid(writeGridFile(t, "grid.json", "{}"))
+	assert.ErrorContains(t, err, "error grid intensity file type not supported")
+
+	_, err = newFileGrid(writeGridFile(t, "bad.csv", "europe-west4,yesterday,100\n"))
+	assert.ErrorContains(t, err, "error grid intensity line 1")
+}
+
+func TestHTTPGrid(t *testing.T) {
+	ctx := context.TODO()
+	ts := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("auth-token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path != "/carbon-intensity/past" ||
+			r.URL.Query().Get("datetime") != "2024-03-01T18:00:00Z" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.URL.Query().Get("zone") {
+		case "NL":
+			fmt.Fprint(w, `{"zone":"NL","carbonIntensity":410,"datetime":"2024-03-01T18:00:00.000Z"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	g := &httpGrid{
+		url:    srv.URL,
+		token:  "secret",
+		zones:  map[string]string{"europe-west4": "NL"},
+		client: srv.Client(),
+	}
+
+	grid, err := g.GridIntensity(ctx, "europe-west4", ts)
+	assert.Nil(t, err)
+	assert.Equal(t, 410.0, grid)
+
+	_, err = g.GridIntensity(ctx, "us-central1", ts)
+	assert.EqualError(t, err, "error getting grid intensity for us-central1: 404 Not Found")
+
+	g.token = "wrong"
+	_, err = g.GridIntensity(ctx, "europe-west4", ts)
+	assert.EqualError(t, err, "error getting grid intensity for NL: 401 Unauthorized")
+}
+
+func TestCachedGrid(t *testing.T) {
+	ctx := context.TODO()
+	ts := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+
+	f := &fakeGrid{intensity: 410}
+	c := newCachedGrid(f, time.Hour)
+
+	// measurements within the same hour are only queried once
+	for _, at := range []time.Time{ts, ts.Add(5 * time.Minute), ts.Add(55 * time.Minute)} {
+		grid, err := c.GridIntensity(ctx, "europe-west4", at)
+		assert.Nil(t, err)
+		assert.Equal(t, 410.0, grid)
+	}
+	assert.Equal(t, 1, f.calls)
+
+	// a different hour or region is queried again
+	_, _ = c.GridIntensity(ctx, "europe-west4", ts.Add(time.Hour))
+	_, _ = c.GridIntensity(ctx, "us-central1", ts)
+	assert.Equal(t, 3, f.calls)
+
+	// failed regions are backed off, for all hours
+	f.err = errors.New("down")
+	_, err := c.GridIntensity(ctx, "us-east1", ts)
+	assert.EqualError(t, err, "down")
+	_, err = c.GridIntensity(ctx, "us-east1", ts.Add(time.Hour))
+	assert.EqualError(t, err, "down")
+	assert.Equal(t, 4, f.calls)
+	assert.WithinDuration(t, time.Now().Add(gridFailureBackoff), c.failures["us-east1"].until, time.Second)
+
+	// the backoff doubles for every failure after it passed
+	for i := 0; i < 10; i++ {
+		c.failures["us-east1"] = gridFailure{
+			err:      f.err,
+			failures: c.failures["us-east1"].failures,
+		}
+		_, _ = c.GridIntensity(ctx, "us-east1", ts)
+	}
+	assert.Equal(t, 14, f.calls)
+	assert.Equal(t, 11, c.failures["us-east1"].failures)
+	assert.WithinDuration(t, time.Now().Add(maxGridFailureBackoff), c.failures["us-east1"].until, time.Second)
+
+	// a success resets the backoff
+	f.err = nil
+	c.failures["us-east1"] = gridFailure{err: errors.New("down")}
+	_, err = c.GridIntensity(ctx, "us-east1", ts)
+	assert.Nil(t, err)
+	assert.NotContains(t, c.failures, "us-east1")
+
+	// expired entries are queried again
+	c.expiry = -time.Second
+	_, _ = c.GridIntensity(ctx, "europe-west4", ts.Add(2*time.Hour))
+	_, _ = c.GridIntensity(ctx, "europe-west4", ts.Add(2*time.Hour))
+	assert.Equal(t, 17, f.calls)
+
+	// the measurements share the intensity for the period of the expiry
+	f = &fakeGrid{intensity: 410}
+	c = newCachedGrid(f, 15*time.Minute)
+	for _, at := range []time.Time{ts, ts.Add(10 * time.Minute), ts.Add(15 * time.Minute)} {
+		_, err := c.GridIntensity(ctx, "europe-west4", at)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 2, f.calls)
+}
+
+func TestNewGridIntensityProvider(t *testing.T) {
+	p, err := newGridIntensityProvider(&config.GridIntensityConfig{Source: StaticGrid})
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+
+	p, err = newGridIntensityProvider(&config.GridIntensityConfig{
+		Source: HTTPGrid,
+		URL:    "http://localhost",
+	})
+	assert.Nil(t, err)
+	assert.IsType(t, &cachedGrid{}, p)
+	assert.Equal(t, HTTPGrid, p.Name())
+
+	_, err = newGridIntensityProvider(&config.GridIntensityConfig{Source: HTTPGrid})
+	assert.EqualError(t, err, "error grid intensity url not set")
+
+	_, err = newGridIntensityProvider(&config.GridIntensityConfig{Source: FileGrid})
+	assert.EqualError(t, err, "error grid intensity file not set")
+
+	_, err = newGridIntensityProvider(&config.GridIntensityConfig{Source: "carrier-pigeon"})
+	assert.EqualError(t, err, "error grid intensity source not supported: carrier-pigeon")
+}
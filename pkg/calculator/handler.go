@@ -4,10 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net/http"
-	"reflect"
-
-	"gopkg.in/yaml.v2"
+	"time"
 
 	"github.com/re-cinq/aether/pkg/bus"
 	"github.com/re-cinq/aether/pkg/config"
@@ -17,167 +14,192 @@ import (
 	data "github.com/re-cinq/emissions-data/pkg/types/v2"
 )
 
-var instanceData map[string]data.Instance
-
-// AWS, GCP and Azure have increased their server lifespan to 6 years (2024)
-// https://sustainability.aboutamazon.com/products-services/the-cloud?energyType=true
-// https://www.theregister.com/2024/01/31/alphabet_q4_2023/
-// https://www.theregister.com/2022/08/02/microsoft_server_life_extension/
-const serverLifespan = 6
-
-//nolint:govet // don't need to write the struct fields each time
-var emptyWattage = []data.Wattage{{0, 0}, {10, 0}, {50, 0}, {100, 0}}
+var emptyWattage = []data.Wattage{
+	{Percentage: 0, Wattage: 0},
+	{Percentage: 10, Wattage: 0},
+	{Percentage: 50, Wattage: 0},
+	{Percentage: 100, Wattage: 0},
+}
 
 // CalculatorHandler is used to handle events when metrics have been collected
 type CalculatorHandler struct {
 	Bus    *bus.Bus
 	logger *slog.Logger
+
+	// grid returns the grid intensity at the time of the measurement,
+	// when nil the annual averages of the emissions dataset are used
+	grid GridIntensityProvider
+
+	// factors holds the emission factors of all providers, which
+	// are refreshed in the background
+	factors *factors.FactorRegistry
 }
 
 // NewHandler returns a new configuered instance of CalculatorHandler
-// as well as setups the factor datasets
-func NewHandler(ctx context.Context, b *bus.Bus) *CalculatorHandler {
+// as well as setups the factor datasets, it returns an error when the
+// emission factors can not be loaded
+func NewHandler(ctx context.Context, b *bus.Bus) (*CalculatorHandler, error) {
 	logger := log.FromContext(ctx)
 
-	err := factors.CloneAndUpdateFactorsData()
-	if err != nil {
-		logger.Error("error with emissions repo", "error", err)
-		return nil
-	}
+	cfg := &config.AppConfig().Calculator.Factors
 
-	for provider := range config.AppConfig().Providers {
-		err = getProviderEmissionFactors(provider)
+	registry, err := factors.NewFactorRegistry(func() (*factors.Dataset, error) {
+		dir, err := loadFactorsData(ctx, cfg)
 		if err != nil {
-			logger.Error("unable to get v2 Emission Factors", "error", err, "provider", provider)
+			return nil, err
+		}
+		d, err := factors.LoadDataset(dir.Version, dir.DataPath, dir.InstanceDataPath)
+		if err != nil || cfg.Overrides == "" {
+			return d, err
 		}
+		return d, d.ApplyOverrides(ctx, cfg.Overrides)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading emission factors: %w", err)
 	}
+	logger.Info("loaded emission factors", "version", registry.Version())
 
-	return &CalculatorHandler{
-		Bus:    b,
-		logger: logger,
+	registry.Start(ctx, cfg.RefreshInterval)
+
+	grid, err := newGridIntensityProvider(&config.AppConfig().Calculator.GridIntensity)
+	if err != nil {
+		logger.Error("unable to setup grid intensity provider, using annual averages", "error", err)
 	}
+
+	return &CalculatorHandler{
+		Bus:     b,
+		logger:  logger,
+		grid:    grid,
+		factors: registry,
+	}, nil
 }
 
 // Stop is used to fulfill the EventHandler interface and all clean up
 // functionality should be run in here
-func (c *CalculatorHandler) Stop(ctx context.Context) {}
+func (c *CalculatorHandler) Stop(ctx context.Context) {
+	c.factors.Stop()
+
+	if err := factors.RemoveFactorsData(); err != nil {
+		c.logger.Error("error removing the extracted emission factors", "error", err)
+	}
+}
 
 // Handle is used to fulfill the EventHandler interface and recives an event
 // when handler is subscribed to it. Currently only handles v1.MetricsCollectedEvent
 func (c *CalculatorHandler) Handle(ctx context.Context, e *bus.Event) {
 	switch e.Type {
 	case v1.MetricsCollectedEvent:
-		c.handleEvent(e)
+		instance, ok := e.Data.(v1.Instance)
+		if !ok {
+			c.logger.Error("EmissionCalculator got an unknown event", "event", e.Type, "id", e.Metadata.ID)
+			return
+		}
+		c.HandleInstance(ctx, instance)
 	default:
 		return
 	}
 }
 
-// handleEvent is the business logic for handeling a v1.MetricsCollectedEvent
-// and runs the emissions calculations on the metrics that where received
-func (c *CalculatorHandler) handleEvent(e *bus.Event) {
-	interval := config.AppConfig().ProvidersConfig.Interval
-	ctx := log.WithContext(context.Background(), c.logger)
-
-	instance, ok := e.Data.(v1.Instance)
-	if !ok {
-		c.logger.Error("EmissionCalculator got an unknown event", "event", e)
-		return
+// HandleInstance is the business logic for handeling a v1.MetricsCollectedEvent
+// and runs the emissions calculations on the metrics that where received,
+// it is subscribed to the v1.MetricsCollected topic
+func (c *CalculatorHandler) HandleInstance(ctx context.Context, instance v1.Instance) {
+	// the emissions are calculated over the window the source collected
+	// the metrics in, events without one use the interval of all providers
+	interval := bus.MetadataFromContext(ctx).Window
+	if interval == 0 {
+		interval = config.AppConfig().ProvidersConfig.Interval
 	}
 
+	// the context carries the metadata of the event, so the
+	// calculated instance keeps the source and scrape ID
+	ctx = log.WithContext(ctx, c.logger)
+	topic := v1.EmissionsCalculated(c.Bus)
+
 	// if an instance is terminated we do not need to calculate
 	// emissions for it
 	if instance.Status == v1.InstanceTerminated {
-		if err := c.Bus.Publish(&bus.Event{
-			Type: v1.EmissionsCalculatedEvent,
-			Data: instance,
-		}); err != nil {
+		if err := topic.Publish(ctx, instance); err != nil {
 			c.logger.Error("failed publishing terminated instance", "instance", instance.Name, "error", err)
 		}
 		return
 	}
 
 	// Gets PUE, grid data, and machine specs
-	factor, err := factors.ProviderEmissions(instance.Provider, factors.DataPath)
-	if err != nil {
-		c.logger.Error("error getting emission factors", "error", err)
+	dataset := c.factors.Dataset()
+	factor, ok := dataset.Providers[instance.Provider]
+	if !ok {
+		c.logger.Error("error getting emission factors", "provider", instance.Provider, "version", dataset.Version)
 		return
 	}
 
-	grid, ok := factor.Coefficient[instance.Region]
-	if !ok {
-		c.logger.Error("region not found in factors", "region", instance.Region, "provider", instance.Provider)
+	grid, gridSource, err := gridIntensity(ctx, c.grid, factor.Coefficient, instance.Region, collectedAt(instance.Metrics))
+	if err != nil {
+		c.logger.Error("error getting grid intensity", "error", err, "region", instance.Region, "provider", instance.Provider)
 		return
 	}
-	// TODO: hotfix until updated in emissions data
-	// convert gridCO2e from metric tonnes to grams
-	grid *= (1000 * 1000)
-
-	// energy consumption is already computed for kepler, so we just need to calculate
-	// the carbon emissions
-	// TODO this is a hack until the energy consumption is calculator is separated from
-	// the emissions calculator
-	if instance.Service == "kepler" {
-		for _, m := range instance.Metrics {
-			m.Emissions = v1.NewResourceEmission(
-				m.Energy*factor.AveragePUE*grid,
-				v1.GCO2eq,
-			)
-			if err != nil {
-				c.logger.Error("error calulating emissions", "type", m.Name, "error", err)
-				continue
-			}
-			// update the instance metrics
-			instance.Metrics.Upsert(&m)
-		}
 
-		// We publish the interface on the bus once its been calculated
-		if err := c.Bus.Publish(&bus.Event{
-			Type: v1.EmissionsCalculatedEvent,
-			Data: instance,
-		}); err != nil {
-			c.logger.Error("failed publishing instance after calculation", "instance", instance.Name, "error", err)
-		}
+	model, err := energyModel(&config.AppConfig().Calculator, instance.Provider, instance.Service)
+	if err != nil {
+		c.logger.Error("error getting energy model", "error", err, "provider", instance.Provider, "service", instance.Service)
 		return
 	}
 
+	pue, level := resolvePUE(config.AppConfig().Calculator.PUE, factor, &instance)
+	c.logger.Debug("resolved PUE", "instance", instance.Name, "pue", pue, "level", level)
+
 	params := &parameters{
-		grid: grid,
-		pue:  factor.AveragePUE,
+		grid:            grid,
+		gridUncertainty: gridUncertainty(gridSource),
+		pue:             pue,
+		pueUncertainty:  pueUncertainty[level],
+		defaults:        factor.ProviderDefaults,
+		gpus:            factor.GPU,
+		model:           model,
+		provenance: v1.Provenance{
+			FactorsVersion: dataset.Version,
+			GridSource:     gridSource,
+			PUESource:      level,
+		},
 	}
 
-	specs, ok := factor.Embodied[instance.Kind]
+	// instance kinds that are not in the datasets yet are approximated
+	// from a sibling in the same family or the provider defaults, instead
+	// of dropping the instance
+	machine, ok := factor.Machines[instance.Kind]
 	if !ok {
-		c.logger.Error("failed finding instance in factor data", "instance", instance.Name, "kind", instance.Kind)
-		return
-	}
-
-	if d, ok := instanceData[instance.Kind]; ok {
-		params.factors = &d
+		var sibling string
+		machine, sibling = approximateMachine(instance.Provider, instance.Kind, factor)
+		params.approximated = true
+		c.logger.Warn("approximating factors of unknown instance kind",
+			"instance", instance.Name, "kind", instance.Kind, "sibling", sibling)
 	}
 
 	// fallback to use spec power min and max watt values.
 	// this is less accurate and a place holder until a
 	// different solution is implemented.
-	if reflect.DeepEqual(params.factors.PkgWatt, emptyWattage) {
-		params.factors.PkgWatt = []data.Wattage{
+	if !machine.HasWattageCurve() {
+		params.fallbackWattage = true
+		machine.PkgWatt = []data.Wattage{
 			{
 				Percentage: 0,
-				Wattage:    specs.MinWatts,
+				Wattage:    machine.MinWatts,
 			},
 			{
 				Percentage: 100,
-				Wattage:    specs.MaxWatts,
+				Wattage:    machine.MaxWatts,
 			},
 		}
 	}
+	params.machine = &machine
 
-	if params.embodiedFactor == 0 {
-		params.embodiedFactor = hourlyEmbodiedEmissions(&specs)
-	}
+	params.embodied = hourlyEmbodiedEmissions(
+		&machine,
+		lifespan(&config.AppConfig().Calculator, instance.Provider, instance.Kind),
+	)
 
 	// calculate and set the operational emissions for each
-	// metric type (CPU, Memory, Storage, and networking)
+	// metric type (CPU, Memory, GPU, Storage, and networking)
 	metrics := instance.Metrics
 	for _, v := range metrics {
 		params.metric = &v
@@ -191,54 +213,82 @@ func (c *CalculatorHandler) handleEvent(e *bus.Event) {
 		metrics.Upsert(params.metric)
 	}
 
-	instance.EmbodiedEmissions = v1.NewResourceEmission(
-		embodiedEmissions(interval, params.embodiedFactor),
-		v1.GCO2eq,
-	)
+	instance.EmbodiedBreakdown = embodiedEmissions(interval, params.embodied)
+	instance.EmbodiedEmissions = instance.EmbodiedBreakdown.TotalEmissions()
 
 	// We publish the interface on the bus once its been calculated
-	if err := c.Bus.Publish(&bus.Event{
-		Type: v1.EmissionsCalculatedEvent,
-		Data: instance,
-	}); err != nil {
+	if err := topic.Publish(ctx, instance); err != nil {
 		c.logger.Error("failed publishing instance after calculation", "instance", instance.Name, "error", err)
 	}
 }
 
-func hourlyEmbodiedEmissions(e *factors.Embodied) float64 {
-	// we fall back on the specs from the previous dataset
-	// and convert it into a hourly factor
-	// this is based on CCF's calculation:
-	//
-	// M = TE * (TR/EL) * (RR/TR)
-	//
-	// TE = Total Embodied Emissions
-	// TR = Time Reserved (in years)
-	// EL = Expected Lifespan
-	// RR = Resources Reserved
-	// TR = Total Resources, the total number of resources available.
-	return e.TotalEmbodiedKiloWattCO2e *
-		// 1 hour normalized to a year
-		((1.0 / 24.0 / 365.0) / serverLifespan) *
-		// amount of vCPUS for instance versus total vCPUS for platform
-		(e.VCPU / e.TotalVCPU)
+// pueOverrides returns the PUE configured for the provider
+func pueOverrides(cfg []config.PUEConfig, provider v1.Provider) []factors.PUE {
+	var pue []factors.PUE
+	for _, p := range cfg {
+		if p.Provider != provider {
+			continue
+		}
+		pue = append(pue, factors.PUE{
+			Region: p.Region,
+			Zone:   p.Zone,
+			PUE:    p.PUE,
+		})
+	}
+	return pue
 }
 
-func getProviderEmissionFactors(provider v1.Provider) error {
-	url := "https://raw.githubusercontent.com/re-cinq/emissions-data/main/data/v2/%s-instances.yaml"
-	u := fmt.Sprintf(url, provider)
+// resolvePUE returns the PUE of the instance and the level it was resolved
+// at, going from the datacenter label of the instance to its zone, region
+// and finally the average PUE of the provider
+func resolvePUE(cfg []config.PUEConfig, factor *factors.EmissionFactors, instance *v1.Instance) (float64, string) {
+	return factor.ResolvePUE(
+		pueOverrides(cfg, instance.Provider),
+		instance.Region,
+		instance.Labels[v1.DatacenterLabel],
+		instance.Zone,
+	)
+}
 
-	r, err := http.Get(u)
-	if err != nil {
-		return err
+// collectedAt returns the time the metrics were last updated at,
+// or the current time if the source did not set it
+func collectedAt(metrics v1.Metrics) time.Time {
+	var t time.Time
+	for _, m := range metrics {
+		if m.UpdatedAt.After(t) {
+			t = m.UpdatedAt
+		}
 	}
-	defer r.Body.Close()
 
-	d := yaml.NewDecoder(r.Body)
-	err = d.Decode(&instanceData)
+	if t.IsZero() {
+		return time.Now()
+	}
+	return t
+}
+
+// loadFactorsData sets up the emission factor datasets from the configured
+// path, falling back to the snapshot embedded in the binary, and updates
+// them from the emissions-data repo when configured
+func loadFactorsData(ctx context.Context, cfg *config.FactorsConfig) (factors.DataDir, error) {
+	logger := log.FromContext(ctx)
+
+	dir, err := factors.LoadFactorsData(cfg.Path)
+	if err != nil && cfg.Path != "" {
+		logger.Error("unable to load emission factors, using embedded snapshot", "error", err, "path", cfg.Path)
+		dir, err = factors.LoadFactorsData("")
+	}
 	if err != nil {
-		return err
+		return factors.DataDir{}, err
+	}
+
+	if cfg.Update {
+		updated, err := factors.UpdateFactorsData()
+		if err != nil {
+			logger.Error("unable to update emission factors", "error", err, "version", dir.Version)
+			return dir, nil
+		}
+		dir = updated
 	}
 
-	return nil
+	return dir, nil
 }
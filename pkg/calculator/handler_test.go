@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/re-cinq/aether/pkg/config"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
 	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
 	"github.com/stretchr/testify/require"
 )
@@ -62,12 +64,96 @@ func TestHourlyEmbodiedEmissions(t *testing.T) {
 			},
 			expected: 0.0006649067732115677,
 		},
+		{
+			description: "p4d.24xlarge",
+			specs: factors.Embodied{
+				TotalVCPU:                  96.0,
+				VCPU:                       96,
+				TotalGPU:                   8,
+				GPU:                        8,
+				AdditionalGPUsKiloWattCO2e: 1200,
+				TotalEmbodiedKiloWattCO2e:  4675.5,
+			},
+			expected: 0.0889554794520548,
+		},
+		{
+			description: "g4dn.xlarge",
+			specs: factors.Embodied{
+				TotalVCPU:                  96.0,
+				VCPU:                       4,
+				TotalGPU:                   8,
+				GPU:                        1,
+				AdditionalGPUsKiloWattCO2e: 1200,
+				TotalEmbodiedKiloWattCO2e:  3542.9,
+			},
+			expected: 0.0047112030695078645,
+		},
 	}
 	for _, test := range tt {
 		t.Run(fmt.Sprintf("correct for %s", test.description), func(t *testing.T) {
 			// #nosec G601
-			res := hourlyEmbodiedEmissions(&test.specs)
-			assert.Equal(test.expected, res)
+			m := factors.MachineFromEmbodied(&test.specs)
+			res := hourlyEmbodiedEmissions(&m, serverLifespan).total()
+			assert.InDelta(test.expected, res, 1e-12)
 		})
 	}
 }
+
+func TestPUEOverrides(t *testing.T) {
+	cfg := []config.PUEConfig{
+		{Provider: v1.AWS, Region: "eu-north-1", PUE: 1.2},
+		{Provider: v1.GCP, Region: "europe-west4", PUE: 1.09},
+		{Provider: v1.AWS, Region: "eu-north-1", Zone: "eu-north-1a", PUE: 1.1},
+	}
+
+	require.Equal(t, []factors.PUE{
+		{Region: "eu-north-1", PUE: 1.2},
+		{Region: "eu-north-1", Zone: "eu-north-1a", PUE: 1.1},
+	}, pueOverrides(cfg, v1.AWS))
+	require.Empty(t, pueOverrides(cfg, v1.Azure))
+}
+
+func TestResolvePUE(t *testing.T) {
+	cfg := []config.PUEConfig{
+		{Provider: v1.AWS, Region: "eu-north-1", PUE: 1.2},
+		{Provider: v1.AWS, Region: "eu-north-1", Zone: "eu-north-1a", PUE: 1.1},
+		{Provider: v1.AWS, Zone: "room-42", PUE: 1.4},
+	}
+	factor := &factors.EmissionFactors{
+		ProviderDefaults: &factors.ProviderDefaults{AveragePUE: 1.135},
+	}
+
+	instance := &v1.Instance{
+		Provider: v1.AWS,
+		Region:   "eu-north-1",
+		Zone:     "eu-north-1a",
+	}
+
+	// the zone takes precedence over the region
+	pue, level := resolvePUE(cfg, factor, instance)
+	require.Equal(t, 1.1, pue)
+	require.Equal(t, factors.PUEZone, level)
+
+	// the datacenter takes precedence over the zone
+	instance.Labels = v1.Labels{v1.DatacenterLabel: "room-42"}
+	pue, level = resolvePUE(cfg, factor, instance)
+	require.Equal(t, 1.4, pue)
+	require.Equal(t, factors.PUEZone, level)
+
+	// a datacenter without a PUE falls back to the zone
+	instance.Labels = v1.Labels{v1.DatacenterLabel: "room-7"}
+	pue, level = resolvePUE(cfg, factor, instance)
+	require.Equal(t, 1.1, pue)
+	require.Equal(t, factors.PUEZone, level)
+
+	// and then to the region and the provider
+	instance.Zone = "eu-north-1b"
+	pue, level = resolvePUE(cfg, factor, instance)
+	require.Equal(t, 1.2, pue)
+	require.Equal(t, factors.PUERegion, level)
+
+	instance.Region = "eu-west-1"
+	pue, level = resolvePUE(cfg, factor, instance)
+	require.Equal(t, 1.135, pue)
+	require.Equal(t, factors.PUEProvider, level)
+}
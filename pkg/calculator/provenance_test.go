@@ -0,0 +1,81 @@
+package calculator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenance(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		params   func() *parameters
+		expModel string
+		expFlags []string
+	}{
+		{
+			name:     "spline",
+			params:   params,
+			expModel: SplineModel,
+			expFlags: []string{v1.FlagEstimated},
+		},
+		{
+			name: "min and max wattage fallback",
+			params: func() *parameters {
+				p := params()
+				p.fallbackWattage = true
+				return p
+			},
+			expModel: SplineModel,
+			expFlags: []string{v1.FlagFallbackWattage, v1.FlagEstimated},
+		},
+		{
+			name: "missing vCPU count and usage",
+			params: func() *parameters {
+				p := params()
+				p.machine = &factors.Machine{Instance: data.Instance{PkgWatt: p.machine.PkgWatt}}
+				p.metric.UnitAmount = 2
+				p.metric.Usage = 0
+				return p
+			},
+			expModel: SplineModel,
+			expFlags: []string{v1.FlagMissingVCPU, v1.FlagEstimated, v1.FlagMissingUsage},
+		},
+		{
+			name: "measured energy",
+			params: func() *parameters {
+				p := params()
+				p.metric.Energy = 1
+				p.metric.MeasuredEnergy = true
+				return p
+			},
+			expModel: MeasuredModel,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := test.params()
+			p.metric.Name = v1.CPU.String()
+			p.provenance = v1.Provenance{
+				FactorsVersion: "0123abc",
+				GridSource:     StaticGrid,
+				PUESource:      factors.PUERegion,
+			}
+
+			err := operationalEmissions(context.TODO(), 5*time.Minute, p)
+			assert.Nil(t, err)
+
+			assert.Equal(t, v1.Provenance{
+				EnergyModel:    test.expModel,
+				FactorsVersion: "0123abc",
+				GridSource:     StaticGrid,
+				PUESource:      factors.PUERegion,
+				Flags:          test.expFlags,
+			}, p.metric.Provenance)
+		})
+	}
+}
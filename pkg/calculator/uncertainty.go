@@ -0,0 +1,68 @@
+package calculator
+
+import (
+	"math"
+
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+)
+
+// The relative uncertainties of the inputs of the calculations, used to
+// give the emissions a lower and upper bound. They are rough estimates of
+// the confidence we have in each of the inputs, for example ±10% for the
+// wattage curves measured by Teads.
+const (
+	// energy measured by the hardware (Kepler, RAPL, Redfish)
+	measuredUncertainty = 0.05
+
+	// wattage interpolated from the measured wattage curves
+	splineUncertainty = 0.1
+
+	// wattage interpolated between the min and max wattage
+	linearUncertainty = 0.25
+
+	// vCPU count missing from the dataset and taken from the source
+	missingVCPUUncertainty = 0.15
+
+	// factors approximated from a sibling of an unknown instance kind
+	approximatedUncertainty = 0.3
+
+	// storage and networking coefficients of the CCF
+	coefficientUncertainty = 0.3
+
+	// grid intensity at the time of the measurement
+	timeSeriesGridUncertainty = 0.05
+
+	// annual average grid intensity of the region
+	annualGridUncertainty = 0.2
+
+	// embodied emissions of the CCF and Dell life-cycle assessments
+	embodiedUncertainty = 0.3
+)
+
+// pueUncertainty is the uncertainty of the PUE per level it was resolved
+// at, where the average of the provider is the least accurate
+var pueUncertainty = map[string]float64{
+	factors.PUEZone:     0.02,
+	factors.PUERegion:   0.05,
+	factors.PUEProvider: 0.1,
+}
+
+// gridUncertainty returns the uncertainty of the grid intensity, which is
+// lower for the intensity at the time than for the annual average
+func gridUncertainty(source string) float64 {
+	if source == StaticGrid {
+		return annualGridUncertainty
+	}
+	return timeSeriesGridUncertainty
+}
+
+// combineUncertainty combines the relative uncertainties of the factors
+// that are multiplied with each other. Assuming the uncertainties are
+// independent, they are added in quadrature.
+func combineUncertainty(u ...float64) float64 {
+	var sum float64
+	for _, v := range u {
+		sum += v * v
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,88 @@
+package calculator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombineUncertainty(t *testing.T) {
+	assert.Equal(t, 0.0, combineUncertainty())
+	assert.Equal(t, 0.1, combineUncertainty(0.1))
+	assert.InDelta(t, 0.5, combineUncertainty(0.3, 0.4), 1e-12)
+}
+
+func TestOperationalUncertainty(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		params func() *parameters
+		expU   float64
+	}{
+		{
+			name: "spline with measured PUE and grid at the time",
+			params: func() *parameters {
+				p := params()
+				p.pueUncertainty = 0.02
+				p.gridUncertainty = timeSeriesGridUncertainty
+				return p
+			},
+			expU: combineUncertainty(splineUncertainty, 0.02, timeSeriesGridUncertainty),
+		},
+		{
+			name: "spline with annual grid",
+			params: func() *parameters {
+				p := params()
+				p.gridUncertainty = annualGridUncertainty
+				return p
+			},
+			expU: combineUncertainty(splineUncertainty, annualGridUncertainty),
+		},
+		{
+			name: "min and max wattage fallback",
+			params: func() *parameters {
+				p := params()
+				p.fallbackWattage = true
+				return p
+			},
+			expU: linearUncertainty,
+		},
+		{
+			name: "missing vCPU count",
+			params: func() *parameters {
+				p := params()
+				p.machine = &factors.Machine{Instance: data.Instance{PkgWatt: p.machine.PkgWatt}}
+				p.metric.UnitAmount = 2
+				return p
+			},
+			expU: combineUncertainty(splineUncertainty, missingVCPUUncertainty),
+		},
+		{
+			name: "measured energy",
+			params: func() *parameters {
+				p := params()
+				p.metric.Energy = 1
+				p.metric.MeasuredEnergy = true
+				return p
+			},
+			expU: measuredUncertainty,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := test.params()
+			p.metric.Name = v1.CPU.String()
+
+			err := operationalEmissions(context.TODO(), 5*time.Minute, p)
+			assert.Nil(t, err)
+
+			e := p.metric.Emissions
+			assert.InDelta(t, test.expU, e.Uncertainty(), 1e-12)
+			assert.Less(t, e.Low, e.Value)
+			assert.Greater(t, e.High, e.Value)
+		})
+	}
+}
@@ -35,6 +35,7 @@ func InitConfig(ctx context.Context) {
 
 	// Set defaults
 	viper.SetDefault("api.metricsPath", "/metrics")
+	viper.SetDefault("api.provenanceLabels", false)
 	viper.SetDefault("logLevel", "info")
 	viper.SetDefault("cache.store", "bigcache")
 	viper.SetDefault("cache.expiry", "3600m")
@@ -43,6 +44,41 @@ func InitConfig(ctx context.Context) {
 	viper.SetDefault("api.address", "0.0.0.0")
 	viper.SetDefault("api.port", "8080")
 	viper.SetDefault("providersConfig.scrapingInterval", "5m")
+	viper.SetDefault("providersConfig.jitter", "0s")
+	viper.SetDefault("providersConfig.align", false)
+	viper.SetDefault("providersConfig.timeout", "0s")
+	viper.SetDefault("providersConfig.retry.attempts", 3)
+	viper.SetDefault("providersConfig.retry.backoff", "1s")
+	viper.SetDefault("providersConfig.retry.maxBackoff", "30s")
+	viper.SetDefault("providersConfig.circuitBreaker.failures", 5)
+	viper.SetDefault("providersConfig.circuitBreaker.cooldown", "15m")
+	viper.SetDefault("calculator.energyModel", "spline")
+	viper.SetDefault("calculator.gridIntensity.source", "static")
+	viper.SetDefault("calculator.gridIntensity.cacheExpiry", "1h")
+	viper.SetDefault("calculator.gridIntensity.maxAge", "24h")
+	viper.SetDefault("calculator.serverLifespan", 6)
+	viper.SetDefault("calculator.factors.update", false)
+	viper.SetDefault("calculator.factors.refreshInterval", "24h")
+	viper.SetDefault("bus.bufferSize", 512)
+	viper.SetDefault("bus.workers", 10)
+	viper.SetDefault("bus.overflow", "block")
+	viper.SetDefault("bus.log.enabled", false)
+	viper.SetDefault("bus.log.path", "/var/lib/aether/wal")
+	viper.SetDefault("bus.log.segmentSize", 64)
+	viper.SetDefault("bus.log.retention", "24h")
+	viper.SetDefault("bus.log.syncInterval", "1s")
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.endpoint", "")
+	viper.SetDefault("tracing.insecure", false)
+	viper.SetDefault("tracing.sampleRatio", 1)
+	viper.SetDefault("cluster.role", "all")
+	viper.SetDefault("cluster.address", "")
+	viper.SetDefault("cluster.calculator", "")
+	viper.SetDefault("cluster.tls.insecure", false)
+	viper.SetDefault("cluster.tls.certFile", "")
+	viper.SetDefault("cluster.tls.keyFile", "")
+	viper.SetDefault("cluster.tls.caFile", "")
+	viper.SetDefault("cluster.tls.serverName", "")
 
 	// Find and read the config file
 	err := viper.ReadInConfig()
@@ -108,6 +144,11 @@ func parseApplicationConfig(ctx context.Context) *ApplicationConfig {
 		os.Exit(1)
 	}
 
+	if err := config.Validate(); err != nil {
+		logger.Error("Error validating config file", "error", err)
+		os.Exit(1)
+	}
+
 	// Update the last modified time
 	UpdatedAt = time.Now()
 
@@ -15,6 +15,10 @@ type ApplicationConfig struct {
 	LogLevel        string                   `mapstructure:"logLevel"`
 	Cache           Cache                    `mapstructure:"cache"`
 	Plugins         PluginConfig             `mapstructure:"plugins"`
+	Calculator      CalculatorConfig         `mapstructure:"calculator"`
+	Bus             BusConfig                `mapstructure:"bus"`
+	Cluster         ClusterConfig            `mapstructure:"cluster"`
+	Tracing         TracingConfig            `mapstructure:"tracing"`
 }
 
 // Defines the configuration for the API
@@ -27,6 +31,97 @@ type APIConfig struct {
 
 	// The prometheus metrics path
 	MetricsPath string `mapstructure:"metricsPath"`
+
+	// Add the provenance of the emissions as labels to the
+	// prometheus metrics, which increases their cardinality
+	ProvenanceLabels bool `mapstructure:"provenanceLabels"`
+}
+
+// Defines how the events handled on the bus are traced
+type TracingConfig struct {
+	// Export the spans of the handlers over OTLP
+	Enabled bool `mapstructure:"enabled"`
+
+	// The OTLP gRPC endpoint the spans are exported to, if empty
+	// OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317 is used
+	Endpoint string `mapstructure:"endpoint"`
+
+	// Export the spans without TLS
+	Insecure bool `mapstructure:"insecure"`
+
+	// The share of the events that is traced, between 0 and 1
+	SampleRatio float64 `mapstructure:"sampleRatio"`
+}
+
+// Defines the configuration of the event bus
+type BusConfig struct {
+	// The amount of events the queue holds
+	BufferSize int `mapstructure:"bufferSize"`
+
+	// The amount of workers handling the events
+	Workers int `mapstructure:"workers"`
+
+	// What to do when the queue is full: block, drop-newest,
+	// drop-oldest or error
+	Overflow string `mapstructure:"overflow"`
+
+	// The write-ahead log of the collected metrics
+	Log BusLogConfig `mapstructure:"log"`
+}
+
+// Defines the write-ahead log of the bus, which keeps the collected metrics
+// on disk until they are handled
+type BusLogConfig struct {
+	// Write the collected metrics to the log
+	Enabled bool `mapstructure:"enabled"`
+
+	// The directory of the log
+	Path string `mapstructure:"path"`
+
+	// The size in megabytes after which a new segment file is started
+	SegmentSize int64 `mapstructure:"segmentSize"`
+
+	// How long handled metrics are kept, so they can be replayed
+	Retention time.Duration `mapstructure:"retention"`
+
+	// How often the log is synced to disk
+	SyncInterval time.Duration `mapstructure:"syncInterval"`
+}
+
+// Defines the role of the node when the collectors and calculators run
+// as separate processes
+type ClusterConfig struct {
+	// What the node runs: all, collector or calculator
+	Role string `mapstructure:"role"`
+
+	// The address a calculator node receives the metrics on,
+	// it must be set on calculator nodes
+	Address string `mapstructure:"address"`
+
+	// The gRPC target of the calculator node a collector node
+	// forwards the metrics to
+	Calculator string `mapstructure:"calculator"`
+
+	// The certificates securing the connections between the nodes
+	TLS ClusterTLSConfig `mapstructure:"tls"`
+}
+
+// Defines the certificates of the connections between the nodes
+type ClusterTLSConfig struct {
+	// Sends the metrics in plain text, without authenticating the nodes
+	Insecure bool `mapstructure:"insecure"`
+
+	// The certificate and key of the node
+	CertFile string `mapstructure:"certFile"`
+	KeyFile  string `mapstructure:"keyFile"`
+
+	// The CA the certificates of the other nodes are verified with, a
+	// calculator node with a CA requires the collector nodes to present
+	// a certificate signed by it
+	CAFile string `mapstructure:"caFile"`
+
+	// The name the certificate of the calculator node is verified with
+	ServerName string `mapstructure:"serverName"`
 }
 
 type PluginConfig struct {
@@ -36,13 +131,170 @@ type PluginConfig struct {
 	SourceDir string `mapstructure:"sourceDir"`
 }
 
+// Defines the configuration for the emissions calculator
+type CalculatorConfig struct {
+	// The energy model used when no model is configured
+	// for the provider or service of an instance
+	EnergyModel string `mapstructure:"energyModel"`
+
+	// The energy models to use per provider and optionally per service
+	EnergyModels []EnergyModelConfig `mapstructure:"energyModels"`
+
+	// Where to get the carbon intensity of the electricity grid from
+	GridIntensity GridIntensityConfig `mapstructure:"gridIntensity"`
+
+	// The PUE of regions and datacenters, overriding the PUE
+	// of the emissions dataset
+	PUE []PUEConfig `mapstructure:"pue"`
+
+	// The years the hardware is used for, over which the embodied
+	// emissions are amortized
+	ServerLifespan float64 `mapstructure:"serverLifespan"`
+
+	// The server lifespan per provider and optionally per machine family
+	Lifespans []LifespanConfig `mapstructure:"lifespans"`
+
+	// Where the emission factor datasets are read from
+	Factors FactorsConfig `mapstructure:"factors"`
+}
+
+// Defines where the emission factor datasets are read from
+type FactorsConfig struct {
+	// A local directory or tarball with the datasets, if empty
+	// the snapshot embedded in the binary is used
+	Path string `mapstructure:"path"`
+
+	// Update the datasets from the emissions-data repo when they are
+	// loaded, which requires network access
+	Update bool `mapstructure:"update"`
+
+	// How often the datasets are reloaded, zero disables reloading
+	RefreshInterval time.Duration `mapstructure:"refreshInterval"`
+
+	// A directory with datasets that are merged on top of the datasets,
+	// for private machine types and regions
+	Overrides string `mapstructure:"overrides"`
+}
+
+// Defines the server lifespan of a provider or a machine family
+type LifespanConfig struct {
+	// The provider the lifespan is used for
+	Provider v1.Provider `mapstructure:"provider"`
+
+	// The machine family the lifespan is used for, for example n2 (GCP)
+	// or m6i (AWS). If empty the lifespan is used for the whole provider
+	Family string `mapstructure:"family"`
+
+	// The lifespan in years
+	Years float64 `mapstructure:"years"`
+}
+
+// Defines the PUE of a region, or of a zone or datacenter of a provider
+type PUEConfig struct {
+	// The provider the PUE is used for
+	Provider v1.Provider `mapstructure:"provider"`
+
+	// The region the PUE is used for
+	Region string `mapstructure:"region"`
+
+	// The zone or datacenter the PUE is used for, if empty the
+	// PUE is used for the whole region
+	Zone string `mapstructure:"zone"`
+
+	// The Power Usage Effectiveness
+	PUE float64 `mapstructure:"pue"`
+}
+
+// Selects the energy model for a provider or a service of a provider
+type EnergyModelConfig struct {
+	// The provider the model is used for
+	Provider v1.Provider `mapstructure:"provider"`
+
+	// The service the model is used for, if empty the model
+	// is used for all services of the provider
+	Service string `mapstructure:"service"`
+
+	// The name of the energy model: spline, linear or measured
+	Model string `mapstructure:"model"`
+}
+
+// Defines where the grid carbon intensity is read from
+type GridIntensityConfig struct {
+	// The source of the grid intensity: static, file or http
+	Source string `mapstructure:"source"`
+
+	// File: the CSV or YAML file containing the time series
+	File string `mapstructure:"file"`
+
+	// HTTP: the base url of the Electricity Maps compatible API
+	URL string `mapstructure:"url"`
+
+	// HTTP: the token to authenticate with the API
+	Token string `mapstructure:"token"`
+
+	// HTTP: maps the cloud regions to the zones of the API
+	Zones map[string]string `mapstructure:"zones"`
+
+	// File: how old the latest point of a region may be to be used,
+	// 0 uses the latest point regardless of its age
+	MaxAge time.Duration `mapstructure:"maxAge"`
+
+	// How long the grid intensities are cached for
+	CacheExpiry time.Duration `mapstructure:"cacheExpiry"`
+}
+
 type ProvidersConfig struct {
 	// How often we should scrape the data
 	Interval time.Duration `mapstructure:"scrapingInterval"`
+
+	// The maximum random delay added to each scrape, so the sources
+	// do not call the APIs at the same time, shorter than the intervals
+	Jitter time.Duration `mapstructure:"jitter"`
+
+	// Align the scrapes to the multiples of the interval since midnight
+	// UTC, so a 5m interval scrapes at :00, :05, :10 and so on
+	Align bool `mapstructure:"align"`
+
+	// How long a source may take to fetch the metrics, defaults to
+	// the interval of the source
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// How failed scrapes are retried within the interval
+	Retry RetryConfig `mapstructure:"retry"`
+
+	// When the scrapes of a failing source are paused
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuitBreaker"`
+}
+
+// Defines how failed scrapes are retried, the wait between the attempts
+// doubles up to the maximum
+type RetryConfig struct {
+	// The attempts of a scrape, 1 disables retries
+	Attempts int `mapstructure:"attempts"`
+
+	// The wait before the first retry
+	Backoff time.Duration `mapstructure:"backoff"`
+
+	// The maximum wait between the attempts
+	MaxBackoff time.Duration `mapstructure:"maxBackoff"`
+}
+
+// Defines when the scrapes of a source are paused, after the cooldown a
+// single scrape is tried which resumes the scrapes when it succeeds
+type CircuitBreakerConfig struct {
+	// The consecutive failed scrapes after which the source is paused,
+	// 0 disables the circuit breaker
+	Failures int `mapstructure:"failures"`
+
+	// How long the source is paused
+	Cooldown time.Duration `mapstructure:"cooldown"`
 }
 
 // Defines the general configuration for a provider
 type Provider struct {
+	// How often the accounts of the provider are scraped, overrides
+	// providersConfig.scrapingInterval
+	Interval time.Duration `mapstructure:"scrapingInterval"`
 
 	// A provider can have different accounts and scraping credentials and settings
 	Accounts []Account `mapstructure:"accounts"`
@@ -51,6 +303,18 @@ type Provider struct {
 	Transport TransportConfig `mapstructure:"transport"`
 }
 
+// ScrapingInterval returns how often the account is scraped, which
+// defaults to the interval of the provider and then of all providers
+func (p *Provider) ScrapingInterval(a *Account) time.Duration {
+	if a != nil && a.Interval > 0 {
+		return a.Interval
+	}
+	if p.Interval > 0 {
+		return p.Interval
+	}
+	return AppConfig().Interval
+}
+
 // Cache configurations
 type Cache struct {
 	// The cache store built-into eko/gocache with
@@ -62,6 +326,9 @@ type Cache struct {
 }
 
 type Account struct {
+	// How often the account is scraped, overrides the
+	// interval of the provider
+	Interval time.Duration `mapstructure:"scrapingInterval"`
 
 	// AWS: The regions we should scrape the data for
 	Regions []string `mapstructure:"regions"`
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+)
+
+// day is the longest interval aligned scrapes support, as they are
+// aligned since the start of the day
+const day = 24 * time.Hour
+
+// Validate returns the settings of the config that cannot be used,
+// which would otherwise block or spin the exporter at runtime
+func (c *ApplicationConfig) Validate() error {
+	var errs []error
+
+	if c.Bus.BufferSize < 1 {
+		errs = append(errs, fmt.Errorf("error bus.bufferSize must be at least 1: %d", c.Bus.BufferSize))
+	}
+	if c.Bus.Workers < 1 {
+		errs = append(errs, fmt.Errorf("error bus.workers must be at least 1: %d", c.Bus.Workers))
+	}
+
+	errs = append(errs, c.validateSchedule()...)
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("error tracing.sampleRatio must be between 0 and 1: %g", c.Tracing.SampleRatio))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateSchedule returns the scraping intervals the sources cannot be
+// scheduled with, the intervals of the providers and accounts default to
+// providersConfig.scrapingInterval when they are 0
+func (c *ApplicationConfig) validateSchedule() []error {
+	var errs []error
+
+	if c.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("error providersConfig.scrapingInterval must be positive: %s", c.Interval))
+	}
+	if c.Jitter < 0 {
+		errs = append(errs, fmt.Errorf("error providersConfig.jitter must not be negative: %s", c.Jitter))
+	}
+
+	keys := []string{"providersConfig.scrapingInterval"}
+	intervals := map[string]time.Duration{keys[0]: c.Interval}
+
+	providers := make([]string, 0, len(c.Providers))
+	for name := range c.Providers {
+		providers = append(providers, string(name))
+	}
+	sort.Strings(providers)
+
+	for _, name := range providers {
+		p := c.Providers[v1.Provider(name)]
+		if p.Interval != 0 {
+			key := fmt.Sprintf("providers.%s.scrapingInterval", name)
+			keys = append(keys, key)
+			intervals[key] = p.Interval
+		}
+
+		for i := range p.Accounts {
+			if p.Accounts[i].Interval != 0 {
+				key := fmt.Sprintf("providers.%s.accounts.%d.scrapingInterval", name, i)
+				keys = append(keys, key)
+				intervals[key] = p.Accounts[i].Interval
+			}
+		}
+	}
+
+	for i, key := range keys {
+		interval := intervals[key]
+		if i > 0 && interval < 0 {
+			errs = append(errs, fmt.Errorf("error %s must be positive: %s", key, interval))
+			continue
+		}
+		if interval <= 0 {
+			continue
+		}
+
+		if c.Jitter >= interval {
+			errs = append(errs, fmt.Errorf("error providersConfig.jitter must be shorter than %s: %s", key, interval))
+		}
+		if c.Align && interval > day {
+			errs = append(errs, fmt.Errorf("error providersConfig.align requires %s of at most 24h: %s", key, interval))
+		}
+	}
+
+	return errs
+}
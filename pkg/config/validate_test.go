@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	c := &ApplicationConfig{
+		ProvidersConfig: ProvidersConfig{Interval: 5 * time.Minute},
+		Bus:             BusConfig{BufferSize: 512, Workers: 10},
+	}
+	assert.Nil(t, c.Validate())
+
+	c.Bus = BusConfig{BufferSize: 0, Workers: -1}
+	c.Interval = 0
+	c.Tracing.SampleRatio = 1.5
+	assert.EqualError(t, c.Validate(), "error bus.bufferSize must be at least 1: 0\n"+
+		"error bus.workers must be at least 1: -1\n"+
+		"error providersConfig.scrapingInterval must be positive: 0s\n"+
+		"error tracing.sampleRatio must be between 0 and 1: 1.5")
+}
+
+func TestValidateSchedule(t *testing.T) {
+	c := &ApplicationConfig{
+		ProvidersConfig: ProvidersConfig{
+			Interval: 5 * time.Minute,
+			Jitter:   time.Minute,
+			Align:    true,
+		},
+		Providers: map[v1.Provider]Provider{
+			v1.AWS: {Interval: time.Minute, Accounts: []Account{{}, {Interval: 48 * time.Hour}}},
+			v1.GCP: {Interval: -time.Minute},
+		},
+	}
+
+	// the intervals of the providers and accounts are checked
+	// as well, an interval of 0 is the default
+	assert.EqualError(t, errors.Join(c.validateSchedule()...),
+		"error providersConfig.jitter must be shorter than providers.aws.scrapingInterval: 1m0s\n"+
+			"error providersConfig.align requires providers.aws.accounts.1.scrapingInterval of at most 24h: 48h0m0s\n"+
+			"error providers.gcp.scrapingInterval must be positive: -1m0s")
+}
@@ -3,14 +3,15 @@ package exporter
 import (
 	"context"
 	"log/slog"
+	"strings"
 
 	"github.com/re-cinq/aether/pkg/bus"
+	"github.com/re-cinq/aether/pkg/config"
 	"github.com/re-cinq/aether/pkg/log"
 	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/prometheus"
 	api "go.opentelemetry.io/otel/metric"
-	"go.opentelemetry.io/otel/sdk/metric"
 )
 
 // PromHandler is the Event handnelr used to configure prometheus
@@ -19,26 +20,21 @@ type PromHandler struct {
 	Bus    *bus.Bus
 	meter  api.Meter
 	logger *slog.Logger
+
+	// provenanceLabels adds the provenance of the
+	// emissions to the labels of the metrics
+	provenanceLabels bool
 }
 
 // NewHandler returns a configured instance of PromHandler
 func NewHandler(ctx context.Context, b *bus.Bus) *PromHandler {
-	logger := log.FromContext(ctx)
-
-	exporter, err := prometheus.New()
-	if err != nil {
-		logger.Error("failed setting up prometheus", "error", err)
-		return nil
-	}
-
-	meter := metric.NewMeterProvider(
-		metric.WithReader(exporter),
-	).Meter("aether")
-
+	// the meter provider exposes the metrics on the
+	// prometheus endpoint, it is set up by the telemetry
 	return &PromHandler{
-		Bus:    b,
-		meter:  meter,
-		logger: logger,
+		Bus:              b,
+		meter:            otel.Meter("aether"),
+		logger:           log.FromContext(ctx),
+		provenanceLabels: config.AppConfig().APIConfig.ProvenanceLabels,
 	}
 }
 
@@ -49,20 +45,20 @@ func (p *PromHandler) Stop(ctx context.Context) {}
 func (p *PromHandler) Handle(ctx context.Context, e *bus.Event) {
 	switch e.Type {
 	case v1.EmissionsCalculatedEvent:
-		p.handleEvent(e)
+		i, ok := e.Data.(v1.Instance)
+		if !ok {
+			p.logger.Error("wrong data on event", "event", e.Type, "id", e.Metadata.ID)
+			return
+		}
+		p.HandleInstance(ctx, i)
 	default:
 		return
 	}
 }
 
-// handleEvent is the business logic for handleing the v1.EmissionsCalculatedEvent
-func (p *PromHandler) handleEvent(e *bus.Event) {
-	i, ok := e.Data.(v1.Instance)
-	if !ok {
-		// wrong data on event
-		return
-	}
-
+// HandleInstance is the business logic for handleing the v1.EmissionsCalculatedEvent,
+// it is subscribed to the v1.EmissionsCalculated topic
+func (p *PromHandler) HandleInstance(ctx context.Context, i v1.Instance) {
 	// if instance is terminated we dont do anything
 	if i.Status == v1.InstanceTerminated {
 		return
@@ -78,6 +74,13 @@ func (p *PromHandler) handleEvent(e *bus.Event) {
 		return
 	}
 
+	// setup the gauges for the bounds of the emissions
+	emissionsLow, emissionsHigh, err := p.boundGauges("emissions")
+	if err != nil {
+		p.logger.Error("[otel] failed setting up emissions bounds metrics")
+		return
+	}
+
 	// setup embodied emissions gauge
 	embodied, err := p.meter.Float64ObservableGauge(
 		"embodied",
@@ -88,19 +91,23 @@ func (p *PromHandler) handleEvent(e *bus.Event) {
 		return
 	}
 
+	embodiedLow, embodiedHigh, err := p.boundGauges("embodied")
+	if err != nil {
+		p.logger.Error("[otel] failed setting up embodied emissions bounds metrics")
+		return
+	}
+
 	// register embodied emissions metrics for instance
 	// NOTE: this will not change based on different types of metrics
 	_, err = p.meter.RegisterCallback(
 		func(ctx context.Context, o api.Observer) error {
-			o.ObserveFloat64(
-				embodied,
-				i.EmbodiedEmissions.Value,
-				api.WithAttributes(
-					getAttributesFromInstance(&i)...,
-				))
+			attrs := api.WithAttributes(getAttributesFromInstance(&i)...)
+			o.ObserveFloat64(embodied, i.EmbodiedEmissions.Value, attrs)
+			o.ObserveFloat64(embodiedLow, i.EmbodiedEmissions.Low, attrs)
+			o.ObserveFloat64(embodiedHigh, i.EmbodiedEmissions.High, attrs)
 
 			return nil
-		}, embodied)
+		}, embodied, embodiedLow, embodiedHigh)
 	if err != nil {
 		p.logger.Error("failed setting embodied metric", "instance", i.Name)
 		return
@@ -117,6 +124,10 @@ func (p *PromHandler) handleEvent(e *bus.Event) {
 			attribute.Key("type").String(m.ResourceType.String()),
 		)
 
+		if p.provenanceLabels {
+			attrs = append(attrs, getAttributesFromProvenance(&m.Provenance)...)
+		}
+
 		// register emission metrics for instance
 		_, err := p.meter.RegisterCallback(
 			func(ctx context.Context, o api.Observer) error {
@@ -125,14 +136,46 @@ func (p *PromHandler) handleEvent(e *bus.Event) {
 					m.Emissions.Value,
 					api.WithAttributes(attrs...),
 				)
+				o.ObserveFloat64(
+					emissionsLow,
+					m.Emissions.Low,
+					api.WithAttributes(attrs...),
+				)
+				o.ObserveFloat64(
+					emissionsHigh,
+					m.Emissions.High,
+					api.WithAttributes(attrs...),
+				)
 				return nil
-			}, emissions)
+			}, emissions, emissionsLow, emissionsHigh)
 		if err != nil {
 			p.logger.Error("failed setting metric", "instance", i.Name)
 		}
 	}
 }
 
+// boundGauges sets up the gauges for the lower and upper bounds
+// of the emissions, named {name}_low and {name}_high
+func (p *PromHandler) boundGauges(name string) (api.Float64ObservableGauge, api.Float64ObservableGauge, error) {
+	low, err := p.meter.Float64ObservableGauge(
+		name+"_low",
+		api.WithDescription("lower bound of the co2eq of various services"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	high, err := p.meter.Float64ObservableGauge(
+		name+"_high",
+		api.WithDescription("upper bound of the co2eq of various services"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return low, high, nil
+}
+
 func getAtrributesFromLabels(m *v1.Metric) []attribute.KeyValue {
 	attrs := []attribute.KeyValue{}
 	for k, l := range m.Labels {
@@ -151,3 +194,13 @@ func getAttributesFromInstance(i *v1.Instance) []attribute.KeyValue {
 		attribute.Key("provider").String(i.Provider.String()),
 	}
 }
+
+func getAttributesFromProvenance(p *v1.Provenance) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Key("energy_model").String(p.EnergyModel),
+		attribute.Key("factors_version").String(p.FactorsVersion),
+		attribute.Key("grid_source").String(p.GridSource),
+		attribute.Key("pue_source").String(p.PUESource),
+		attribute.Key("flags").String(strings.Join(p.Flags, ",")),
+	}
+}
@@ -15,17 +15,20 @@ func ConvertToPB(src *v1.Instance) (*InstanceRequest, error) {
 	}
 
 	dest := &InstanceRequest{
-		Id:       src.ID,
-		Provider: string(src.Provider),
-		Service:  src.Service,
-		Name:     src.Name,
-		Region:   src.Region,
-		Zone:     src.Zone,
-		Kind:     src.Kind,
-		Status:   string(src.Status),
-		EmbodiedEmissions: &ResourceEmissions{
-			Value: src.EmbodiedEmissions.Value,
-			Unit:  string(src.EmbodiedEmissions.Unit),
+		Id:                src.ID,
+		Provider:          string(src.Provider),
+		Service:           src.Service,
+		Name:              src.Name,
+		Region:            src.Region,
+		Zone:              src.Zone,
+		Kind:              src.Kind,
+		Status:            string(src.Status),
+		EmbodiedEmissions: convertResourceEmissionsToPB(src.EmbodiedEmissions),
+		EmbodiedBreakdown: &EmbodiedBreakdown{
+			Cpu:     convertResourceEmissionsToPB(src.EmbodiedBreakdown.CPU),
+			Memory:  convertResourceEmissionsToPB(src.EmbodiedBreakdown.Memory),
+			Storage: convertResourceEmissionsToPB(src.EmbodiedBreakdown.Storage),
+			Gpu:     convertResourceEmissionsToPB(src.EmbodiedBreakdown.GPU),
 		},
 		Labels: map[string]string(src.Labels),
 	}
@@ -33,17 +36,17 @@ func ConvertToPB(src *v1.Instance) (*InstanceRequest, error) {
 	metrics := make(map[string]*Metric)
 	for key, metric := range src.Metrics {
 		metrics[key] = &Metric{
-			Name:       metric.Name,
-			Usage:      metric.Usage,
-			UnitAmount: metric.UnitAmount,
-			Unit:       string(metric.Unit),
-			Energy:     metric.Energy,
-			Emissions: &ResourceEmissions{
-				Value: metric.Emissions.Value,
-				Unit:  string(metric.Emissions.Unit),
-			},
-			Labels:    map[string]string(metric.Labels),
-			UpdatedAt: metric.UpdatedAt.Unix(),
+			Name:           metric.Name,
+			Usage:          metric.Usage,
+			UnitAmount:     metric.UnitAmount,
+			Unit:           string(metric.Unit),
+			Energy:         metric.Energy,
+			MeasuredEnergy: metric.MeasuredEnergy,
+			Pue:            metric.PUE,
+			Provenance:     convertProvenanceToPB(metric.Provenance),
+			Emissions:      convertResourceEmissionsToPB(metric.Emissions),
+			Labels:         map[string]string(metric.Labels),
+			UpdatedAt:      metric.UpdatedAt.Unix(),
 		}
 	}
 	dest.Metrics = metrics
@@ -67,33 +70,88 @@ func ConvertToInstance(src *InstanceRequest) (*v1.Instance, error) {
 		Kind:     src.Kind,
 		Status:   v1.InstanceStatus(src.Status),
 		Labels:   v1.Labels(src.Labels),
+
+		EmbodiedEmissions: convertResourceEmissions(src.EmbodiedEmissions),
 	}
 
-	if src.EmbodiedEmissions != nil {
-		instance.EmbodiedEmissions = v1.ResourceEmissions{
-			Value: src.EmbodiedEmissions.Value,
-			Unit:  v1.EmissionUnit(src.EmbodiedEmissions.Unit),
+	if src.EmbodiedBreakdown != nil {
+		instance.EmbodiedBreakdown = v1.EmbodiedBreakdown{
+			CPU:     convertResourceEmissions(src.EmbodiedBreakdown.Cpu),
+			Memory:  convertResourceEmissions(src.EmbodiedBreakdown.Memory),
+			Storage: convertResourceEmissions(src.EmbodiedBreakdown.Storage),
+			GPU:     convertResourceEmissions(src.EmbodiedBreakdown.Gpu),
 		}
 	}
 
 	instanceMetrics := make(v1.Metrics)
 	for key, metric := range src.Metrics {
 		instanceMetrics[key] = v1.Metric{
-			Name:         metric.Name,
-			ResourceType: v1.ResourceType(metric.ResourceType),
-			Usage:        metric.Usage,
-			Energy:       metric.Energy,
-			UnitAmount:   metric.UnitAmount,
-			Unit:         v1.ResourceUnit(metric.Unit),
-			Emissions: v1.ResourceEmissions{
-				Value: metric.Emissions.Value,
-				Unit:  v1.EmissionUnit(metric.Emissions.Unit),
-			},
-			UpdatedAt: time.Unix(metric.UpdatedAt, 0),
-			Labels:    v1.Labels(metric.Labels),
+			Name:           metric.Name,
+			ResourceType:   v1.ResourceType(metric.ResourceType),
+			Usage:          metric.Usage,
+			Energy:         metric.Energy,
+			MeasuredEnergy: metric.MeasuredEnergy,
+			PUE:            metric.Pue,
+			Provenance:     convertProvenance(metric.Provenance),
+			UnitAmount:     metric.UnitAmount,
+			Unit:           v1.ResourceUnit(metric.Unit),
+			Emissions:      convertResourceEmissions(metric.Emissions),
+			UpdatedAt:      time.Unix(metric.UpdatedAt, 0),
+			Labels:         v1.Labels(metric.Labels),
 		}
 	}
 	instance.Metrics = instanceMetrics
 
 	return instance, nil
 }
+
+// convertResourceEmissionsToPB converts the resource emissions to their
+// protobuffer alternative
+func convertResourceEmissionsToPB(src v1.ResourceEmissions) *ResourceEmissions {
+	return &ResourceEmissions{
+		Value: src.Value,
+		Unit:  string(src.Unit),
+		Low:   src.Low,
+		High:  src.High,
+	}
+}
+
+// convertResourceEmissions converts the protobuffer resource emissions,
+// which are not set when the plugin did not send them
+func convertResourceEmissions(src *ResourceEmissions) v1.ResourceEmissions {
+	if src == nil {
+		return v1.ResourceEmissions{}
+	}
+	return v1.ResourceEmissions{
+		Value: src.Value,
+		Unit:  v1.EmissionUnit(src.Unit),
+		Low:   src.Low,
+		High:  src.High,
+	}
+}
+
+// convertProvenanceToPB converts the provenance to its protobuffer alternative
+func convertProvenanceToPB(src v1.Provenance) *Provenance {
+	return &Provenance{
+		EnergyModel:    src.EnergyModel,
+		FactorsVersion: src.FactorsVersion,
+		GridSource:     src.GridSource,
+		PueSource:      src.PUESource,
+		Flags:          src.Flags,
+	}
+}
+
+// convertProvenance converts the protobuffer provenance, which is not set
+// when the plugin did not send it
+func convertProvenance(src *Provenance) v1.Provenance {
+	if src == nil {
+		return v1.Provenance{}
+	}
+	return v1.Provenance{
+		EnergyModel:    src.EnergyModel,
+		FactorsVersion: src.FactorsVersion,
+		GridSource:     src.GridSource,
+		PUESource:      src.PueSource,
+		Flags:          src.Flags,
+	}
+}
@@ -2,6 +2,7 @@ package proto
 
 import (
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
@@ -30,17 +31,29 @@ func TestConvertToPB(t *testing.T) {
 				Kind:              "test-kind",
 				Status:            v1.InstanceRunning,
 				EmbodiedEmissions: v1.ResourceEmissions{Value: 200, Unit: "test-unit"},
-				Labels:            map[string]string{"label1": "value1", "label2": "value2"},
+				EmbodiedBreakdown: v1.EmbodiedBreakdown{
+					CPU:    v1.ResourceEmissions{Value: 150, Unit: "test-unit"},
+					Memory: v1.ResourceEmissions{Value: 50, Unit: "test-unit"},
+				},
+				Labels: map[string]string{"label1": "value1", "label2": "value2"},
 				Metrics: map[string]v1.Metric{
 					"metric1": {
-						Name:       "metric1",
-						Usage:      100,
-						UnitAmount: 10.5,
-						Energy:     0.0001,
-						Unit:       "test-unit",
-						Emissions:  v1.ResourceEmissions{Value: 50, Unit: "test-unit"},
-						Labels:     map[string]string{"label1": "value1"},
-						UpdatedAt:  time.Now(),
+						Name:           "metric1",
+						Usage:          100,
+						UnitAmount:     10.5,
+						Energy:         0.0001,
+						MeasuredEnergy: true,
+						PUE:            1.1,
+						Provenance: v1.Provenance{
+							EnergyModel: "measured",
+							GridSource:  "file",
+							PUESource:   "zone",
+							Flags:       []string{"missing_usage"},
+						},
+						Unit:      "test-unit",
+						Emissions: v1.ResourceEmissions{Value: 50, Unit: "test-unit", Low: 40, High: 60},
+						Labels:    map[string]string{"label1": "value1"},
+						UpdatedAt: time.Now(),
 					},
 				},
 			},
@@ -54,17 +67,31 @@ func TestConvertToPB(t *testing.T) {
 				Kind:              "test-kind",
 				Status:            string(v1.InstanceRunning),
 				EmbodiedEmissions: &ResourceEmissions{Value: 200, Unit: "test-unit"},
-				Labels:            map[string]string{"label1": "value1", "label2": "value2"},
+				EmbodiedBreakdown: &EmbodiedBreakdown{
+					Cpu:     &ResourceEmissions{Value: 150, Unit: "test-unit"},
+					Memory:  &ResourceEmissions{Value: 50, Unit: "test-unit"},
+					Storage: &ResourceEmissions{},
+					Gpu:     &ResourceEmissions{},
+				},
+				Labels: map[string]string{"label1": "value1", "label2": "value2"},
 				Metrics: map[string]*Metric{
 					"metric1": {
-						Name:       "metric1",
-						Usage:      100,
-						UnitAmount: 10.5,
-						Energy:     0.0001,
-						Unit:       "test-unit",
-						Emissions:  &ResourceEmissions{Value: 50, Unit: "test-unit"},
-						Labels:     map[string]string{"label1": "value1"},
-						UpdatedAt:  time.Now().Unix(),
+						Name:           "metric1",
+						Usage:          100,
+						UnitAmount:     10.5,
+						Energy:         0.0001,
+						MeasuredEnergy: true,
+						Pue:            1.1,
+						Provenance: &Provenance{
+							EnergyModel: "measured",
+							GridSource:  "file",
+							PueSource:   "zone",
+							Flags:       []string{"missing_usage"},
+						},
+						Unit:      "test-unit",
+						Emissions: &ResourceEmissions{Value: 50, Unit: "test-unit", Low: 40, High: 60},
+						Labels:    map[string]string{"label1": "value1"},
+						UpdatedAt: time.Now().Unix(),
 					},
 				},
 			},
@@ -106,6 +133,11 @@ func compareInstanceRequests(a, b *InstanceRequest) bool {
 		return false
 	}
 
+	// Compare EmbodiedBreakdown
+	if !compareEmbodiedBreakdown(a.EmbodiedBreakdown, b.EmbodiedBreakdown) {
+		return false
+	}
+
 	// Compare Labels
 	if !compareStringMaps(a.Labels, b.Labels) {
 		return false
@@ -119,6 +151,20 @@ func compareInstanceRequests(a, b *InstanceRequest) bool {
 	return true
 }
 
+func compareEmbodiedBreakdown(a, b *EmbodiedBreakdown) bool {
+	if a == nil && b != nil || a != nil && b == nil {
+		return false
+	}
+	if a == nil && b == nil {
+		return true
+	}
+
+	return compareResourceEmissions(a.Cpu, b.Cpu) &&
+		compareResourceEmissions(a.Memory, b.Memory) &&
+		compareResourceEmissions(a.Storage, b.Storage) &&
+		compareResourceEmissions(a.Gpu, b.Gpu)
+}
+
 func compareResourceEmissions(a, b *ResourceEmissions) bool {
 	if a == nil && b != nil || a != nil && b == nil {
 		return false
@@ -127,7 +173,20 @@ func compareResourceEmissions(a, b *ResourceEmissions) bool {
 		return true
 	}
 
-	return a.Value == b.Value && a.Unit == b.Unit
+	return a.Value == b.Value && a.Unit == b.Unit && a.Low == b.Low && a.High == b.High
+}
+
+func compareProvenance(a, b *Provenance) bool {
+	if a == nil && b != nil || a != nil && b == nil {
+		return false
+	}
+	if a == nil && b == nil {
+		return true
+	}
+
+	return a.EnergyModel == b.EnergyModel && a.FactorsVersion == b.FactorsVersion &&
+		a.GridSource == b.GridSource && a.PueSource == b.PueSource &&
+		slices.Equal(a.Flags, b.Flags)
 }
 
 func compareStringMaps(a, b map[string]string) bool {
@@ -170,6 +229,8 @@ func compareMetrics(a, b *Metric) bool {
 
 	return a.Name == b.Name && a.Usage == b.Usage &&
 		a.UnitAmount == b.UnitAmount && a.Unit == b.Unit && a.Energy == b.Energy &&
+		a.MeasuredEnergy == b.MeasuredEnergy && a.Pue == b.Pue &&
+		compareProvenance(a.Provenance, b.Provenance) &&
 		a.UpdatedAt == b.UpdatedAt && compareStringMaps(a.Labels, b.Labels) &&
 		compareResourceEmissions(a.Emissions, b.Emissions)
 }
@@ -195,18 +256,33 @@ func TestConvertToInstance(t *testing.T) {
 					Value: 200,
 					Unit:  "test-unit",
 				},
+				EmbodiedBreakdown: &EmbodiedBreakdown{
+					Cpu: &ResourceEmissions{
+						Value: 200,
+						Unit:  "test-unit",
+					},
+				},
 				Labels: map[string]string{"label1": "value1", "label2": "value2"},
 				Metrics: map[string]*Metric{
 					"metric1": {
-						Name:         "metric1",
-						ResourceType: "test-resource-type",
-						Usage:        100,
-						UnitAmount:   10.5,
-						Energy:       0.0001,
-						Unit:         "test-unit",
+						Name:           "metric1",
+						ResourceType:   "test-resource-type",
+						Usage:          100,
+						UnitAmount:     10.5,
+						Energy:         0.0001,
+						MeasuredEnergy: true,
+						Pue:            1.1,
+						Provenance: &Provenance{
+							EnergyModel:    "spline",
+							FactorsVersion: "0123abc",
+							Flags:          []string{"estimated", "fallback_wattage"},
+						},
+						Unit: "test-unit",
 						Emissions: &ResourceEmissions{
 							Value: 50,
 							Unit:  "test-unit",
+							Low:   40,
+							High:  60,
 						},
 						UpdatedAt: 1234567890,
 						Labels:    map[string]string{"label1": "value1"},
@@ -226,17 +302,32 @@ func TestConvertToInstance(t *testing.T) {
 					Value: 200,
 					Unit:  v1.EmissionUnit("test-unit"),
 				},
+				EmbodiedBreakdown: v1.EmbodiedBreakdown{
+					CPU: v1.ResourceEmissions{
+						Value: 200,
+						Unit:  v1.EmissionUnit("test-unit"),
+					},
+				},
 				Metrics: v1.Metrics{
 					"metric1": {
-						Name:         "metric1",
-						ResourceType: v1.ResourceType("test-resource-type"),
-						Usage:        100,
-						UnitAmount:   10.5,
-						Energy:       0.0001,
-						Unit:         v1.ResourceUnit("test-unit"),
+						Name:           "metric1",
+						ResourceType:   v1.ResourceType("test-resource-type"),
+						Usage:          100,
+						UnitAmount:     10.5,
+						Energy:         0.0001,
+						MeasuredEnergy: true,
+						PUE:            1.1,
+						Provenance: v1.Provenance{
+							EnergyModel:    "spline",
+							FactorsVersion: "0123abc",
+							Flags:          []string{"estimated", "fallback_wattage"},
+						},
+						Unit: v1.ResourceUnit("test-unit"),
 						Emissions: v1.ResourceEmissions{
 							Value: 50,
 							Unit:  v1.EmissionUnit("test-unit"),
+							Low:   40,
+							High:  60,
 						},
 						UpdatedAt: time.Unix(1234567890, 0),
 						Labels:    v1.Labels{"label1": "value1"},
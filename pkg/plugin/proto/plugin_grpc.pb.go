@@ -107,8 +107,9 @@ var Exporter_ServiceDesc = grpc.ServiceDesc{
 }
 
 const (
-	Source_Fetch_FullMethodName = "/proto.Source/Fetch"
-	Source_Stop_FullMethodName  = "/proto.Source/Stop"
+	Source_Fetch_FullMethodName      = "/proto.Source/Fetch"
+	Source_FetchRange_FullMethodName = "/proto.Source/FetchRange"
+	Source_Stop_FullMethodName       = "/proto.Source/Stop"
 )
 
 // SourceClient is the client API for Source service.
@@ -116,6 +117,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type SourceClient interface {
 	Fetch(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ListInstanceResponse, error)
+	FetchRange(ctx context.Context, in *FetchRangeRequest, opts ...grpc.CallOption) (*ListInstanceResponse, error)
 	Stop(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
 }
 
@@ -136,6 +138,15 @@ func (c *sourceClient) Fetch(ctx context.Context, in *Empty, opts ...grpc.CallOp
 	return out, nil
 }
 
+func (c *sourceClient) FetchRange(ctx context.Context, in *FetchRangeRequest, opts ...grpc.CallOption) (*ListInstanceResponse, error) {
+	out := new(ListInstanceResponse)
+	err := c.cc.Invoke(ctx, Source_FetchRange_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *sourceClient) Stop(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
 	out := new(Empty)
 	err := c.cc.Invoke(ctx, Source_Stop_FullMethodName, in, out, opts...)
@@ -150,6 +161,7 @@ func (c *sourceClient) Stop(ctx context.Context, in *Empty, opts ...grpc.CallOpt
 // for forward compatibility
 type SourceServer interface {
 	Fetch(context.Context, *Empty) (*ListInstanceResponse, error)
+	FetchRange(context.Context, *FetchRangeRequest) (*ListInstanceResponse, error)
 	Stop(context.Context, *Empty) (*Empty, error)
 }
 
@@ -160,6 +172,9 @@ type UnimplementedSourceServer struct {
 func (UnimplementedSourceServer) Fetch(context.Context, *Empty) (*ListInstanceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Fetch not implemented")
 }
+func (UnimplementedSourceServer) FetchRange(context.Context, *FetchRangeRequest) (*ListInstanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchRange not implemented")
+}
 func (UnimplementedSourceServer) Stop(context.Context, *Empty) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
 }
@@ -193,6 +208,24 @@ func _Source_Fetch_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Source_FetchRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SourceServer).FetchRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Source_FetchRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SourceServer).FetchRange(ctx, req.(*FetchRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Source_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Empty)
 	if err := dec(in); err != nil {
@@ -222,6 +255,10 @@ var Source_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Fetch",
 			Handler:    _Source_Fetch_Handler,
 		},
+		{
+			MethodName: "FetchRange",
+			Handler:    _Source_FetchRange_Handler,
+		},
 		{
 			MethodName: "Stop",
 			Handler:    _Source_Stop_Handler,
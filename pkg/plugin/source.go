@@ -7,10 +7,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/re-cinq/aether/pkg/log"
 	"github.com/re-cinq/aether/pkg/plugin/proto"
@@ -54,6 +57,33 @@ func (g *SourceGRPCClient) Fetch(ctx context.Context) ([]*v1.Instance, error) {
 	return instances, err
 }
 
+// FetchRange is used to fullfil the v1.RangeSource interface over RPC,
+// plugins that do not implement it return v1.ErrRangeNotSupported
+func (g *SourceGRPCClient) FetchRange(ctx context.Context, start, end time.Time, step time.Duration) ([]*v1.Instance, error) {
+	res, err := g.client.FetchRange(ctx, &proto.FetchRangeRequest{
+		Start: start.UnixNano(),
+		End:   end.UnixNano(),
+		Step:  int64(step),
+	})
+	if status.Code(err) == codes.Unimplemented {
+		return nil, v1.ErrRangeNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []*v1.Instance
+	for i := range res.Instances {
+		r, err := proto.ConvertToInstance(res.Instances[i])
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, r)
+	}
+
+	return instances, nil
+}
+
 // Stop is used to fullfil the v1.Source interface over RPC
 func (g *SourceGRPCClient) Stop(ctx context.Context) error {
 	return nil
@@ -82,6 +112,33 @@ func (s *SourceGRPCServer) Fetch(
 	return &listRes, err
 }
 
+// FetchRange is used to fulfill the GRPC Interface, the plugin fetches
+// ranges when it implements v1.RangeSource
+func (s *SourceGRPCServer) FetchRange(
+	ctx context.Context,
+	p *proto.FetchRangeRequest,
+) (*proto.ListInstanceResponse, error) {
+	impl, ok := s.Impl.(v1.RangeSource)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, v1.ErrRangeNotSupported.Error())
+	}
+
+	res, err := impl.FetchRange(ctx, time.Unix(0, p.Start), time.Unix(0, p.End), time.Duration(p.Step))
+	if err != nil {
+		return nil, err
+	}
+
+	var listRes proto.ListInstanceResponse
+	for i := range res {
+		r, err := proto.ConvertToPB(res[i])
+		if err != nil {
+			return nil, err
+		}
+		listRes.Instances = append(listRes.Instances, r)
+	}
+	return &listRes, nil
+}
+
 // Stop is used to fulfill the GRPC Interface
 func (s *SourceGRPCServer) Stop(
 	ctx context.Context,
@@ -19,7 +19,12 @@ func NewHandler(ctx context.Context, e *ExportPluginSystem) *PluginHandler {
 func (p *PluginHandler) Handle(ctx context.Context, e *bus.Event) {
 	switch e.Type {
 	case v1.EmissionsCalculatedEvent:
-		p.SendToExporters(ctx, e)
+		instance, ok := e.Data.(v1.Instance)
+		if !ok {
+			log.FromContext(ctx).Error("wrong data on event", "event", e.Type, "id", e.Metadata.ID)
+			return
+		}
+		p.SendToExporters(ctx, instance)
 	default:
 		return
 	}
@@ -27,13 +32,10 @@ func (p *PluginHandler) Handle(ctx context.Context, e *bus.Event) {
 
 func (p *PluginHandler) Stop(ctx context.Context) {}
 
-func (p *PluginHandler) SendToExporters(ctx context.Context, e *bus.Event) {
+// SendToExporters sends the instance to all exporter plugins, it is
+// subscribed to the v1.EmissionsCalculated topic
+func (p *PluginHandler) SendToExporters(ctx context.Context, instance v1.Instance) {
 	logger := log.FromContext(ctx)
-	instance, ok := e.Data.(v1.Instance)
-	if !ok {
-		// wrong data on event
-		return
-	}
 
 	for i := range p.exporters.Plugins {
 		err := p.exporters.Plugins[i].Exporter.Send(&instance)
@@ -79,14 +79,6 @@ func (c *Client) cpuMetrics(ctx context.Context, region string, input *cloudwatc
 		}
 
 		if len(metric.Values) > 0 {
-			m := v1.NewMetric(v1.CPU.String())
-			m.Unit = v1.VCPU
-			m.Usage = metric.Values[0]
-			m.ResourceType = v1.CPU
-			m.Labels = v1.Labels{
-				"instanceID": instanceID,
-			}
-
 			// Update cached instance with metric
 			key := util.Key(region, ec2Service, instanceID)
 			instance, ok := c.instancesMap[key]
@@ -95,22 +87,38 @@ func (c *Client) cpuMetrics(ctx context.Context, region string, input *cloudwatc
 				continue
 			}
 
-			// ParseFloat returns 0 on failure, since that's the default
-			// value of an unassigned int, store it regardless of the
-			// error. This value for vCPUs is a fallback to that provided
-			// by the dataset.
-			if vCPUs, exists := instance.Labels["VCPUCount"]; exists {
-				m.UnitAmount, err = strconv.ParseFloat(vCPUs, 64)
-				if err != nil {
-					logger.Error("failed to parse GCP total VCPUs", "error", err)
-				}
-			}
-			instance.Metrics.Upsert(m)
+			instance.Metrics.Upsert(cpuMetric(ctx, instance, instanceID, metric.Values[0]))
 		}
 	}
 	return nil
 }
 
+// cpuMetric returns the CPU metric of the instance with the average
+// utilization
+func cpuMetric(ctx context.Context, instance *v1.Instance, instanceID string, usage float64) *v1.Metric {
+	var err error
+
+	m := v1.NewMetric(v1.CPU.String())
+	m.Unit = v1.VCPU
+	m.Usage = usage
+	m.ResourceType = v1.CPU
+	m.Labels = v1.Labels{
+		"instanceID": instanceID,
+	}
+
+	// ParseFloat returns 0 on failure, since that's the default
+	// value of an unassigned int, store it regardless of the
+	// error. This value for vCPUs is a fallback to that provided
+	// by the dataset.
+	if vCPUs, exists := instance.Labels["VCPUCount"]; exists {
+		m.UnitAmount, err = strconv.ParseFloat(vCPUs, 64)
+		if err != nil {
+			log.FromContext(ctx).Error("failed to parse GCP total VCPUs", "error", err)
+		}
+	}
+	return m
+}
+
 // memoryMetrics queries cloudwatch for the memory utilization percentage over a
 // window of time and updates the EC2 instance with the memory metric.
 func (c *Client) memoryMetrics(ctx context.Context, region string, input *cloudwatch.GetMetricDataInput) error {
@@ -144,18 +152,121 @@ func (c *Client) memoryMetrics(ctx context.Context, region string, input *cloudw
 				continue
 			}
 
-			instance.Metrics.Upsert(&v1.Metric{
-				Name:         v1.Memory.String(),
-				Unit:         v1.GB,
-				Usage:        metric.Values[0],
-				ResourceType: v1.Memory,
-				UpdatedAt:    time.Now(),
-				Labels: v1.Labels{
-					"instanceID": instanceID,
-					"region":     region,
-					"name":       aws.ToString(metric.Label),
+			instance.Metrics.Upsert(memoryMetric(region, instanceID, metric.Values[0]))
+		}
+	}
+	return nil
+}
+
+// memoryMetric returns the memory metric of the instance with the
+// average utilization percentage
+func memoryMetric(region, instanceID string, usage float64) *v1.Metric {
+	return &v1.Metric{
+		Name:         v1.Memory.String(),
+		Unit:         v1.GB,
+		Usage:        usage,
+		ResourceType: v1.Memory,
+		UpdatedAt:    time.Now(),
+		Labels: v1.Labels{
+			"instanceID": instanceID,
+			"region":     region,
+			"name":       instanceID,
+		},
+	}
+}
+
+// GetEC2MetricsRange gets the resource consumptions of the EC2 instances
+// for every step between start and end. The instances are returned once
+// per step, instances that no longer exist are skipped.
+func (c *Client) GetEC2MetricsRange(ctx context.Context, region string, start, end time.Time, step time.Duration) ([]*v1.Instance, error) {
+	period := int32(step.Seconds())
+	// validate the casting from float64 to int32
+	if float64(period) != step.Seconds() {
+		return nil, fmt.Errorf("error casting %+v to int32", step.Seconds())
+	}
+
+	// the instances of every step, by their key and
+	// the end of the step
+	snapshots := make(map[string]*v1.Instance)
+	var instances []*v1.Instance
+
+	for _, expression := range []string{cpuExpression, memExpression} {
+		input := &cloudwatch.GetMetricDataInput{
+			StartTime: aws.Time(start.UTC()),
+			EndTime:   aws.Time(end.UTC()),
+			MetricDataQueries: []types.MetricDataQuery{
+				{
+					Id:         aws.String(v1.CPU.String()),
+					Expression: aws.String(expression),
+					Period:     aws.Int32(period),
 				},
-			})
+			},
+		}
+
+		err := c.metricsRange(ctx, region, input, func(instanceID string, at time.Time, usage float64) {
+			key := util.Key(region, ec2Service, instanceID)
+			instance, ok := c.instancesMap[key]
+			if !ok {
+				return
+			}
+
+			snapshot, ok := snapshots[key+at.String()]
+			if !ok {
+				snapshot = instance.Snapshot()
+				snapshots[key+at.String()] = snapshot
+				instances = append(instances, snapshot)
+			}
+
+			m := memoryMetric(region, instanceID, usage)
+			if expression == cpuExpression {
+				m = cpuMetric(ctx, instance, instanceID, usage)
+			}
+			m.UpdatedAt = at
+			snapshot.Metrics.Upsert(m)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return instances, nil
+}
+
+// metricsRange queries cloudwatch for the values of every period, and
+// passes them to the function with the end of their period
+func (c *Client) metricsRange(
+	ctx context.Context,
+	region string,
+	input *cloudwatch.GetMetricDataInput,
+	fn func(instanceID string, at time.Time, usage float64),
+) error {
+	// Override the region
+	withRegion := func(o *cloudwatch.Options) {
+		o.Region = region
+	}
+
+	period := time.Duration(aws.ToInt32(input.MetricDataQueries[0].Period)) * time.Second
+
+	// a range has more values than fit in a single response
+	pages := cloudwatch.NewGetMetricDataPaginator(c.cloudwatch, input)
+	for pages.HasMorePages() {
+		output, err := pages.NextPage(ctx, withRegion)
+		if err != nil {
+			return err
+		}
+
+		for _, metric := range output.MetricDataResults {
+			instanceID := aws.ToString(metric.Label)
+			if instanceID == "Other" {
+				continue
+			}
+
+			// the timestamps are the start of the periods
+			for i := range metric.Values {
+				if i < len(metric.Timestamps) {
+					fn(instanceID, metric.Timestamps[i].Add(period), metric.Values[i])
+				}
+			}
 		}
 	}
 	return nil
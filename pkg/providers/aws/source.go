@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/re-cinq/aether/pkg/config"
 	v1 "github.com/re-cinq/aether/pkg/types/v1"
@@ -18,6 +19,13 @@ type Source struct {
 	// AWS doesn't use project, but instead is
 	// separated by region
 	Region string
+
+	// the profile of the account, which tells the
+	// sources of the accounts apart
+	profile string
+
+	// How often the region is scraped
+	interval time.Duration
 }
 
 // Sources instantiates a slice of instances of the Amazon Sources configured
@@ -36,8 +44,10 @@ func Sources(ctx context.Context, cfg *config.Provider) []v1.Source {
 
 		for _, region := range account.Regions {
 			sources = append(sources, &Source{
-				Region: region,
-				Client: c,
+				Region:   region,
+				Client:   c,
+				profile:  account.Credentials.Profile,
+				interval: cfg.ScrapingInterval(&account),
 			})
 		}
 	}
@@ -57,9 +67,7 @@ func (s *Source) Fetch(ctx context.Context) ([]*v1.Instance, error) {
 		return nil, err
 	}
 
-	interval := config.AppConfig().Interval
-
-	err = s.Client.GetEC2Metrics(ctx, s.Region, interval)
+	err = s.Client.GetEC2Metrics(ctx, s.Region, s.interval)
 	if err != nil {
 		return nil, fmt.Errorf("failed getting instance metrics: %v", err)
 	}
@@ -72,6 +80,42 @@ func (s *Source) Fetch(ctx context.Context) ([]*v1.Instance, error) {
 	return instances, nil
 }
 
+// FetchRange returns the instances of the region with the metrics of every
+// step between start and end, CloudWatch keeps the metrics of a 5m step
+// for 63 days. Only the instances that still exist are returned.
+func (s *Source) FetchRange(ctx context.Context, start, end time.Time, step time.Duration) ([]*v1.Instance, error) {
+	if s.Region == "" {
+		return nil, errors.New("no region set")
+	}
+
+	err := s.Client.Refresh(ctx, s.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := s.Client.GetEC2MetricsRange(ctx, s.Region, start, end, step)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting instance metrics: %v", err)
+	}
+
+	return instances, nil
+}
+
+// Name returns the name of the source, which is the region, and the
+// profile of the account when it is set
+func (s *Source) Name() string {
+	if s.profile != "" {
+		return fmt.Sprintf("%s/%s/%s", v1.AWS, s.profile, s.Region)
+	}
+	return fmt.Sprintf("%s/%s", v1.AWS, s.Region)
+}
+
+// Interval returns how often the region is scraped, CloudWatch supports
+// intervals down to 1m
+func (s *Source) Interval() time.Duration {
+	return s.interval
+}
+
 // Stop is used to gracefully shutdown a source
 func (s *Source) Stop(ctx context.Context) error {
 	return nil
@@ -7,10 +7,12 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	compute "cloud.google.com/go/compute/apiv1"
 	"cloud.google.com/go/compute/apiv1/computepb"
 	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	monitoringpb "cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
 	"github.com/re-cinq/aether/pkg/config"
 	"github.com/re-cinq/aether/pkg/log"
 	"github.com/re-cinq/aether/pkg/providers/util"
@@ -96,13 +98,14 @@ func (c *Client) GetMetricsForInstances(
 	ctx context.Context,
 	project, window string,
 ) error {
-	// TODO these parameters can be cleaned up
-	err := c.cpuMetrics(ctx, project, fmt.Sprintf(CPUQuery, project, window, window))
+	clause := fmt.Sprintf(windowClause, window, window)
+
+	err := c.cpuMetrics(ctx, project, fmt.Sprintf(CPUQuery, project)+clause)
 	if err != nil {
 		return err
 	}
 
-	err = c.memoryMetrics(ctx, project, fmt.Sprintf(MEMQuery, project, window, window))
+	err = c.memoryMetrics(ctx, project, fmt.Sprintf(MEMQuery, project)+clause)
 	if err != nil {
 		return err
 	}
@@ -110,6 +113,42 @@ func (c *Client) GetMetricsForInstances(
 	return nil
 }
 
+// GetMetricsForRange retrieves the metrics of the instances for every step
+// between start and end. The instances are returned once per step, the
+// instances that no longer exist are skipped.
+func (c *Client) GetMetricsForRange(
+	ctx context.Context,
+	project string,
+	start, end time.Time,
+	step time.Duration,
+) ([]*v1.Instance, error) {
+	clause := fmt.Sprintf(rangeClause, step, step, start.UTC().Format(mqlDate), end.UTC().Format(mqlDate))
+
+	// the instances of every step, by their key and
+	// the end of the step
+	snapshots := make(map[string]*v1.Instance)
+
+	err := c.metricsRange(ctx, project, fmt.Sprintf(CPUQuery, project)+clause, snapshots,
+		func(resp *monitoringpb.TimeSeriesData, point *monitoringpb.TimeSeriesData_PointData) *v1.Metric {
+			return cpuMetric(ctx, resp, point)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.metricsRange(ctx, project, fmt.Sprintf(MEMQuery, project)+clause, snapshots, memoryMetric)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]*v1.Instance, 0, len(snapshots))
+	for _, instance := range snapshots {
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
 // Refresh fetches all the Instances
 // for a project and stores metadata in order to help with
 // metric collections
@@ -39,8 +39,6 @@ var (
 		metadata.system.machine_type,
     reserved_cores: format(t_1.value.reserved_cores, '%%f')
   ], [max(t_0.value.utilization)]
-  | window %s
-  | within %s
 	`
 	/*
 	* An MQL query that will return memory data from Google Cloud with the
@@ -64,11 +62,27 @@ var (
 		resource.zone,
 		metadata.system.machine_type,
 	], [max(value.ram_used)]
+	`
+
+	// windowClause averages the metrics of the queries over the window
+	// that ends now
+	windowClause = `
 	| window %s
 	| within %s
 	`
+
+	// rangeClause returns a point per step between the start and end
+	// of the range, which are UTC dates
+	rangeClause = `
+	| every %s
+	| window %s
+	| within d'%s', d'%s'
+	`
 )
 
+// mqlDate is the format of the dates in the MQL queries
+const mqlDate = "2006/01/02 15:04:05"
+
 // instanceMetrics runs a query on googe cloud monitoring using MQL
 // and responds with a list of metrics
 func (c *Client) memoryMetrics(ctx context.Context, project, query string) error {
@@ -88,41 +102,31 @@ func (c *Client) memoryMetrics(ctx context.Context, project, query string) error
 			return err
 		}
 
-		// This is dependant on the MQL query
-		// label ordering
-		instanceID := resp.GetLabelValues()[0].GetStringValue()
-		instanceName := resp.GetLabelValues()[1].GetStringValue()
-		region := resp.GetLabelValues()[2].GetStringValue()
-		zone := resp.GetLabelValues()[3].GetStringValue()
-		instanceType := resp.GetLabelValues()[4].GetStringValue()
-
-		m := v1.NewMetric(v1.Memory.String())
-		m.Unit = v1.GB
-		m.ResourceType = v1.Memory
-		// convert Bytes to GB
-		m.UnitAmount = float64(resp.GetPointData()[0].GetValues()[0].GetInt64Value()) / 1024 / 1024 / 1024
-		m.Labels = v1.Labels{
-			"id":           instanceID,
-			"name":         instanceName,
-			"region":       region,
-			"zone":         zone,
-			"machine_type": instanceType,
-		}
-
 		// Get the stored instance, update the metric and restore
 		// the instance in the cache
-		key := util.Key(zone, service, instanceName)
+		key := seriesKey(resp)
 		instance, ok := c.instancesMap[key]
 		if !ok {
 			logger.Warn("instance not found in cache", "error", err, "key", key)
 			continue
 		}
 
-		instance.Metrics.Upsert(m)
+		instance.Metrics.Upsert(memoryMetric(resp, resp.GetPointData()[0]))
 	}
 	return nil
 }
 
+// memoryMetric returns the memory metric of a point of the time series
+func memoryMetric(resp *monitoringpb.TimeSeriesData, point *monitoringpb.TimeSeriesData_PointData) *v1.Metric {
+	m := v1.NewMetric(v1.Memory.String())
+	m.Unit = v1.GB
+	m.ResourceType = v1.Memory
+	// convert Bytes to GB
+	m.UnitAmount = float64(point.GetValues()[0].GetInt64Value()) / 1024 / 1024 / 1024
+	m.Labels = seriesLabels(resp)
+	return m
+}
+
 // instanceCPUMetrics runs a query on googe cloud monitoring using MQL
 // and responds with a list of CPU metrics
 func (c *Client) cpuMetrics(ctx context.Context, project, query string) error {
@@ -142,42 +146,9 @@ func (c *Client) cpuMetrics(ctx context.Context, project, query string) error {
 			return err
 		}
 
-		// This is dependant on the MQL query
-		// label ordering
-		instanceID := resp.GetLabelValues()[0].GetStringValue()
-		instanceName := resp.GetLabelValues()[1].GetStringValue()
-		region := resp.GetLabelValues()[2].GetStringValue()
-		zone := resp.GetLabelValues()[3].GetStringValue()
-		instanceType := resp.GetLabelValues()[4].GetStringValue()
-		totalVCPUs := resp.GetLabelValues()[5].GetStringValue()
-
-		m := v1.NewMetric(v1.CPU.String())
-		m.Unit = v1.VCPU
-		m.ResourceType = v1.CPU
-
-		// translate fraction to a percentage
-		m.Usage = resp.GetPointData()[0].GetValues()[0].GetDoubleValue() * 100
-
-		// ParseFloat returns 0 on failure, since that's the default
-		// value of an unassigned int, store it regardless of the
-		// error. This value for vCPUs is a fallback to that provided
-		// by the dataset.
-		m.UnitAmount, err = strconv.ParseFloat(totalVCPUs, 64)
-		if err != nil {
-			logger.Error("failed to parse GCP total VCPUs", "error", err)
-		}
-
-		m.Labels = v1.Labels{
-			"id":           instanceID,
-			"name":         instanceName,
-			"region":       region,
-			"zone":         zone,
-			"machine_type": instanceType,
-		}
-
 		// Get the cached instance, update the metric and restore
 		// the instance in the cache
-		key := util.Key(zone, service, instanceName)
+		key := seriesKey(resp)
 		instance, ok := c.instancesMap[key]
 		if !ok {
 			logger.Warn("instance not found in cache", "error", err, "key", key)
@@ -187,7 +158,98 @@ func (c *Client) cpuMetrics(ctx context.Context, project, query string) error {
 		// We are updating the instance based on the stored pointer.
 		// Since it's by reference, we don't need to restore the
 		// object in the map
-		instance.Metrics.Upsert(m)
+		instance.Metrics.Upsert(cpuMetric(ctx, resp, resp.GetPointData()[0]))
+	}
+	return nil
+}
+
+// cpuMetric returns the CPU metric of a point of the time series
+func cpuMetric(ctx context.Context, resp *monitoringpb.TimeSeriesData, point *monitoringpb.TimeSeriesData_PointData) *v1.Metric {
+	var err error
+
+	m := v1.NewMetric(v1.CPU.String())
+	m.Unit = v1.VCPU
+	m.ResourceType = v1.CPU
+
+	// translate fraction to a percentage
+	m.Usage = point.GetValues()[0].GetDoubleValue() * 100
+
+	// ParseFloat returns 0 on failure, since that's the default
+	// value of an unassigned int, store it regardless of the
+	// error. This value for vCPUs is a fallback to that provided
+	// by the dataset.
+	totalVCPUs := resp.GetLabelValues()[5].GetStringValue()
+	m.UnitAmount, err = strconv.ParseFloat(totalVCPUs, 64)
+	if err != nil {
+		log.FromContext(ctx).Error("failed to parse GCP total VCPUs", "error", err)
+	}
+
+	m.Labels = seriesLabels(resp)
+	return m
+}
+
+// seriesLabels returns the labels of the instance of the time series,
+// this is dependant on the MQL query label ordering
+func seriesLabels(resp *monitoringpb.TimeSeriesData) v1.Labels {
+	return v1.Labels{
+		"id":           resp.GetLabelValues()[0].GetStringValue(),
+		"name":         resp.GetLabelValues()[1].GetStringValue(),
+		"region":       resp.GetLabelValues()[2].GetStringValue(),
+		"zone":         resp.GetLabelValues()[3].GetStringValue(),
+		"machine_type": resp.GetLabelValues()[4].GetStringValue(),
+	}
+}
+
+// seriesKey returns the key of the cached instance of the time series
+func seriesKey(resp *monitoringpb.TimeSeriesData) string {
+	zone := resp.GetLabelValues()[3].GetStringValue()
+	instanceName := resp.GetLabelValues()[1].GetStringValue()
+	return util.Key(zone, service, instanceName)
+}
+
+// metricsRange runs a query with a point per step on google cloud
+// monitoring, and adds the metric of every point to a snapshot of its
+// instance at the end of the point
+func (c *Client) metricsRange(
+	ctx context.Context,
+	project, query string,
+	snapshots map[string]*v1.Instance,
+	metric func(resp *monitoringpb.TimeSeriesData, point *monitoringpb.TimeSeriesData_PointData) *v1.Metric,
+) error {
+	it := c.monitoring.QueryTimeSeries(ctx, &monitoringpb.QueryTimeSeriesRequest{
+		Name:  fmt.Sprintf("projects/%s", project),
+		Query: query,
+	})
+
+	for {
+		resp, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// instances that no longer exist are skipped
+		key := seriesKey(resp)
+		instance, ok := c.instancesMap[key]
+		if !ok {
+			continue
+		}
+
+		for _, point := range resp.GetPointData() {
+			at := point.GetTimeInterval().GetEndTime().AsTime()
+
+			snapshot, ok := snapshots[key+at.String()]
+			if !ok {
+				snapshot = instance.Snapshot()
+				snapshots[key+at.String()] = snapshot
+			}
+
+			m := metric(resp, point)
+			m.UpdatedAt = at
+			snapshot.Metrics.Upsert(m)
+		}
 	}
 	return nil
 }
@@ -21,6 +21,9 @@ type Source struct {
 
 	// Teardown functionality
 	Shutdown func()
+
+	// How often the project is scraped
+	interval time.Duration
 }
 
 // Sources instantiates a slice of instances of the Google Sources configured
@@ -41,6 +44,7 @@ func Sources(ctx context.Context, cfg *config.Provider) []v1.Source {
 			Project:  &account.Project,
 			Client:   c,
 			Shutdown: shutdown,
+			interval: cfg.ScrapingInterval(&account),
 		})
 	}
 
@@ -56,12 +60,11 @@ func (s *Source) Fetch(ctx context.Context) ([]*v1.Instance, error) {
 
 	s.Client.Refresh(ctx, *s.Project)
 
-	interval := config.AppConfig().Interval
-	if interval < 5*time.Minute {
-		return nil, fmt.Errorf("error interval for GCP needs to be atleast 5m. It is: %+v", interval)
+	if s.interval < 5*time.Minute {
+		return nil, fmt.Errorf("error interval for GCP needs to be atleast 5m. It is: %+v", s.interval)
 	}
 
-	err := s.Client.GetMetricsForInstances(ctx, *s.Project, interval.String())
+	err := s.Client.GetMetricsForInstances(ctx, *s.Project, s.interval.String())
 	if err != nil {
 		return nil, fmt.Errorf("failed getting instance metrics: %v", err)
 	}
@@ -80,6 +83,41 @@ func (s *Source) Fetch(ctx context.Context) ([]*v1.Instance, error) {
 	return instances, nil
 }
 
+// FetchRange returns the instances of the project with the metrics of
+// every step between start and end, Cloud Monitoring keeps the metrics for
+// 6 weeks. Only the instances that still exist are returned.
+func (s *Source) FetchRange(ctx context.Context, start, end time.Time, step time.Duration) ([]*v1.Instance, error) {
+	if s.Project == nil {
+		return nil, errors.New("no project set")
+	}
+
+	if step < 5*time.Minute {
+		return nil, fmt.Errorf("error step for GCP needs to be atleast 5m. It is: %+v", step)
+	}
+
+	s.Client.Refresh(ctx, *s.Project)
+
+	instances, err := s.Client.GetMetricsForRange(ctx, *s.Project, start, end, step)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting instance metrics: %v", err)
+	}
+
+	return instances, nil
+}
+
+// Name returns the name of the source, which is the project
+func (s *Source) Name() string {
+	if s.Project == nil {
+		return string(v1.GCP)
+	}
+	return fmt.Sprintf("%s/%s", v1.GCP, *s.Project)
+}
+
+// Interval returns how often the project is scraped, which is at least 5m
+func (s *Source) Interval() time.Duration {
+	return s.interval
+}
+
 // Stop is used to gracefully shutdown a source
 func (s *Source) Stop(ctx context.Context) error {
 	s.Shutdown()
@@ -0,0 +1,113 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/log"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+)
+
+// backfillChunk is the range fetched at once, so a long backfill does not
+// hold the metrics of all steps in memory
+var backfillChunk = 24 * time.Hour
+
+// Backfill fetches the metrics of the range sources between from and to,
+// and publishes them on the bus a chunk at a time, oldest first. The
+// calculator and exporters handle them like the metrics scraped now, at the
+// time they were collected. The step defaults to the interval of the
+// source, and only the sources with the names are backfilled when given.
+// It returns the amount of instances published.
+func (m *Manager) Backfill(ctx context.Context, from, to time.Time, step time.Duration, names ...string) (int, error) {
+	logger := log.FromContext(ctx)
+
+	if !from.Before(to) {
+		return 0, fmt.Errorf("error backfill range is empty: %s - %s", from, to)
+	}
+
+	var count int
+	var errs []error
+	for i := range m.Sources {
+		if len(names) > 0 && !slices.Contains(names, m.names[i]) {
+			continue
+		}
+
+		source, ok := m.Sources[i].(v1.RangeSource)
+		if !ok {
+			logger.Warn("skipping source that does not support backfilling", "source", m.names[i])
+			continue
+		}
+
+		s := step
+		if s <= 0 {
+			s = m.schedules[i].interval
+		}
+
+		n, err := m.backfill(ctx, i, source, from, to, s)
+		count += n
+		if errors.Is(err, v1.ErrRangeNotSupported) {
+			logger.Warn("skipping source that does not support backfilling", "source", m.names[i])
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error backfilling %s: %w", m.names[i], err))
+		}
+	}
+
+	return count, errors.Join(errs...)
+}
+
+// backfill fetches and publishes the metrics of a source between from and
+// to, a chunk at a time
+func (m *Manager) backfill(ctx context.Context, i int, source v1.RangeSource, from, to time.Time, step time.Duration) (int, error) {
+	logger := log.FromContext(ctx).With("source", m.names[i])
+
+	// the chunks end on a step, so the
+	// steps are the same for every chunk
+	chunk := backfillChunk.Truncate(step)
+	if chunk < step {
+		chunk = step
+	}
+
+	var count int
+	for start := from; start.Before(to); start = start.Add(chunk) {
+		end := start.Add(chunk)
+		if end.After(to) {
+			end = to
+		}
+
+		instances, err := source.FetchRange(ctx, start, end, step)
+		if err != nil {
+			return count, err
+		}
+
+		// the windows are published in the order they were collected
+		sort.SliceStable(instances, func(a, b int) bool {
+			return collectedAt(instances[a]).Before(collectedAt(instances[b]))
+		})
+
+		if err := m.publishInstances(ctx, m.names[i], step, instances); err != nil {
+			return count, err
+		}
+		count += len(instances)
+
+		logger.Info("backfilled metrics", "start", start, "end", end, "instances", len(instances))
+	}
+
+	return count, nil
+}
+
+// collectedAt returns when the latest metric of the instance was collected
+func collectedAt(instance *v1.Instance) time.Time {
+	var t time.Time
+	for _, m := range instance.Metrics {
+		if m.UpdatedAt.After(t) {
+			t = m.UpdatedAt
+		}
+	}
+	return t
+}
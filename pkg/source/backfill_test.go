@@ -0,0 +1,78 @@
+package source
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/bus"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// rangeSource returns an instance per step of the ranges it is asked for
+type rangeSource struct {
+	failingSource
+	ranges [][2]time.Time
+}
+
+func (s *rangeSource) FetchRange(ctx context.Context, start, end time.Time, step time.Duration) ([]*v1.Instance, error) {
+	s.ranges = append(s.ranges, [2]time.Time{start, end})
+
+	var instances []*v1.Instance
+	// returned newest first, the backfill publishes them oldest first
+	for at := end; at.After(start); at = at.Add(-step) {
+		m := v1.NewMetric(v1.CPU.String())
+		m.UpdatedAt = at
+		instance := &v1.Instance{Name: "test", Metrics: v1.Metrics{}}
+		instance.Metrics.Upsert(m)
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+	defer func(chunk time.Duration) { backfillChunk = chunk }(backfillChunk)
+	backfillChunk = time.Hour
+
+	s := &rangeSource{}
+	m := testManager(s, 1)
+
+	var mu sync.Mutex
+	var collected []time.Time
+	var windows []time.Duration
+	v1.MetricsCollected(m.bus).Subscribe(func(ctx context.Context, i v1.Instance) {
+		mu.Lock()
+		defer mu.Unlock()
+		collected = append(collected, i.Metrics[v1.CPU.String()].UpdatedAt)
+		windows = append(windows, bus.MetadataFromContext(ctx).Window)
+	}, bus.WithConcurrency(1))
+	m.bus.Start(ctx)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	count, err := m.Backfill(ctx, from, from.Add(90*time.Minute), 30*time.Minute)
+	m.bus.Stop(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// the range is fetched a chunk at a time
+	assert.Equal(t, [][2]time.Time{
+		{from, from.Add(time.Hour)},
+		{from.Add(time.Hour), from.Add(90 * time.Minute)},
+	}, s.ranges)
+
+	assert.Equal(t, []time.Time{
+		from.Add(30 * time.Minute),
+		from.Add(time.Hour),
+		from.Add(90 * time.Minute),
+	}, collected)
+	assert.Equal(t, []time.Duration{30 * time.Minute, 30 * time.Minute, 30 * time.Minute}, windows)
+
+	// sources that cannot fetch ranges are skipped
+	count, err = testManager(&failingSource{}, 1).Backfill(ctx, from, from.Add(time.Hour), 0)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,163 @@
+package source
+
+import (
+	"sync"
+	"time"
+)
+
+// circuit is the state of the circuit breaker of a source
+type circuit int
+
+const (
+	// circuitClosed scrapes the source
+	circuitClosed circuit = iota
+
+	// circuitOpen pauses the scrapes until the cooldown passed
+	circuitOpen
+
+	// circuitHalfOpen tries a single scrape after the cooldown,
+	// which closes or opens the circuit again
+	circuitHalfOpen
+)
+
+var circuits = map[circuit]string{
+	circuitClosed:   "closed",
+	circuitOpen:     "open",
+	circuitHalfOpen: "half-open",
+}
+
+func (c circuit) String() string {
+	return circuits[c]
+}
+
+// Status is the health of a source
+type Status struct {
+	// The name of the source
+	Name string `json:"name"`
+
+	// How often the source is scraped
+	Interval string `json:"interval"`
+
+	// The state of the circuit breaker: closed, open or half-open
+	Circuit string `json:"circuit"`
+
+	// When the source was scraped successfully the last time
+	LastSuccess time.Time `json:"lastSuccess"`
+
+	// The error of the last failed scrape
+	LastError string `json:"lastError,omitempty"`
+
+	// When the last scrape failed
+	LastErrorAt time.Time `json:"lastErrorAt"`
+
+	// The failed scrapes since the last success
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+
+	// The scrapes of the source, including the failed ones
+	Scrapes uint64 `json:"scrapes"`
+
+	// The failed scrapes of the source
+	Failures uint64 `json:"failures"`
+}
+
+// Paused returns whether the scrapes of the source are paused by the
+// circuit breaker
+func (s Status) Paused() bool {
+	return s.Circuit == circuitOpen.String()
+}
+
+// health tracks the scrapes of a source, and opens the circuit when the
+// source failed too many times in a row
+type health struct {
+	// the consecutive failures that open the circuit
+	threshold int
+
+	// how long the circuit stays open
+	cooldown time.Duration
+
+	circuit   circuit
+	openUntil time.Time
+
+	lastSuccess time.Time
+	lastError   error
+	lastErrorAt time.Time
+	consecutive int
+	scrapes     uint64
+	failures    uint64
+
+	mu sync.Mutex
+}
+
+// allow returns whether the source is scraped, an open circuit becomes
+// half-open after the cooldown
+func (h *health) allow(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch h.circuit {
+	case circuitOpen:
+		if now.Before(h.openUntil) {
+			return false
+		}
+		h.circuit = circuitHalfOpen
+		return true
+	default:
+		return true
+	}
+}
+
+// success closes the circuit, and returns when the source was scraped
+// successfully before, which is zero for the first scrape
+func (h *health) success(now time.Time) time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev := h.lastSuccess
+	h.scrapes++
+	h.consecutive = 0
+	h.lastSuccess = now
+	h.circuit = circuitClosed
+	return prev
+}
+
+// failure counts the failed scrape, and returns whether it opened the
+// circuit. A failed scrape of a half-open circuit opens it again.
+func (h *health) failure(err error, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.scrapes++
+	h.failures++
+	h.consecutive++
+	h.lastError = err
+	h.lastErrorAt = now
+
+	if h.threshold <= 0 {
+		return false
+	}
+	if h.circuit == circuitHalfOpen || h.consecutive >= h.threshold {
+		h.circuit = circuitOpen
+		h.openUntil = now.Add(h.cooldown)
+		return true
+	}
+	return false
+}
+
+// status returns the health of the source
+func (h *health) status() Status {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := Status{
+		Circuit:             h.circuit.String(),
+		LastSuccess:         h.lastSuccess,
+		LastErrorAt:         h.lastErrorAt,
+		ConsecutiveFailures: h.consecutive,
+		Scrapes:             h.scrapes,
+		Failures:            h.failures,
+	}
+	if h.lastError != nil {
+		s.LastError = h.lastError.Error()
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/bus"
+	"github.com/re-cinq/aether/pkg/config"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingSource fails the first fetches
+type failingSource struct {
+	failures int
+	fetches  int
+}
+
+func (s *failingSource) Fetch(ctx context.Context) ([]*v1.Instance, error) {
+	s.fetches++
+	if s.fetches <= s.failures {
+		return nil, errors.New("error fetching")
+	}
+	return []*v1.Instance{{Name: "test"}}, nil
+}
+
+func (s *failingSource) Stop(ctx context.Context) error {
+	return nil
+}
+
+// testManager returns a manager of the source that retries and opens the
+// circuit after two failed scrapes
+func testManager(s v1.Source, attempts int) *Manager {
+	return &Manager{
+		bus:       bus.New(),
+		Sources:   []v1.Source{s},
+		names:     []string{"test"},
+		schedules: []schedule{{interval: time.Minute}},
+		health:    []*health{{threshold: 2, cooldown: time.Hour}},
+		retry:     config.RetryConfig{Attempts: attempts, Backoff: time.Millisecond},
+		done:      make(chan struct{}),
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	h := &health{threshold: 2, cooldown: time.Minute}
+
+	assert.True(t, h.allow(now))
+	assert.False(t, h.failure(errors.New("error"), now))
+	assert.True(t, h.failure(errors.New("error"), now))
+
+	// the scrapes are paused until the cooldown passed
+	assert.True(t, h.status().Paused())
+	assert.False(t, h.allow(now.Add(time.Second)))
+
+	// a failed scrape after the cooldown opens the circuit again
+	assert.True(t, h.allow(now.Add(time.Minute)))
+	assert.Equal(t, "half-open", h.status().Circuit)
+	assert.True(t, h.failure(errors.New("error"), now.Add(time.Minute)))
+	assert.False(t, h.allow(now.Add(time.Minute+time.Second)))
+
+	// a successful one closes it
+	assert.True(t, h.allow(now.Add(2*time.Minute)))
+	h.success(now.Add(2 * time.Minute))
+
+	status := h.status()
+	assert.Equal(t, "closed", status.Circuit)
+	assert.Zero(t, status.ConsecutiveFailures)
+	assert.Equal(t, uint64(4), status.Scrapes)
+	assert.Equal(t, uint64(3), status.Failures)
+	assert.Equal(t, "error", status.LastError)
+}
+
+func TestFetchRetry(t *testing.T) {
+	ctx := context.Background()
+
+	// the failed attempts are retried within the scrape
+	s := &failingSource{failures: 2}
+	m := testManager(s, 3)
+	m.fetch(ctx, 0)
+
+	assert.Equal(t, 3, s.fetches)
+	status := m.Status()[0]
+	assert.Equal(t, "test", status.Name)
+	assert.Equal(t, uint64(1), status.Scrapes)
+	assert.Zero(t, status.Failures)
+	assert.False(t, status.LastSuccess.IsZero())
+}
+
+func TestFetchPaused(t *testing.T) {
+	ctx := context.Background()
+
+	s := &failingSource{failures: 10}
+	m := testManager(s, 1)
+	m.fetch(ctx, 0)
+	m.fetch(ctx, 0)
+
+	// the source is not scraped while the circuit is open
+	m.fetch(ctx, 0)
+	assert.Equal(t, 2, s.fetches)
+
+	status := m.Status()[0]
+	assert.True(t, status.Paused())
+	assert.Equal(t, 2, status.ConsecutiveFailures)
+	assert.Equal(t, "error fetching", status.LastError)
+}
@@ -2,6 +2,7 @@ package source
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -13,13 +14,30 @@ import (
 )
 
 type Manager struct {
-	ticker *time.Ticker
-
 	bus *bus.Bus
 
 	Sources []v1.Source
 
+	// the names of the sources, by their index in Sources
+	names []string
+
+	// when the sources are scraped, by their index in Sources
+	schedules []schedule
+
+	// the health of the sources, by their index in Sources
+	health []*health
+
+	// how long a scrape may take, and how it is retried
+	timeout time.Duration
+	retry   config.RetryConfig
+
 	plugin *plugin.SourcePluginSystem
+
+	// stops the scrapes, which are waited for on Stop, and
+	// cancels the fetches in flight
+	done   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 type option func(m *Manager)
@@ -38,7 +56,7 @@ func WithBus(b *bus.Bus) option {
 
 func New(ctx context.Context, opts ...option) *Manager {
 	m := &Manager{
-		ticker: time.NewTicker(config.AppConfig().ProvidersConfig.Interval),
+		done: make(chan struct{}),
 	}
 
 	for _, o := range opts {
@@ -48,70 +66,205 @@ func New(ctx context.Context, opts ...option) *Manager {
 	// load buil in sources
 	m.Sources = BuiltInSources(ctx)
 
+	// built in sources are named by themselves
+	for i, source := range m.Sources {
+		name := fmt.Sprintf("source-%d", i)
+		if named, ok := source.(v1.NamedSource); ok {
+			name = named.Name()
+		}
+		m.names = append(m.names, name)
+	}
+
 	// load plugin sources
 	for _, p := range m.plugin.Plugins {
+		m.names = append(m.names, p.Name)
 		m.Sources = append(m.Sources, p.Source)
 	}
 
+	// the names identify the sources in the metrics, the
+	// health and the backfill, so they must be unique
+	m.names = uniqueNames(ctx, m.names)
+
+	// sources are scraped at their own interval, or
+	// at the interval of all providers
+	cfg := config.AppConfig().ProvidersConfig
+	m.timeout = cfg.Timeout
+	m.retry = cfg.Retry
+	for _, source := range m.Sources {
+		s := schedule{
+			interval: cfg.Interval,
+			jitter:   cfg.Jitter,
+			align:    cfg.Align,
+		}
+		if scheduled, ok := source.(v1.ScheduledSource); ok && scheduled.Interval() > 0 {
+			s.interval = scheduled.Interval()
+		}
+		m.schedules = append(m.schedules, s)
+		m.health = append(m.health, &health{
+			threshold: cfg.CircuitBreaker.Failures,
+			cooldown:  cfg.CircuitBreaker.Cooldown,
+		})
+	}
+
 	return m
 }
 
-// Start is used to start the processing of the manager
+// Start is used to start the processing of the manager, every source is
+// scraped once and then on its own schedule
 func (m *Manager) Start(ctx context.Context) {
-	go func() {
-		for {
-			select {
-			// when context is canceled we will
-			// stop processing
-			case <-ctx.Done():
-				return
-			case <-m.ticker.C:
-				m.Fetch(ctx)
-			}
-		}
-	}()
+	ctx, m.cancel = context.WithCancel(ctx)
+
+	for i := range m.Sources {
+		m.wg.Add(1)
+		go m.run(ctx, i)
+	}
+}
+
+// run scrapes the source on its schedule until the manager is stopped
+func (m *Manager) run(ctx context.Context, i int) {
+	defer m.wg.Done()
 
 	// run fetch once the first time
-	m.Fetch(ctx)
+	m.fetch(ctx, i)
+
+	s := m.schedules[i]
+	prev := time.Now()
+
+	for {
+		prev = s.next(prev, time.Now())
+		timer := time.NewTimer(time.Until(prev) + s.delay())
+
+		select {
+		// when context is canceled we will
+		// stop processing
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-m.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+			m.fetch(ctx, i)
+		}
+	}
 }
 
 // Fetch goes to all sources and fetchs the instance list from them and then
 // publishes all those instances on the bus
 func (m *Manager) Fetch(ctx context.Context) {
-	logger := log.FromContext(ctx)
-
 	var wg sync.WaitGroup
 	for i := range m.Sources {
 		wg.Add(1)
 
-		go func(source v1.Source) {
-			instances, err := source.Fetch(ctx)
-			if err != nil {
-				logger.Error("failed fetching instances", "error", err)
-				wg.Done()
-				return
-			}
-			logger.Debug("publishing instances", "instance count", len(instances))
-			err = m.publishInstances(instances)
-			if err != nil {
-				logger.Error("failed publishing instances", "error", err)
-			}
+		go func(i int) {
+			m.fetch(ctx, i)
 			wg.Done()
-		}(m.Sources[i])
+		}(i)
 	}
 
 	wg.Wait()
 }
 
+// fetch fetches the instances of a source and publishes them on the bus,
+// a source with an open circuit is skipped until the cooldown passed
+func (m *Manager) fetch(ctx context.Context, i int) {
+	logger := log.FromContext(ctx).With("source", m.names[i])
+	h := m.health[i]
+
+	if !h.allow(time.Now()) {
+		logger.Debug("skipping scrape of paused source")
+		return
+	}
+
+	instances, err := m.fetchWithRetry(ctx, i)
+	if err != nil {
+		opened := h.failure(err, time.Now())
+		logger.Error("failed fetching instances", "error", err, "failures", h.status().ConsecutiveFailures)
+		if opened {
+			logger.Warn("pausing scrapes of failing source", "cooldown", h.cooldown)
+		}
+		return
+	}
+	// the metrics are collected over the time since the previous scrape,
+	// which differs from the interval when scrapes failed or were delayed
+	now := time.Now()
+	window := m.schedules[i].interval
+	if prev := h.success(now); !prev.IsZero() {
+		window = now.Sub(prev)
+	}
+
+	logger.Debug("publishing instances", "instance count", len(instances))
+	err = m.publishInstances(ctx, m.names[i], window, instances)
+	if err != nil {
+		logger.Error("failed publishing instances", "error", err)
+	}
+}
+
+// fetchWithRetry fetches the instances of a source, a failed attempt is
+// retried with a backoff as long as it ends before the next scrape
+func (m *Manager) fetchWithRetry(ctx context.Context, i int) ([]*v1.Instance, error) {
+	logger := log.FromContext(ctx).With("source", m.names[i])
+
+	interval := m.schedules[i].interval
+	timeout := m.timeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+
+	deadline := time.Now().Add(interval)
+	backoff := m.retry.Backoff
+
+	for attempt := 1; ; attempt++ {
+		fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+		instances, err := m.Sources[i].Fetch(fetchCtx)
+		cancel()
+		if err == nil {
+			return instances, nil
+		}
+
+		if attempt >= m.retry.Attempts || time.Now().Add(backoff).After(deadline) {
+			return nil, err
+		}
+		logger.Warn("failed fetching instances, retrying", "error", err, "attempt", attempt, "backoff", backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, err
+		case <-m.done:
+			return nil, err
+		}
+
+		backoff *= 2
+		if m.retry.MaxBackoff > 0 && backoff > m.retry.MaxBackoff {
+			backoff = m.retry.MaxBackoff
+		}
+	}
+}
+
+// Status returns the health of the sources
+func (m *Manager) Status() []Status {
+	var statuses []Status
+	for i := range m.Sources {
+		s := m.health[i].status()
+		s.Name = m.names[i]
+		s.Interval = m.schedules[i].interval.String()
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
 // publishInstances is a helper that publishes each instance in a slice on the
-// bus under the MetricsCollectedEvent
-func (m *Manager) publishInstances(instances []*v1.Instance) error {
+// bus under the MetricsCollectedEvent. All instances share the same scrape ID,
+// and are named after the source. The window is the time the metrics were
+// collected over.
+func (m *Manager) publishInstances(ctx context.Context, name string, window time.Duration, instances []*v1.Instance) error {
+	topic := v1.MetricsCollected(m.bus)
+	scrape := bus.NewID()
+	ctx = bus.WithMetadata(ctx, bus.Metadata{Source: name, ScrapeID: scrape, Window: window})
+
 	for i := range instances {
-		err := m.bus.Publish(&bus.Event{
-			Type: v1.MetricsCollectedEvent,
-			Data: *instances[i],
-		})
-		if err != nil {
+		if err := topic.Publish(ctx, *instances[i]); err != nil {
 			return err
 		}
 	}
@@ -119,16 +272,55 @@ func (m *Manager) publishInstances(instances []*v1.Instance) error {
 }
 
 // Stop is used to graceful shut down the manager and by extension all the
-// sources
+// sources. The fetches in flight are cancelled, and the scrapes are waited
+// for until the context is done.
 func (m *Manager) Stop(ctx context.Context) {
 	logger := log.FromContext(ctx)
 
-	m.ticker.Stop()
+	close(m.done)
+	if m.cancel != nil {
+		m.cancel()
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logger.Warn("stopped waiting for the scrapes", "error", ctx.Err())
+	}
 
 	for i := range m.Sources {
 		err := m.Sources[i].Stop(ctx)
 		if err != nil {
-			logger.Error("failed stopping source", "error", err)
+			logger.Error("failed stopping source", "source", m.names[i], "error", err)
 		}
 	}
 }
+
+// uniqueNames suffixes the names that are used by more than one source with
+// a count, in the order of the config, so the first source keeps its name
+// and the others are named like aws/eu-west-1#2
+func uniqueNames(ctx context.Context, names []string) []string {
+	unique := make([]string, 0, len(names))
+	used := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		n := name
+		for count := 2; used[n]; count++ {
+			n = fmt.Sprintf("%s#%d", name, count)
+		}
+
+		if n != name {
+			log.FromContext(ctx).Warn("renamed source with a duplicate name", "source", name, "name", n)
+		}
+		used[n] = true
+		unique = append(unique, n)
+	}
+
+	return unique
+}
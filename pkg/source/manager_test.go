@@ -0,0 +1,88 @@
+package source
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/bus"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingSource blocks the fetches until they are cancelled
+type blockingSource struct {
+	fetching chan struct{}
+}
+
+func (s *blockingSource) Fetch(ctx context.Context) ([]*v1.Instance, error) {
+	s.fetching <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *blockingSource) Stop(ctx context.Context) error {
+	return nil
+}
+
+func TestUniqueNames(t *testing.T) {
+	names := uniqueNames(context.Background(), []string{
+		"aws/eu-west-1", "aws/eu-west-1", "gcp/project", "aws/eu-west-1", "aws/eu-west-1#2",
+	})
+
+	assert.Equal(t, []string{
+		"aws/eu-west-1", "aws/eu-west-1#2", "gcp/project", "aws/eu-west-1#3", "aws/eu-west-1#2#2",
+	}, names)
+}
+
+func TestStopCancelsFetch(t *testing.T) {
+	ctx := context.Background()
+
+	s := &blockingSource{fetching: make(chan struct{}, 1)}
+	m := testManager(s, 1)
+	m.timeout = time.Hour
+	m.Start(ctx)
+	<-s.fetching
+
+	// the fetch in flight is cancelled instead of waiting for its timeout
+	stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	m.Stop(stopCtx)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, "context canceled", m.Status()[0].LastError)
+}
+
+func TestScrapeWindow(t *testing.T) {
+	ctx := context.Background()
+
+	m := testManager(&failingSource{failures: 1}, 1)
+
+	var mu sync.Mutex
+	var windows []time.Duration
+	v1.MetricsCollected(m.bus).Subscribe(func(ctx context.Context, i v1.Instance) {
+		mu.Lock()
+		defer mu.Unlock()
+		windows = append(windows, bus.MetadataFromContext(ctx).Window)
+	})
+	m.bus.Start(ctx)
+
+	// the failed scrape publishes nothing, the first scrape is
+	// collected over the interval
+	m.fetch(ctx, 0)
+	start := time.Now()
+	m.fetch(ctx, 0)
+
+	// the next one over the time since the previous scrape
+	time.Sleep(20 * time.Millisecond)
+	m.fetch(ctx, 0)
+	elapsed := time.Since(start)
+	m.bus.Stop(ctx)
+
+	assert.Len(t, windows, 2)
+	assert.Equal(t, time.Minute, windows[0])
+	assert.GreaterOrEqual(t, windows[1], 20*time.Millisecond)
+	assert.LessOrEqual(t, windows[1], elapsed)
+}
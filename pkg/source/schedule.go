@@ -0,0 +1,56 @@
+package source
+
+import (
+	"math/rand"
+	"time"
+)
+
+// day is the period aligned scrapes start again at
+const day = 24 * time.Hour
+
+// schedule decides when a source is scraped next
+type schedule struct {
+	// how often the source is scraped
+	interval time.Duration
+
+	// the maximum random delay added to each scrape
+	jitter time.Duration
+
+	// whether the scrapes are aligned to the wall clock
+	align bool
+}
+
+// next returns the time of the scrape after the previous one, without
+// the jitter. Aligned scrapes are at the multiples of the interval since
+// the start of the day in UTC, a 5m interval scrapes at :00, :05 and so on.
+// The scrapes start again at midnight, so an interval that does not divide
+// the day has a shorter last interval. The interval must be positive, and
+// at most a day when aligned.
+func (s schedule) next(prev, now time.Time) time.Time {
+	if s.align {
+		start := now.UTC().Truncate(day)
+		end := start.Add(day)
+
+		next := start.Add(now.Sub(start).Truncate(s.interval) + s.interval)
+		if next.After(end) {
+			return end
+		}
+		return next
+	}
+
+	// a late scrape does not shift the ones after it, unless
+	// it took longer than the interval
+	next := prev.Add(s.interval)
+	if next.Before(now) {
+		return now
+	}
+	return next
+}
+
+// delay returns the random delay added to a scrape
+func (s schedule) delay() time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(s.jitter)))
+}
@@ -0,0 +1,49 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleNext(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 2, 30, 0, time.UTC)
+
+	s := schedule{interval: 5 * time.Minute}
+	assert.Equal(t, start.Add(5*time.Minute), s.next(start, start))
+
+	// a late scrape does not shift the next one
+	assert.Equal(t, start.Add(5*time.Minute), s.next(start, start.Add(time.Minute)))
+
+	// unless it took longer than the interval
+	late := start.Add(6 * time.Minute)
+	assert.Equal(t, late, s.next(start, late))
+
+	// aligned scrapes are on the boundaries of the interval
+	s.align = true
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC), s.next(start, start))
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 10, 0, 0, time.UTC), s.next(start, start.Add(3*time.Minute)))
+
+	// since the start of the day in UTC, in any time zone
+	berlin := time.FixedZone("CET", 3600)
+	s.interval = 7 * time.Minute
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 9, 0, 0, time.UTC), s.next(start, start).UTC())
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 9, 0, 0, time.UTC), s.next(start, start.In(berlin)).UTC())
+
+	// an interval that does not divide the day starts again at midnight
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), s.next(start, time.Date(2024, 1, 1, 23, 56, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 7, 0, 0, time.UTC), s.next(start, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestScheduleDelay(t *testing.T) {
+	s := schedule{interval: time.Minute}
+	assert.Zero(t, s.delay())
+
+	s.jitter = 10 * time.Second
+	for i := 0; i < 100; i++ {
+		d := s.delay()
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.Less(t, d, s.jitter)
+	}
+}
@@ -0,0 +1,80 @@
+// Package telemetry sets up the OpenTelemetry providers of aether
+package telemetry
+
+import (
+	"context"
+	"errors"
+
+	"github.com/re-cinq/aether/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// serviceName is the name aether reports its spans with
+const serviceName = "aether"
+
+// Setup installs the global meter provider, which exposes the metrics on
+// the prometheus endpoint of the API, and the tracer provider, which exports
+// the spans over OTLP when tracing is enabled. It must be called once, and
+// the returned function flushes and shuts down the providers.
+func Setup(ctx context.Context, cfg *config.TracingConfig) (func(context.Context) error, error) {
+	exporter, err := prometheus.New()
+	if err != nil {
+		return nil, err
+	}
+
+	mp := metric.NewMeterProvider(metric.WithReader(exporter))
+	otel.SetMeterProvider(mp)
+
+	if cfg == nil || !cfg.Enabled {
+		return mp.Shutdown, nil
+	}
+
+	tp, err := tracerProvider(ctx, cfg)
+	if err != nil {
+		return nil, errors.Join(err, mp.Shutdown(ctx))
+	}
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		return errors.Join(tp.Shutdown(ctx), mp.Shutdown(ctx))
+	}, nil
+}
+
+// tracerProvider returns a tracer provider that exports a share of the
+// spans to the OTLP endpoint
+func tracerProvider(ctx context.Context, cfg *config.TracingConfig) (*trace.TracerProvider, error) {
+	// the endpoint defaults to OTEL_EXPORTER_OTLP_ENDPOINT
+	var opts []otlptracegrpc.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return trace.NewTracerProvider(
+		trace.WithBatcher(exporter),
+		trace.WithResource(res),
+		trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(cfg.SampleRatio))),
+	), nil
+}
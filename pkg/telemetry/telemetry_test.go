@@ -0,0 +1,36 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/re-cinq/aether/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	// the tracer provider is only installed when tracing is enabled
+	shutdown, err := Setup(ctx, &config.TracingConfig{})
+	assert.NoError(t, err)
+	assert.IsType(t, &sdkmetric.MeterProvider{}, otel.GetMeterProvider())
+	_, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	assert.False(t, ok)
+	assert.NoError(t, shutdown(ctx))
+
+	shutdown, err = Setup(ctx, &config.TracingConfig{
+		Enabled:     true,
+		Endpoint:    "localhost:4317",
+		Insecure:    true,
+		SampleRatio: 0.5,
+	})
+	assert.NoError(t, err)
+	assert.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+	assert.NoError(t, shutdown(ctx))
+}
@@ -0,0 +1,312 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/bus"
+	"github.com/re-cinq/aether/pkg/log"
+	"github.com/re-cinq/aether/pkg/transport/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var (
+	// the default time to wait for the acknowledgement of an event
+	defaultTimeout = 10 * time.Second
+
+	// the default time an event is retried before it is left
+	// unacknowledged in the log of the bus
+	defaultRetryTimeout = time.Minute
+
+	// the wait between the first retries, which doubles up to a maximum
+	defaultBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+)
+
+var (
+	// ErrStopped is returned when forwarding an event after Stop
+	ErrStopped = errors.New("forwarder has stopped")
+
+	// errTimeout is returned when an event is not acknowledged in time
+	errTimeout = errors.New("event not acknowledged in time")
+)
+
+// rejectedError is returned when the other node could not publish the
+// event, which is not retried
+type rejectedError struct {
+	msg string
+}
+
+func (e *rejectedError) Error() string {
+	return "event rejected: " + e.msg
+}
+
+// Forwarder is an EventHandler that sends the events it handles to another
+// node, over a single gRPC stream. An event is handled once the other node
+// acknowledged it, failures are retried with a backoff. An event that is not
+// forwarded is not acknowledged in the log of the bus, so it is delivered
+// again after a restart.
+type Forwarder struct {
+	client proto.TransportClient
+
+	// the connection is closed on Stop when it was dialed
+	conn *grpc.ClientConn
+
+	timeout      time.Duration
+	retryTimeout time.Duration
+
+	// the open stream, and the acknowledgements it waits for by sequence
+	stream   proto.Transport_PublishClient
+	cancel   context.CancelFunc
+	sequence uint64
+	pending  map[uint64]chan error
+
+	done     chan struct{}
+	stopOnce sync.Once
+	mu       sync.Mutex
+}
+
+type option func(*Forwarder)
+
+// WithTimeout sets how long to wait for the acknowledgement of an event
+func WithTimeout(d time.Duration) option {
+	return func(f *Forwarder) {
+		f.timeout = d
+	}
+}
+
+// WithRetryTimeout sets how long an event is retried before it is
+// left unacknowledged in the log of the bus
+func WithRetryTimeout(d time.Duration) option {
+	return func(f *Forwarder) {
+		f.retryTimeout = d
+	}
+}
+
+// NewForwarder returns a forwarder sending the events over the connection
+func NewForwarder(conn grpc.ClientConnInterface, opts ...option) *Forwarder {
+	f := &Forwarder{
+		client:       proto.NewTransportClient(conn),
+		timeout:      defaultTimeout,
+		retryTimeout: defaultRetryTimeout,
+		pending:      make(map[uint64]chan error),
+		done:         make(chan struct{}),
+	}
+
+	for _, o := range opts {
+		o(f)
+	}
+
+	return f
+}
+
+// Dial returns a forwarder sending the events to the node at the target,
+// which is a gRPC target like dns:///aether-calculator:8081, over a
+// connection secured with the credentials (see ClientCredentials). The
+// connection is established when the first event is sent.
+func Dial(target string, creds credentials.TransportCredentials, opts ...option) (*Forwarder, error) {
+	conn, err := grpc.Dial(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %w", target, err)
+	}
+
+	f := NewForwarder(conn, opts...)
+	f.conn = conn
+	return f, nil
+}
+
+// Handle sends the event to the other node and waits for the
+// acknowledgement, retrying until the retry timeout
+func (f *Forwarder) Handle(ctx context.Context, e *bus.Event) {
+	logger := log.FromContext(ctx)
+
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		logger.Error("error encoding event", "id", e.Metadata.ID, "error", err)
+		return
+	}
+
+	event := &proto.Event{
+		Type:      int32(e.Type),
+		Id:        e.Metadata.ID,
+		Timestamp: e.Metadata.Timestamp.UnixNano(),
+		Source:    e.Metadata.Source,
+		ScrapeId:  e.Metadata.ScrapeID,
+		Replayed:  e.Metadata.Replayed,
+		Window:    int64(e.Metadata.Window),
+		Data:      data,
+	}
+
+	deadline := time.Now().Add(f.retryTimeout)
+	backoff := defaultBackoff
+
+	for {
+		err := f.send(ctx, event)
+		if err == nil {
+			return
+		}
+
+		// the event stays in the log of the bus, so
+		// it is forwarded again after a restart
+		var rejected *rejectedError
+		if errors.As(err, &rejected) || errors.Is(err, ErrStopped) || time.Now().After(deadline) {
+			logger.Error("error forwarding event", "id", e.Metadata.ID, "error", err)
+			bus.Fail(ctx)
+			return
+		}
+		logger.Warn("error forwarding event, retrying", "id", e.Metadata.ID, "error", err, "backoff", backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			bus.Fail(ctx)
+			return
+		case <-f.done:
+			bus.Fail(ctx)
+			return
+		}
+
+		backoff *= 2
+		if backoff > defaultMaxBackoff {
+			backoff = defaultMaxBackoff
+		}
+	}
+}
+
+// send sends the event on the stream and waits for its acknowledgement
+func (f *Forwarder) send(ctx context.Context, e *proto.Event) error {
+	f.mu.Lock()
+	stream, err := f.openStream()
+	if err != nil {
+		f.mu.Unlock()
+		return err
+	}
+
+	f.sequence++
+	e.Sequence = f.sequence
+	ack := make(chan error, 1)
+	f.pending[e.Sequence] = ack
+
+	err = stream.Send(e)
+	f.mu.Unlock()
+
+	if err != nil {
+		f.reset(stream, err)
+		return err
+	}
+
+	timer := time.NewTimer(f.timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-ack:
+		return err
+	case <-timer.C:
+		// the stream is broken when the other node does
+		// not answer, so the next event opens a new one
+		f.reset(stream, errTimeout)
+		return errTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-f.done:
+		return ErrStopped
+	}
+}
+
+// openStream opens a new stream when there is none, the lock must be held
+func (f *Forwarder) openStream() (proto.Transport_PublishClient, error) {
+	select {
+	case <-f.done:
+		return nil, ErrStopped
+	default:
+	}
+
+	if f.stream != nil {
+		return f.stream, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := f.client.Publish(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	f.stream = stream
+	f.cancel = cancel
+	go f.receive(stream)
+
+	return stream, nil
+}
+
+// receive passes the acknowledgements of the stream to the senders
+func (f *Forwarder) receive(stream proto.Transport_PublishClient) {
+	for {
+		ack, err := stream.Recv()
+		if err != nil {
+			f.reset(stream, err)
+			return
+		}
+
+		f.mu.Lock()
+		ch, ok := f.pending[ack.Sequence]
+		delete(f.pending, ack.Sequence)
+		f.mu.Unlock()
+
+		if !ok {
+			continue
+		}
+		if ack.Error != "" {
+			ch <- &rejectedError{msg: ack.Error}
+			continue
+		}
+		ch <- nil
+	}
+}
+
+// reset closes the stream after an error, and fails the events waiting
+// for an acknowledgement on it
+func (f *Forwarder) reset(stream proto.Transport_PublishClient, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stream != stream {
+		return
+	}
+
+	f.cancel()
+	f.stream = nil
+	f.cancel = nil
+
+	for seq, ch := range f.pending {
+		ch <- err
+		delete(f.pending, seq)
+	}
+}
+
+// Stop closes the stream and the connection, the events that are
+// retried are left unacknowledged in the log of the bus
+func (f *Forwarder) Stop(ctx context.Context) {
+	f.stopOnce.Do(func() {
+		close(f.done)
+
+		f.mu.Lock()
+		if f.stream != nil {
+			_ = f.stream.CloseSend()
+			f.cancel()
+			f.stream = nil
+		}
+		f.mu.Unlock()
+
+		if f.conn != nil {
+			if err := f.conn.Close(); err != nil {
+				log.FromContext(ctx).Error("error closing transport connection", "error", err)
+			}
+		}
+	})
+}
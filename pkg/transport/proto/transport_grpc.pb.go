@@ -0,0 +1,139 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: proto/transport.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Transport_Publish_FullMethodName = "/proto.Transport/Publish"
+)
+
+// TransportClient is the client API for Transport service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TransportClient interface {
+	Publish(ctx context.Context, opts ...grpc.CallOption) (Transport_PublishClient, error)
+}
+
+type transportClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTransportClient(cc grpc.ClientConnInterface) TransportClient {
+	return &transportClient{cc}
+}
+
+func (c *transportClient) Publish(ctx context.Context, opts ...grpc.CallOption) (Transport_PublishClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Transport_ServiceDesc.Streams[0], Transport_Publish_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &transportPublishClient{stream}
+	return x, nil
+}
+
+type Transport_PublishClient interface {
+	Send(*Event) error
+	Recv() (*Ack, error)
+	grpc.ClientStream
+}
+
+type transportPublishClient struct {
+	grpc.ClientStream
+}
+
+func (x *transportPublishClient) Send(m *Event) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *transportPublishClient) Recv() (*Ack, error) {
+	m := new(Ack)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TransportServer is the server API for Transport service.
+// All implementations should embed UnimplementedTransportServer
+// for forward compatibility
+type TransportServer interface {
+	Publish(Transport_PublishServer) error
+}
+
+// UnimplementedTransportServer should be embedded to have forward compatible implementations.
+type UnimplementedTransportServer struct {
+}
+
+func (UnimplementedTransportServer) Publish(Transport_PublishServer) error {
+	return status.Errorf(codes.Unimplemented, "method Publish not implemented")
+}
+
+// UnsafeTransportServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TransportServer will
+// result in compilation errors.
+type UnsafeTransportServer interface {
+	mustEmbedUnimplementedTransportServer()
+}
+
+func RegisterTransportServer(s grpc.ServiceRegistrar, srv TransportServer) {
+	s.RegisterService(&Transport_ServiceDesc, srv)
+}
+
+func _Transport_Publish_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TransportServer).Publish(&transportPublishServer{stream})
+}
+
+type Transport_PublishServer interface {
+	Send(*Ack) error
+	Recv() (*Event, error)
+	grpc.ServerStream
+}
+
+type transportPublishServer struct {
+	grpc.ServerStream
+}
+
+func (x *transportPublishServer) Send(m *Ack) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *transportPublishServer) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Transport_ServiceDesc is the grpc.ServiceDesc for Transport service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Transport_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.Transport",
+	HandlerType: (*TransportServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Publish",
+			Handler:       _Transport_Publish_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "proto/transport.proto",
+}
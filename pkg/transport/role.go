@@ -0,0 +1,51 @@
+package transport
+
+import "fmt"
+
+// Role is what a node of a distributed deployment runs, every node runs
+// the same binary
+type Role int
+
+const (
+	// RoleAll runs the sources, the calculator and the exporters in a
+	// single process, which is the default
+	RoleAll Role = iota
+
+	// RoleCollector runs the sources, and forwards the collected metrics
+	// to a calculator node
+	RoleCollector
+
+	// RoleCalculator receives the collected metrics of the collector
+	// nodes, and runs the calculator and the exporters
+	RoleCalculator
+)
+
+var roles = map[Role]string{
+	RoleAll:        "all",
+	RoleCollector:  "collector",
+	RoleCalculator: "calculator",
+}
+
+func (r Role) String() string {
+	return roles[r]
+}
+
+// Collects returns whether the node runs the sources
+func (r Role) Collects() bool {
+	return r != RoleCalculator
+}
+
+// Calculates returns whether the node runs the calculator and exporters
+func (r Role) Calculates() bool {
+	return r != RoleCollector
+}
+
+// ParseRole returns the role by name: all, collector or calculator
+func ParseRole(s string) (Role, error) {
+	for r, name := range roles {
+		if name == s {
+			return r, nil
+		}
+	}
+	return RoleAll, fmt.Errorf("error node role not supported: %s", s)
+}
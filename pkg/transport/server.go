@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/bus"
+	"github.com/re-cinq/aether/pkg/log"
+	"github.com/re-cinq/aether/pkg/transport/proto"
+	"google.golang.org/grpc"
+)
+
+// Server receives the events of other nodes and publishes them on the bus
+// of this node. An event is acknowledged once it is queued on the bus.
+type Server struct {
+	bus *bus.Bus
+
+	// the event types other nodes may publish
+	events map[bus.EventType]bool
+
+	grpc *grpc.Server
+}
+
+// NewServer returns a server publishing the events of the event types it
+// receives on the bus, the topics of the event types must be created on
+// the bus to decode the events
+func NewServer(b *bus.Bus, events ...bus.EventType) *Server {
+	s := &Server{
+		bus:    b,
+		events: make(map[bus.EventType]bool),
+	}
+
+	for _, e := range events {
+		s.events[e] = true
+	}
+
+	return s
+}
+
+// Register the transport service on the gRPC server
+func (s *Server) Register(g *grpc.Server) {
+	proto.RegisterTransportServer(g, s)
+}
+
+// Serve accepts the connections of other nodes on the listener, which are
+// secured with the credentials of the options (see ServerCredentials). It
+// blocks until the server is stopped.
+func (s *Server) Serve(ctx context.Context, lis net.Listener, opts ...grpc.ServerOption) error {
+	s.grpc = grpc.NewServer(opts...)
+	s.Register(s.grpc)
+
+	log.FromContext(ctx).Info("transport server started", "address", lis.Addr().String())
+	return s.grpc.Serve(lis)
+}
+
+// Stop closes the connections of the other nodes, after the events they
+// sent are published
+func (s *Server) Stop(ctx context.Context) {
+	if s.grpc != nil {
+		s.grpc.GracefulStop()
+	}
+}
+
+// Publish receives a stream of events from another node and acknowledges
+// each event once it is published on the bus
+func (s *Server) Publish(stream proto.Transport_PublishServer) error {
+	ctx := stream.Context()
+
+	for {
+		e, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ack := &proto.Ack{Sequence: e.Sequence}
+		if err := s.publish(ctx, e); err != nil {
+			log.FromContext(ctx).Error("error publishing event from other node", "id", e.Id, "error", err)
+			ack.Error = err.Error()
+		}
+
+		if err := stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+// publish decodes the event with the topic of its type and publishes it,
+// the metadata is kept, so the event has the same ID on all nodes
+func (s *Server) publish(ctx context.Context, e *proto.Event) error {
+	t := bus.EventType(e.Type)
+	if !s.events[t] {
+		return fmt.Errorf("error event type not accepted: %d", e.Type)
+	}
+
+	data, err := s.bus.Decode(t, e.Data)
+	if err != nil {
+		return err
+	}
+
+	return s.bus.Publish(ctx, &bus.Event{
+		Type: t,
+		Data: data,
+		Metadata: bus.Metadata{
+			ID:        e.Id,
+			Timestamp: time.Unix(0, e.Timestamp),
+			Source:    e.Source,
+			ScrapeID:  e.ScrapeId,
+			Window:    time.Duration(e.Window),
+			Replayed:  e.Replayed,
+		},
+	})
+}
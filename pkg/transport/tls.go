@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TLSConfig are the certificates securing the connections between the
+// nodes, the files are PEM encoded
+type TLSConfig struct {
+	// Sends the events in plain text, without authenticating the nodes
+	Insecure bool
+
+	// The certificate and key of the node
+	CertFile string
+	KeyFile  string
+
+	// The CA the certificates of the other nodes are verified with, the
+	// system roots are used when it is not set
+	CAFile string
+
+	// The name the certificate of the calculator node is verified
+	// with, the default is the host of the target
+	ServerName string
+}
+
+// ServerCredentials returns the credentials of the server of a calculator
+// node, which requires a certificate. With a CA the collector nodes must
+// present a certificate signed by it (mTLS).
+func ServerCredentials(cfg TLSConfig) (credentials.TransportCredentials, error) {
+	if cfg.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("error the transport server requires a certificate and key")
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the transport certificate: %w", err)
+	}
+
+	c := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.CAFile != "" {
+		c.ClientCAs, err = loadCA(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(c), nil
+}
+
+// ClientCredentials returns the credentials of the forwarder of a collector
+// node, which verifies the certificate of the calculator node. With a
+// certificate the collector node authenticates itself (mTLS).
+func ClientCredentials(cfg TLSConfig) (credentials.TransportCredentials, error) {
+	if cfg.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	c := &tls.Config{
+		ServerName: cfg.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading the transport certificate: %w", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CAFile != "" {
+		pool, err := loadCA(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		c.RootCAs = pool
+	}
+
+	return credentials.NewTLS(c), nil
+}
+
+// loadCA reads the certificates of the CA file
+func loadCA(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the transport CA: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("error no certificates in the transport CA: %s", file)
+	}
+	return pool, nil
+}
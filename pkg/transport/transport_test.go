@@ -0,0 +1,286 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/bus"
+	"github.com/re-cinq/aether/pkg/transport/proto"
+	"github.com/re-cinq/aether/pkg/wal"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type collected struct {
+	Name string
+}
+
+type received struct {
+	data     collected
+	metadata bus.Metadata
+}
+
+// serve starts a server publishing the events on the bus, over an
+// in-memory connection, and returns a forwarder to it
+func serve(t *testing.T, b *bus.Bus, events ...bus.EventType) *Forwarder {
+	return serveWith(t, b, insecure.NewCredentials(), insecure.NewCredentials(), events...)
+}
+
+// serveWith starts a server with the credentials of the server, and
+// returns a forwarder to it with the credentials of the client
+func serveWith(t *testing.T, b *bus.Bus, server, client credentials.TransportCredentials, events ...bus.EventType) *Forwarder {
+	lis := bufconn.Listen(1 << 20)
+	s := NewServer(b, events...)
+	go func() {
+		_ = s.Serve(context.Background(), lis, grpc.Creds(server))
+	}()
+	t.Cleanup(func() { s.Stop(context.Background()) })
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(client),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return NewForwarder(conn, WithTimeout(time.Second), WithRetryTimeout(time.Second))
+}
+
+func TestForward(t *testing.T) {
+	ctx := context.Background()
+
+	// the calculator node
+	calculator := bus.New()
+	events := make(chan received, 10)
+	bus.NewTopic[collected](calculator, 1).Subscribe(func(ctx context.Context, c collected) {
+		events <- received{data: c, metadata: bus.MetadataFromContext(ctx)}
+	})
+	calculator.Start(ctx)
+	defer calculator.Stop(ctx)
+
+	// the collector node
+	collector := bus.New()
+	collector.Subscribe(1, serve(t, calculator, 1))
+	collector.Start(ctx)
+
+	ctx = bus.WithMetadata(ctx, bus.Metadata{Source: "gcp", ScrapeID: "scrape", Window: 5 * time.Minute})
+	topic := bus.NewTopic[collected](collector, 1)
+	assert.NoError(t, topic.Publish(ctx, collected{Name: "a"}))
+	assert.NoError(t, topic.Publish(ctx, collected{Name: "b"}))
+
+	// the events are handled once the calculator node has them
+	collector.Stop(ctx)
+	stats := collector.SubscriberStats()
+	assert.Equal(t, uint64(2), stats[0].Handled)
+
+	names := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		e := <-events
+		names[e.data.Name] = true
+		assert.NotEmpty(t, e.metadata.ID)
+		assert.Equal(t, "gcp", e.metadata.Source)
+		assert.Equal(t, "scrape", e.metadata.ScrapeID)
+		assert.Equal(t, 5*time.Minute, e.metadata.Window)
+	}
+	assert.Equal(t, map[string]bool{"a": true, "b": true}, names)
+}
+
+func TestForwardRejected(t *testing.T) {
+	ctx := context.Background()
+
+	calculator := bus.New()
+	bus.NewTopic[collected](calculator, 1)
+	calculator.Start(ctx)
+	defer calculator.Stop(ctx)
+
+	f := serve(t, calculator, 1)
+	defer f.Stop(ctx)
+
+	// events of other types are rejected, and not retried
+	err := f.send(ctx, event(t, 2))
+	assert.ErrorContains(t, err, "event type not accepted")
+
+	// the stream is still open for the accepted events
+	assert.NoError(t, f.send(ctx, event(t, 1)))
+}
+
+func TestForwardNotAcked(t *testing.T) {
+	ctx := context.Background()
+
+	calculator := bus.New()
+	calculator.Start(ctx)
+	defer calculator.Stop(ctx)
+
+	l, err := wal.Open(t.TempDir(), wal.WithSyncInterval(0))
+	assert.NoError(t, err)
+	defer l.Close()
+
+	// the calculator node does not accept the events
+	collector := bus.New(bus.WithLog(l, 1))
+	bus.NewTopic[collected](collector, 1)
+	collector.Subscribe(1, serve(t, calculator))
+	collector.Start(ctx)
+
+	assert.NoError(t, bus.NewTopic[collected](collector, 1).Publish(ctx, collected{Name: "a"}))
+	collector.Stop(ctx)
+
+	// the event stays in the log, so it is forwarded again after a restart
+	assert.Equal(t, uint64(1), collector.SubscriberStats()[0].Handled)
+	assert.Equal(t, uint64(0), l.Committed())
+}
+
+func TestForwardTLS(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	ca := newCA(t)
+	ca.issue(t, dir, "calculator", "localhost")
+	ca.issue(t, dir, "collector")
+	caFile := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, ca.pem, 0o600))
+
+	calculator := bus.New()
+	bus.NewTopic[collected](calculator, 1)
+	calculator.Start(ctx)
+	defer calculator.Stop(ctx)
+
+	// the calculator node requires a certificate of the collector nodes
+	server, err := ServerCredentials(TLSConfig{
+		CertFile: filepath.Join(dir, "calculator.pem"),
+		KeyFile:  filepath.Join(dir, "calculator-key.pem"),
+		CAFile:   caFile,
+	})
+	assert.NoError(t, err)
+
+	client, err := ClientCredentials(TLSConfig{
+		CertFile:   filepath.Join(dir, "collector.pem"),
+		KeyFile:    filepath.Join(dir, "collector-key.pem"),
+		CAFile:     caFile,
+		ServerName: "localhost",
+	})
+	assert.NoError(t, err)
+
+	f := serveWith(t, calculator, server, client, 1)
+	defer f.Stop(ctx)
+	assert.NoError(t, f.send(ctx, event(t, 1)))
+
+	// a collector node without a certificate is rejected
+	anonymous, err := ClientCredentials(TLSConfig{CAFile: caFile, ServerName: "localhost"})
+	assert.NoError(t, err)
+
+	f = serveWith(t, calculator, server, anonymous, 1)
+	defer f.Stop(ctx)
+	assert.Error(t, f.send(ctx, event(t, 1)))
+
+	// the server requires a certificate
+	_, err = ServerCredentials(TLSConfig{})
+	assert.Error(t, err)
+}
+
+func TestForwardStopped(t *testing.T) {
+	ctx := context.Background()
+
+	f := serve(t, bus.New(), 1)
+	f.Stop(ctx)
+
+	assert.ErrorIs(t, f.send(ctx, event(t, 1)), ErrStopped)
+}
+
+func TestParseRole(t *testing.T) {
+	for _, r := range []Role{RoleAll, RoleCollector, RoleCalculator} {
+		parsed, err := ParseRole(r.String())
+		assert.NoError(t, err)
+		assert.Equal(t, r, parsed)
+	}
+
+	_, err := ParseRole("exporter")
+	assert.Error(t, err)
+
+	assert.True(t, RoleCollector.Collects())
+	assert.False(t, RoleCollector.Calculates())
+	assert.False(t, RoleCalculator.Collects())
+	assert.True(t, RoleAll.Collects() && RoleAll.Calculates())
+}
+
+// testCA signs the certificates of the nodes
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "aether"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue writes the certificate and key of the node to the directory
+func (ca *testCA) issue(t *testing.T, dir, name string, hosts ...string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     hosts,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPEM, 0o600))
+}
+
+// event returns an event of the type to send
+func event(t *testing.T, eventType int32) *proto.Event {
+	data, err := json.Marshal(collected{Name: "a"})
+	assert.NoError(t, err)
+	return &proto.Event{Type: eventType, Id: bus.NewID(), Timestamp: time.Now().UnixNano(), Data: data}
+}
@@ -7,6 +7,11 @@ type ResourceEmissions struct {
 
 	// The unit of the emission
 	Unit EmissionUnit
+
+	// The lower and upper bounds of the emissions, as the
+	// inputs of the estimation have a different confidence
+	Low  float64
+	High float64
 }
 
 // New instance of the resource emission
@@ -16,3 +21,28 @@ func NewResourceEmission(value float64, unit EmissionUnit) ResourceEmissions {
 		Unit:  unit,
 	}
 }
+
+// WithUncertainty sets the bounds of the emissions from the relative
+// uncertainty of the estimate, for example 0.1 for ±10%
+func (r ResourceEmissions) WithUncertainty(u float64) ResourceEmissions {
+	r.Low = r.Value * (1 - u)
+	if r.Low < 0 {
+		r.Low = 0
+	}
+	r.High = r.Value * (1 + u)
+	return r
+}
+
+// Uncertainty returns the relative uncertainty of the emissions,
+// being the distance from the value to the furthest bound
+func (r ResourceEmissions) Uncertainty() float64 {
+	if r.Value == 0 {
+		return 0
+	}
+
+	u := (r.High - r.Value) / r.Value
+	if l := (r.Value - r.Low) / r.Value; l > u {
+		u = l
+	}
+	return u
+}
@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceEmissionsUncertainty(t *testing.T) {
+	r := NewResourceEmission(100, GCO2eq).WithUncertainty(0.25)
+	assert.Equal(t, 100.0, r.Value)
+	assert.Equal(t, 75.0, r.Low)
+	assert.Equal(t, 125.0, r.High)
+	assert.Equal(t, 0.25, r.Uncertainty())
+
+	// the lower bound never goes below zero
+	r = NewResourceEmission(100, GCO2eq).WithUncertainty(1.5)
+	assert.Equal(t, 0.0, r.Low)
+	assert.Equal(t, 250.0, r.High)
+	assert.Equal(t, 1.5, r.Uncertainty())
+
+	// no emissions have no uncertainty
+	assert.Equal(t, 0.0, NewResourceEmission(0, GCO2eq).Uncertainty())
+}
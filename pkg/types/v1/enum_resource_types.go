@@ -18,6 +18,7 @@ var ResourceTypes = map[string]ResourceType{
 	memoryString:  Memory,
 	storageString: Storage,
 	networkString: Network,
+	gpuString:     GPU,
 }
 
 const (
@@ -33,12 +34,15 @@ const (
 
 	// Network resource
 	Network ResourceType = networkString
+	// GPU resource
+	GPU ResourceType = gpuString
 
 	// Constant string definitions
 	cpuString     = "cpu"
 	memoryString  = "memory"
 	storageString = "storage"
 	networkString = "network"
+	gpuString     = "gpu"
 )
 
 // Return the resource type as string
@@ -19,10 +19,12 @@ var ResourceUnits = map[string]ResourceUnit{
 	mbString:   MB,
 	gbString:   GB,
 	tbString:   TB,
+	pbString:   PB,
 	kbsString:  KBs,
 	mbsString:  MBs,
 	gbsString:  GBs,
 	tbsString:  TBs,
+	gpusString: GPUs,
 }
 
 const (
@@ -63,6 +65,12 @@ const (
 	// TBs: Terabits per second
 	TBs ResourceUnit = tbsString
 
+	// -------------------------------------------
+	// Used for accelerators
+
+	// GPUs: amount of GPUs attached to the instance
+	GPUs ResourceUnit = gpusString
+
 	// Static strings
 	vCPUString = "vCPU"
 	kbString   = "KB"
@@ -75,6 +83,8 @@ const (
 	mbsString = "MB/s"
 	gbsString = "GB/s"
 	tbsString = "TB/s"
+
+	gpusString = "GPU"
 )
 
 // Returns a string representation of the Resource unit
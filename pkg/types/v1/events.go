@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"strconv"
+
 	"github.com/re-cinq/aether/pkg/bus"
 )
 
@@ -12,3 +14,28 @@ const (
 	// calculated
 	EmissionsCalculatedEvent
 )
+
+// EventName returns the name of the event type, used
+// to label the statistics of the bus
+func EventName(t bus.EventType) string {
+	switch t {
+	case MetricsCollectedEvent:
+		return "metrics_collected"
+	case EmissionsCalculatedEvent:
+		return "emissions_calculated"
+	default:
+		return strconv.Itoa(int(t))
+	}
+}
+
+// MetricsCollected returns the typed topic of the MetricsCollectedEvent,
+// the instances are published as values
+func MetricsCollected(b *bus.Bus) *bus.Topic[Instance] {
+	return bus.NewTopic[Instance](b, MetricsCollectedEvent)
+}
+
+// EmissionsCalculated returns the typed topic of the EmissionsCalculatedEvent,
+// the instances are published as values
+func EmissionsCalculated(b *bus.Bus) *bus.Topic[Instance] {
+	return bus.NewTopic[Instance](b, EmissionsCalculatedEvent)
+}
@@ -0,0 +1,201 @@
+package v1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+)
+
+// Problem is an issue in a dataset that does not prevent loading it,
+// but makes the calculations less accurate
+type Problem struct {
+	Provider v1.Provider
+
+	// The entry with the problem, for example a machine type or region
+	Entry string
+
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Entry == "" {
+		return fmt.Sprintf("%s: %s", p.Provider, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Provider, p.Entry, p.Message)
+}
+
+// Check returns the problems in the emission factors of all providers:
+// machine types with an architecture that has no machine specs, negative
+// wattage, power curves that are not monotonic, and regions without grid
+// intensity. Unlike Validate, the dataset can still be used.
+func (d *Dataset) Check() []Problem {
+	var problems []Problem
+
+	for provider, ef := range d.Providers {
+		c := &checker{provider: provider}
+		c.defaults(ef.ProviderDefaults)
+		c.embodied(ef.Embodied)
+		c.machines(ef.Machines)
+		c.gpu(ef.GPU)
+		c.grid(ef)
+		problems = append(problems, c.problems...)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].String() < problems[j].String()
+	})
+
+	return problems
+}
+
+// checker collects the problems of a provider
+type checker struct {
+	provider v1.Provider
+	problems []Problem
+}
+
+func (c *checker) add(entry, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{
+		Provider: c.provider,
+		Entry:    entry,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// minMax checks the min and max wattage of a power profile
+func (c *checker) minMax(entry string, minWatts, maxWatts float64) {
+	if minWatts < 0 || maxWatts < 0 {
+		c.add(entry, "negative wattage: min %g, max %g", minWatts, maxWatts)
+		return
+	}
+	if minWatts > maxWatts {
+		c.add(entry, "min wattage %g is above max wattage %g", minWatts, maxWatts)
+	}
+}
+
+func (c *checker) defaults(pd *ProviderDefaults) {
+	if pd == nil {
+		return
+	}
+
+	c.minMax("defaults", pd.MinWatts, pd.MaxWatts)
+
+	if pd.HDDStorageWatts < 0 || pd.SSDStorageWatts < 0 {
+		c.add("defaults", "negative storage wattage: hdd %g, ssd %g", pd.HDDStorageWatts, pd.SSDStorageWatts)
+	}
+}
+
+// unknownArchitecture is the architecture of the machine types the
+// datasets do not know the architecture of, which use the provider defaults
+const unknownArchitecture = "Unknown"
+
+func (c *checker) embodied(embodied EmbodiedData) {
+	missing := make(map[string][]string)
+	for kind, e := range embodied {
+		if e.Architecture == "" || e.Architecture == unknownArchitecture {
+			continue
+		}
+
+		// the machine specs of the architecture are not in the use dataset,
+		// so the provider defaults are used
+		if e.MachineSpecs.Architecture != e.Architecture {
+			missing[e.Architecture] = append(missing[e.Architecture], kind)
+		}
+	}
+
+	for arch, kinds := range missing {
+		sort.Strings(kinds)
+		c.add(arch, "architecture has no machine specs, used by %d machine types: %s",
+			len(kinds), truncate(kinds, 5))
+	}
+}
+
+func (c *checker) machines(machines MachineData) {
+	for kind := range machines {
+		m := machines[kind]
+
+		if !m.HasWattageCurve() {
+			c.minMax(kind, m.MinWatts, m.MaxWatts)
+		}
+
+		for _, curve := range []struct {
+			name  string
+			curve []data.Wattage
+		}{
+			{"PkgWatt", m.PkgWatt},
+			{"RAMWatt", m.RAMWatt},
+			{"GPUWatt", m.GPUWatt},
+			{"TotalWatt", m.TotalWatt},
+		} {
+			if msg := checkCurve(curve.curve); msg != "" {
+				c.add(kind, "%s %s", curve.name, msg)
+			}
+		}
+
+		if m.Platform.TotalScope3 < 0 {
+			c.add(kind, "negative embodied emissions: %g", m.Platform.TotalScope3)
+		}
+	}
+}
+
+// checkCurve returns why the power curve is not valid, the wattage
+// must not be negative and must not decrease as the utilization increases
+func checkCurve(curve []data.Wattage) string {
+	for i, w := range curve {
+		if w.Wattage < 0 {
+			return fmt.Sprintf("has negative wattage %g at %d%%", w.Wattage, w.Percentage)
+		}
+		if i == 0 {
+			continue
+		}
+
+		prev := curve[i-1]
+		if w.Percentage <= prev.Percentage {
+			return fmt.Sprintf("utilization is not increasing: %d%% after %d%%", w.Percentage, prev.Percentage)
+		}
+		if w.Wattage < prev.Wattage {
+			return fmt.Sprintf("is not monotonic: %g W at %d%% after %g W at %d%%",
+				w.Wattage, w.Percentage, prev.Wattage, prev.Percentage)
+		}
+	}
+	return ""
+}
+
+func (c *checker) gpu(gpus GPUData) {
+	for model, g := range gpus {
+		c.minMax(model, g.MinWatts, g.MaxWatts)
+	}
+}
+
+func (c *checker) grid(ef *EmissionFactors) {
+	for region, co2e := range ef.Coefficient {
+		if co2e <= 0 {
+			c.add(region, "grid intensity is not positive: %g", co2e)
+		}
+	}
+
+	// regions with a PUE are expected to have grid intensity as well
+	seen := make(map[string]bool)
+	for _, p := range ef.PUE {
+		if p.PUE < 1 && p.PUE != 0 {
+			c.add(p.Region, "PUE %g is below 1", p.PUE)
+		}
+
+		if _, ok := ef.Coefficient[p.Region]; ok || seen[p.Region] {
+			continue
+		}
+		seen[p.Region] = true
+		c.add(p.Region, "region has no grid intensity")
+	}
+}
+
+// truncate joins the first n values
+func truncate(values []string, n int) string {
+	if len(values) <= n {
+		return strings.Join(values, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(values[:n], ", "), len(values)-n)
+}
@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"testing"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDataset(t *testing.T) {
+	d := &Dataset{
+		Providers: map[v1.Provider]*EmissionFactors{
+			"fake": {
+				ProviderDefaults: &ProviderDefaults{MinWatts: 3.5, MaxWatts: 0.7, SSDStorageWatts: -1},
+				Coefficient:      CoefficientData{"eu-west-1": 0.0003, "eu-north-1": 0},
+				Embodied: EmbodiedData{
+					"a1.large":  {Architecture: "Graviton", MachineSpecs: MachineSpecs{Architecture: "Graviton"}},
+					"x9.large":  {Architecture: "Zen 9"},
+					"x9.xlarge": {Architecture: "Zen 9"},
+					"x1.large":  {Architecture: "Unknown"},
+				},
+				Machines: MachineData{
+					"a1.large": {MinWatts: -1, MaxWatts: 3.5},
+					"m5.large": {Instance: data.Instance{
+						PkgWatt: []data.Wattage{{Percentage: 0, Wattage: 2}, {Percentage: 50, Wattage: 1}},
+						RAMWatt: []data.Wattage{{Percentage: 0, Wattage: -1}},
+					}},
+					"m6.large": {Instance: data.Instance{
+						PkgWatt: []data.Wattage{{Percentage: 50, Wattage: 1}, {Percentage: 10, Wattage: 2}},
+					}},
+					"m7.large": {
+						Instance: data.Instance{
+							PkgWatt: []data.Wattage{{Percentage: 0, Wattage: 1}, {Percentage: 100, Wattage: 2}},
+						},
+						// the min and max wattage are not used with a curve
+						MinWatts: -1,
+					},
+				},
+				GPU: GPUData{"t4": {Model: "t4", MinWatts: 70, MaxWatts: 8}},
+				PUE: []PUE{
+					{Region: "eu-west-1", PUE: 1.1},
+					{Region: "us-east-1", PUE: 0.9},
+					{Region: "us-east-1", Zone: "us-east-1a", PUE: 1.2},
+				},
+			},
+		},
+	}
+
+	var problems []string
+	for _, p := range d.Check() {
+		problems = append(problems, p.String())
+	}
+
+	assert.Equal(t, []string{
+		"fake: Zen 9: architecture has no machine specs, used by 2 machine types: x9.large, x9.xlarge",
+		"fake: a1.large: negative wattage: min -1, max 3.5",
+		"fake: defaults: min wattage 3.5 is above max wattage 0.7",
+		"fake: defaults: negative storage wattage: hdd 0, ssd -1",
+		"fake: eu-north-1: grid intensity is not positive: 0",
+		"fake: m5.large: PkgWatt is not monotonic: 1 W at 50% after 2 W at 0%",
+		"fake: m5.large: RAMWatt has negative wattage -1 at 0%",
+		"fake: m6.large: PkgWatt utilization is not increasing: 10% after 50%",
+		"fake: t4: min wattage 70 is above max wattage 8",
+		"fake: us-east-1: PUE 0.9 is below 1",
+		"fake: us-east-1: region has no grid intensity",
+	}, problems)
+
+	// the embedded snapshot has no problems
+	dir, err := LoadFactorsData("")
+	assert.Nil(t, err)
+	d, err = LoadDataset("snapshot", dir.DataPath, dir.InstanceDataPath)
+	assert.Nil(t, err)
+	assert.Empty(t, d.Check())
+}
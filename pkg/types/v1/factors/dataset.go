@@ -0,0 +1,274 @@
+package v1
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// snapshot is a versioned copy of the data directory of the emissions-data
+// repo embedded in the binary, so that aether can start without network.
+// The v2 instance datasets of GCP and Azure are not in the repo yet, they
+// are derived from the v1 datasets of the snapshot.
+//
+//go:generate go run ./internal/instances -data data
+//go:embed data
+var snapshot embed.FS
+
+const (
+	// the pattern of the directories the snapshot and tarballs are
+	// extracted to, which are created per process so that processes on
+	// the same host do not remove the datasets of each other
+	extractPattern = "aether-factors-"
+
+	// the file holding the version of a dataset directory
+	versionFile = "VERSION"
+)
+
+// DataDir is a directory set up with the datasets of a version
+type DataDir struct {
+	// The version of the datasets
+	Version string
+
+	// The directory of the v1 datasets
+	DataPath string
+
+	// The directory of the v2 {provider}-instances.yaml datasets
+	InstanceDataPath string
+}
+
+var (
+	// the directories this process extracted the snapshot and the last
+	// tarball to. The snapshot does not change, so it is only extracted
+	// once, a tarball is extracted again every time it is loaded
+	snapshotDir string
+	tarballDir  string
+
+	extractLock sync.Mutex
+)
+
+// LoadFactorsData sets up the datasets the emission factors are read from
+// and returns their directories and version. The path can be a local directory or tarball
+// (.tar, .tar.gz or .tgz) laid out like the data directory of the
+// emissions-data repo, with the v1 and v2 directories. When the path
+// is empty the snapshot embedded in the binary is used.
+func LoadFactorsData(path string) (DataDir, error) {
+	extractLock.Lock()
+	defer extractLock.Unlock()
+
+	if path == "" {
+		if snapshotDir == "" {
+			dir, err := extract(extractSnapshot)
+			if err != nil {
+				return DataDir{}, err
+			}
+			snapshotDir = dir
+		}
+		return useDataDir(snapshotDir)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return DataDir{}, err
+	}
+
+	if info.IsDir() {
+		return useDataDir(path)
+	}
+
+	dir, err := extract(func(dir string) error {
+		return extractTarball(path, dir)
+	})
+	if err != nil {
+		return DataDir{}, err
+	}
+
+	d, err := useDataDir(dir)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return DataDir{}, err
+	}
+
+	// the datasets of the previous tarball are no longer used
+	_ = removeDir(tarballDir)
+	tarballDir = dir
+
+	return d, nil
+}
+
+// RemoveFactorsData removes the directories the snapshot and tarballs were
+// extracted to, it is called when the datasets are no longer loaded. The
+// dataset directories that were not extracted by this process are kept.
+func RemoveFactorsData() error {
+	extractLock.Lock()
+	defer extractLock.Unlock()
+
+	err := errors.Join(removeDir(snapshotDir), removeDir(tarballDir))
+	snapshotDir = ""
+	tarballDir = ""
+	return err
+}
+
+// removeDir removes a directory created by extract, any other
+// directory is kept so a configured path is never removed
+func removeDir(dir string) error {
+	if dir == "" || !strings.HasPrefix(filepath.Base(dir), extractPattern) {
+		return nil
+	}
+	return os.RemoveAll(dir)
+}
+
+// extract creates a new directory and extracts the datasets to it
+func extract(fn func(dir string) error) (string, error) {
+	dir, err := os.MkdirTemp("", extractPattern)
+	if err != nil {
+		return "", err
+	}
+
+	if err := fn(dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// UpdateFactorsData clones or updates the emissions-data repo and reads the
+// emission factors from it, which requires network access. It returns the
+// commit the repo is checked out at as the version of the datasets.
+func UpdateFactorsData() (DataDir, error) {
+	if err := CloneAndUpdateFactorsData(); err != nil {
+		return DataDir{}, err
+	}
+
+	version, err := RepoVersion(repoPath)
+	if err != nil {
+		return DataDir{}, err
+	}
+
+	return DataDir{
+		Version:          version,
+		DataPath:         filepath.Join(repoPath, "data", "v1"),
+		InstanceDataPath: filepath.Join(repoPath, "data", "v2"),
+	}, nil
+}
+
+// useDataDir returns the directories of the datasets in the directory,
+// which may also be the root of the emissions-data repo
+func useDataDir(dir string) (DataDir, error) {
+	if _, err := os.Stat(filepath.Join(dir, "data", "v1")); err == nil {
+		dir = filepath.Join(dir, "data")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "v1")); err != nil {
+		return DataDir{}, fmt.Errorf("error dataset directory %s has no v1 datasets: %w", dir, err)
+	}
+
+	return DataDir{
+		Version:          datasetVersion(dir),
+		DataPath:         filepath.Join(dir, "v1"),
+		InstanceDataPath: filepath.Join(dir, "v2"),
+	}, nil
+}
+
+// datasetVersion returns the version in the VERSION file of the dataset
+// directory, datasets without a version are identified by their path
+func datasetVersion(dir string) string {
+	b, err := os.ReadFile(filepath.Join(dir, versionFile))
+	if err != nil {
+		return dir
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// extractSnapshot writes the embedded snapshot to the directory
+func extractSnapshot(dir string) error {
+	root, err := fs.Sub(snapshot, "data")
+	if err != nil {
+		return err
+	}
+
+	return fs.WalkDir(root, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		dest := filepath.Join(dir, path)
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0o755)
+		}
+
+		b, err := fs.ReadFile(root, path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dest, b, 0o600)
+	})
+}
+
+// extractTarball extracts the, optionally gzipped, tarball to the directory
+func extractTarball(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// do not write outside of the directory
+		dest := filepath.Join(dir, filepath.Clean("/"+h.Name))
+
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dest, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+				return err
+			}
+			if err := writeFile(dest, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeFile writes the contents of the reader to the file
+func writeFile(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// #nosec G110 -- the tarball is provided by the operator
+	_, err = io.Copy(f, r)
+	return err
+}
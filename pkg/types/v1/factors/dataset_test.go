@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	// remove the datasets extracted by the tests
+	_ = RemoveFactorsData()
+	os.Exit(code)
+}
+
+func TestLoadEmbeddedFactorsData(t *testing.T) {
+	dir, err := LoadFactorsData("")
+	assert.Nil(t, err)
+	assert.Equal(t, "7a12fb60f3bd", dir.Version)
+	assert.Equal(t, filepath.Join(snapshotDir, "v1"), dir.DataPath)
+	assert.FileExists(t, filepath.Join(dir.InstanceDataPath, "aws-instances.yaml"))
+
+	// the snapshot is extracted once per process
+	extracted := snapshotDir
+	_, err = LoadFactorsData("")
+	assert.Nil(t, err)
+	assert.Equal(t, extracted, snapshotDir)
+
+	for _, p := range []v1.Provider{v1.AWS, v1.GCP, v1.Azure} {
+		ef, err := ProviderEmissions(p, dir.DataPath)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, ef.Embodied)
+		assert.NotEmpty(t, ef.Coefficient)
+	}
+}
+
+func TestEmbeddedWattageCurves(t *testing.T) {
+	dir, err := LoadFactorsData("")
+	assert.Nil(t, err)
+	d, err := LoadDataset("snapshot", dir.DataPath, dir.InstanceDataPath)
+	assert.Nil(t, err)
+
+	for _, p := range []v1.Provider{v1.AWS, v1.GCP, v1.Azure} {
+		assert.NotEmpty(t, d.Instances[p], p)
+
+		for kind, i := range d.Instances[p] {
+			m := d.Providers[p].Machines[kind]
+			assert.True(t, m.HasWattageCurve(), "%s %s has no CPU wattage curve", p, kind)
+			assert.NotEmpty(t, m.RAMWatt, "%s %s has no memory wattage curve", p, kind)
+			assert.Equal(t, i.PkgWatt, m.PkgWatt)
+		}
+	}
+}
+
+func TestLoadFactorsDataDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "v1"), 0o755))
+
+	// datasets without a version are identified by their path
+	d, err := LoadFactorsData(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, DataDir{
+		Version:          dir,
+		DataPath:         filepath.Join(dir, "v1"),
+		InstanceDataPath: filepath.Join(dir, "v2"),
+	}, d)
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "VERSION"), []byte("2024.1\n"), 0o600))
+	d, err = LoadFactorsData(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, "2024.1", d.Version)
+
+	_, err = LoadFactorsData(testDataPath)
+	assert.ErrorContains(t, err, "has no v1 datasets")
+
+	_, err = LoadFactorsData(filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// only the extracted directories are removed, never a configured one
+	assert.Nil(t, RemoveFactorsData())
+	assert.DirExists(t, dir)
+
+	tarballDir = dir
+	assert.Nil(t, RemoveFactorsData())
+	assert.DirExists(t, dir)
+	assert.Empty(t, tarballDir)
+}
+
+func TestLoadFactorsDataTarball(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.tar.gz")
+	f, err := os.Create(path)
+	assert.Nil(t, err)
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"data/VERSION":              "abc123",
+		"data/v1/fake-default.yaml": "name: fake\nminWatts: 0.71\n",
+		"../outside.yaml":           "name: evil\n",
+	} {
+		assert.Nil(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o600,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err = tw.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gz.Close())
+	assert.Nil(t, f.Close())
+
+	d, err := LoadFactorsData(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "abc123", d.Version)
+	assert.Equal(t, filepath.Join(tarballDir, "data", "v1"), d.DataPath)
+
+	ef := &EmissionFactors{Provider: "fake"}
+	assert.Nil(t, ef.getProviderDefaults(d.DataPath))
+	assert.Equal(t, 0.71, ef.MinWatts)
+
+	// entries are not written outside of the directory
+	assert.FileExists(t, filepath.Join(tarballDir, "outside.yaml"))
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(tarballDir), "outside.yaml"))
+
+	// loading the tarball again extracts it to a new
+	// directory and removes the previous one
+	dir := tarballDir
+	_, err = LoadFactorsData(path)
+	assert.Nil(t, err)
+	assert.NotEqual(t, dir, tarballDir)
+	assert.NoDirExists(t, dir)
+
+	dir = tarballDir
+	assert.Nil(t, RemoveFactorsData())
+	assert.NoDirExists(t, dir)
+	assert.Empty(t, tarballDir)
+}
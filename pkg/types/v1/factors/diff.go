@@ -0,0 +1,276 @@
+package v1
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+)
+
+// Change is a difference between two versions of the datasets. Added
+// entries have no old value and removed entries have no new value.
+type Change struct {
+	Provider v1.Provider
+
+	// The dataset of the entry: defaults, grid, machine, gpu or pue
+	Dataset string
+
+	// The entry that changed, for example a machine type or region
+	Entry string
+
+	// The field of the entry that changed, empty when the
+	// entry is a single value or was added or removed
+	Field string
+
+	Old string
+	New string
+}
+
+// Diff returns the changes of the emission factors from the old to the
+// new dataset, sorted by provider, dataset and entry
+func Diff(from, to *Dataset) []Change {
+	var changes []Change
+
+	for _, provider := range providers(from, to) {
+		o, n := from.Providers[provider], to.Providers[provider]
+		if o == nil {
+			o = &EmissionFactors{}
+		}
+		if n == nil {
+			n = &EmissionFactors{}
+		}
+
+		d := &differ{provider: provider}
+		d.fields("defaults", "", defaultsFields(o.ProviderDefaults), defaultsFields(n.ProviderDefaults))
+		d.grid(o.Coefficient, n.Coefficient)
+		d.machines(o.Machines, n.Machines)
+		d.gpu(o.GPU, n.GPU)
+		d.pue(o.PUE, n.PUE)
+		changes = append(changes, d.changes...)
+	}
+
+	return changes
+}
+
+// providers returns the providers of both datasets
+func providers(from, to *Dataset) []v1.Provider {
+	seen := make(map[v1.Provider]bool)
+	for p := range from.Providers {
+		seen[p] = true
+	}
+	for p := range to.Providers {
+		seen[p] = true
+	}
+
+	ps := make([]v1.Provider, 0, len(seen))
+	for p := range seen {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i] < ps[j] })
+	return ps
+}
+
+// differ collects the changes of a provider
+type differ struct {
+	provider v1.Provider
+	changes  []Change
+}
+
+func (d *differ) add(dataset, entry, field, from, to string) {
+	d.changes = append(d.changes, Change{
+		Provider: d.provider,
+		Dataset:  dataset,
+		Entry:    entry,
+		Field:    field,
+		Old:      from,
+		New:      to,
+	})
+}
+
+// fields adds the changed fields of an entry, or the whole entry when
+// it was added or removed. Nil fields mean the entry does not exist.
+func (d *differ) fields(dataset, entry string, from, to map[string]string) {
+	switch {
+	case from == nil && to == nil:
+		return
+	case from == nil:
+		d.add(dataset, entry, "", "", summary(to))
+		return
+	case to == nil:
+		d.add(dataset, entry, "", summary(from), "")
+		return
+	}
+
+	for _, k := range keys(from, to) {
+		if from[k] != to[k] {
+			d.add(dataset, entry, k, from[k], to[k])
+		}
+	}
+}
+
+func (d *differ) grid(from, to CoefficientData) {
+	for _, region := range keys(from, to) {
+		o, inOld := from[region]
+		n, inNew := to[region]
+		if inOld && inNew && o == n {
+			continue
+		}
+
+		var ov, nv string
+		if inOld {
+			ov = formatFloat(o)
+		}
+		if inNew {
+			nv = formatFloat(n)
+		}
+		d.add("grid", region, "", ov, nv)
+	}
+}
+
+func (d *differ) machines(from, to MachineData) {
+	for _, kind := range keys(from, to) {
+		var o, n map[string]string
+		if m, ok := from[kind]; ok {
+			o = machineFields(&m)
+		}
+		if m, ok := to[kind]; ok {
+			n = machineFields(&m)
+		}
+		d.fields("machine", kind, o, n)
+	}
+}
+
+func (d *differ) gpu(from, to GPUData) {
+	for _, model := range keys(from, to) {
+		var o, n map[string]string
+		if g, ok := from[model]; ok {
+			o = yamlFields(g)
+		}
+		if g, ok := to[model]; ok {
+			n = yamlFields(g)
+		}
+		d.fields("gpu", model, o, n)
+	}
+}
+
+func (d *differ) pue(from, to []PUE) {
+	byEntry := func(pue []PUE) map[string]string {
+		m := make(map[string]string, len(pue))
+		for _, p := range pue {
+			m[pueEntry(p)] = formatFloat(p.PUE)
+		}
+		return m
+	}
+
+	o, n := byEntry(from), byEntry(to)
+	for _, entry := range keys(o, n) {
+		if o[entry] != n[entry] {
+			d.add("pue", entry, "", o[entry], n[entry])
+		}
+	}
+}
+
+// pueEntry returns the region, or the region and zone, of the PUE
+func pueEntry(p PUE) string {
+	if p.Zone == "" {
+		return p.Region
+	}
+	return p.Region + "/" + p.Zone
+}
+
+func defaultsFields(pd *ProviderDefaults) map[string]string {
+	if pd == nil {
+		return nil
+	}
+	return yamlFields(pd)
+}
+
+// yamlFields returns the fields of a flat struct by their yaml name
+func yamlFields(v interface{}) map[string]string {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(b, &values); err != nil {
+		return nil
+	}
+
+	fields := make(map[string]string, len(values))
+	for k, v := range values {
+		fields[k] = fmt.Sprint(v)
+	}
+	return fields
+}
+
+// machineFields returns the fields of a machine type that are used
+// in the calculations
+func machineFields(m *Machine) map[string]string {
+	return map[string]string{
+		"architecture":     m.Platform.Architecture,
+		"vcpu":             strconv.Itoa(m.VCPU),
+		"memory":           formatFloat(m.MemoryGB),
+		"gpus":             strconv.Itoa(m.GPUCount),
+		"platform.vcpu":    strconv.Itoa(m.Platform.VCPU),
+		"platform.memory":  formatFloat(m.Platform.MemoryGB),
+		"platform.gpus":    strconv.Itoa(m.Platform.GPUCount),
+		"embodied":         formatFloat(m.Platform.TotalScope3),
+		"embodied.cpu":     formatFloat(m.Platform.CPUScope3),
+		"embodied.memory":  formatFloat(m.Platform.MemoryScope3),
+		"embodied.storage": formatFloat(m.Platform.StorageScope3),
+		"embodied.gpu":     formatFloat(m.Platform.GPUScope3),
+		"minwatts":         formatFloat(m.MinWatts),
+		"maxwatts":         formatFloat(m.MaxWatts),
+		"pkgwatt":          FormatCurve(m.PkgWatt),
+		"ramwatt":          FormatCurve(m.RAMWatt),
+		"gpuwatt":          FormatCurve(m.GPUWatt),
+	}
+}
+
+// FormatCurve formats a power curve as the wattage per utilization,
+// for example "0%: 1.21W, 100%: 9.96W"
+func FormatCurve(curve []data.Wattage) string {
+	points := make([]string, len(curve))
+	for i, w := range curve {
+		points[i] = fmt.Sprintf("%d%%: %sW", w.Percentage, formatFloat(w.Wattage))
+	}
+	return strings.Join(points, ", ")
+}
+
+// summary formats the fields of an entry that are set
+func summary(fields map[string]string) string {
+	var s []string
+	for _, k := range keys(fields, nil) {
+		if fields[k] != "" && fields[k] != "0" {
+			s = append(s, fmt.Sprintf("%s=%s", k, fields[k]))
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// keys returns the sorted keys of both maps
+func keys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		seen[k] = true
+	}
+	for k := range b {
+		seen[k] = true
+	}
+
+	ks := make([]string, 0, len(seen))
+	for k := range seen {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
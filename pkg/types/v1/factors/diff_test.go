@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"testing"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	from := &Dataset{
+		Providers: map[v1.Provider]*EmissionFactors{
+			"fake": {
+				ProviderDefaults: &ProviderDefaults{MinWatts: 0.74, MaxWatts: 3.5},
+				Coefficient:      CoefficientData{"eu-west-1": 0.0003, "eu-north-1": 0.00001},
+				Machines: MachineData{
+					"m5.large": {Instance: data.Instance{
+						VCPU:    2,
+						PkgWatt: []data.Wattage{{Percentage: 0, Wattage: 1.21}, {Percentage: 100, Wattage: 9.96}},
+					}},
+					"m4.large": {Instance: data.Instance{VCPU: 2}},
+				},
+				PUE: []PUE{{Region: "eu-west-1", Zone: "a", PUE: 1.2}},
+			},
+			"old": {},
+		},
+	}
+	to := &Dataset{
+		Providers: map[v1.Provider]*EmissionFactors{
+			"fake": {
+				ProviderDefaults: &ProviderDefaults{MinWatts: 0.71, MaxWatts: 3.5},
+				Coefficient:      CoefficientData{"eu-west-1": 0.0002, "eu-south-1": 0.0004},
+				Machines: MachineData{
+					"m5.large": {Instance: data.Instance{
+						VCPU:    2,
+						PkgWatt: []data.Wattage{{Percentage: 0, Wattage: 1.2}, {Percentage: 100, Wattage: 9.96}},
+					}},
+					"m7i.large": {Instance: data.Instance{VCPU: 2}},
+				},
+				GPU: GPUData{"t4": {Model: "t4", MinWatts: 8, MaxWatts: 70}},
+				PUE: []PUE{{Region: "eu-west-1", Zone: "a", PUE: 1.1}},
+			},
+		},
+	}
+
+	assert.Equal(t, []Change{
+		{Provider: "fake", Dataset: "defaults", Field: "minWatts", Old: "0.74", New: "0.71"},
+		{Provider: "fake", Dataset: "grid", Entry: "eu-north-1", Old: "1e-05"},
+		{Provider: "fake", Dataset: "grid", Entry: "eu-south-1", New: "0.0004"},
+		{Provider: "fake", Dataset: "grid", Entry: "eu-west-1", Old: "0.0003", New: "0.0002"},
+		{Provider: "fake", Dataset: "machine", Entry: "m4.large", Old: "vcpu=2"},
+		{Provider: "fake", Dataset: "machine", Entry: "m5.large", Field: "pkgwatt", Old: "0%: 1.21W, 100%: 9.96W", New: "0%: 1.2W, 100%: 9.96W"},
+		{Provider: "fake", Dataset: "machine", Entry: "m7i.large", New: "vcpu=2"},
+		{Provider: "fake", Dataset: "gpu", Entry: "t4", New: "maxWatts=70 minWatts=8 model=t4"},
+		{Provider: "fake", Dataset: "pue", Entry: "eu-west-1/a", Old: "1.2", New: "1.1"},
+	}, Diff(from, to))
+
+	assert.Empty(t, Diff(to, to))
+}
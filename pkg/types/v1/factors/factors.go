@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,8 +17,6 @@ const (
 	repoPath            = "/tmp/emissions-data/"
 )
 
-var DataPath string = fmt.Sprintf("%s/data/v1", repoPath)
-
 // Emission data is currently stored as files in our emissions-data repo.
 // Each file is named "{provider}-{emissionFactor}" where emissionFactor
 // may be default, embodied, grid, and use.
@@ -45,6 +44,16 @@ func ProviderEmissions(provider v1.Provider, dataPath string) (*EmissionFactors,
 		return nil, err
 	}
 
+	err = ef.getGPUData(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ef.getPUEData(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
 	return ef, nil
 }
 
@@ -139,6 +148,49 @@ func (ef *EmissionFactors) getEmbodiedData(dataPath string) error {
 	return nil
 }
 
+// getGPUData reads the optional {provider}-gpu.yaml file into a map of
+// GPU specs by GPU model. Providers without a GPU file use the default
+// GPU specs
+func (ef *EmissionFactors) getGPUData(dataPath string) error {
+	data := []GPUSpecs{}
+	ef.GPU = make(GPUData)
+
+	fp := filepath.Join(dataPath, fmt.Sprintf("%s-gpu.yaml", ef.Provider))
+	err := readYamlData(fp, &data)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, d := range data {
+		d.Model = GPUModel(d.Model)
+		ef.GPU[d.Model] = d
+	}
+
+	return nil
+}
+
+// getPUEData reads the optional {provider}-pue.yaml file containing the
+// PUE of regions and zones. Providers without a PUE file use the
+// average PUE of the provider defaults
+func (ef *EmissionFactors) getPUEData(dataPath string) error {
+	data := []PUE{}
+
+	fp := filepath.Join(dataPath, fmt.Sprintf("%s-pue.yaml", ef.Provider))
+	err := readYamlData(fp, &data)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ef.PUE = data
+	return nil
+}
+
 // readYamlData reads a yaml file and returns a slice of bytes
 func readYamlData(filePath string, data interface{}) error {
 	yamlFile, err := os.ReadFile(filePath)
@@ -175,11 +227,13 @@ func CloneAndUpdateRepo(repoPath, repoURL string) error {
 		}
 		// local repo outdated, so remove the directory
 		// to be re-cloned later
-		if errFetch == nil {
-			os.RemoveAll(repoPath)
-		} else {
+		if errFetch != nil {
+			return errFetch
+		}
+		if err := os.RemoveAll(repoPath); err != nil {
 			return err
 		}
+		err = git.ErrRepositoryNotExists
 	}
 
 	// Repo doesn't exist, so clone it
@@ -192,3 +246,19 @@ func CloneAndUpdateRepo(repoPath, repoURL string) error {
 	}
 	return err
 }
+
+// RepoVersion returns the commit the local repo is checked out
+// at, which is used as the version of the factor datasets
+func RepoVersion(repoPath string) (string, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+
+	return head.Hash().String(), nil
+}
@@ -348,6 +348,17 @@ func TestGetEmissionFactors(t *testing.T) {
 						},
 					},
 				},
+				GPU: GPUData{
+					"a100": {
+						Model:    "a100",
+						MinWatts: 60,
+						MaxWatts: 400,
+					},
+				},
+				PUE: []PUE{
+					{Region: "us-east-1", PUE: 1.15},
+					{Region: "us-east-1", Zone: "us-east-1a", PUE: 1.08},
+				},
 			},
 			expErr: "",
 		},
@@ -365,3 +376,167 @@ func TestGetEmissionFactors(t *testing.T) {
 		})
 	}
 }
+
+func TestGetGPUData(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider v1.Provider
+		expRes   GPUData
+	}{
+		{
+			name:     "pass: read GPU data",
+			provider: "fake",
+			expRes: GPUData{
+				"a100": {
+					Model:    "a100",
+					MinWatts: 60,
+					MaxWatts: 400,
+				},
+			},
+		},
+		{
+			name:     "pass: GPU data file is optional",
+			provider: "fake2",
+			expRes:   GPUData{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ef := &EmissionFactors{Provider: test.provider}
+			err := ef.getGPUData(testDataPath)
+			assert.Nil(t, err)
+			assert.Equalf(t, test.expRes, ef.GPU, "Result should be: %v, got: %v", test.expRes, ef.GPU)
+		})
+	}
+}
+
+func TestGPUSpecs(t *testing.T) {
+	gpus := GPUData{
+		"a100": {
+			Model:    "a100",
+			MinWatts: 60,
+			MaxWatts: 400,
+		},
+	}
+
+	// provider data takes precedence over the defaults
+	specs, ok := gpus.Specs("nvidia-tesla-a100")
+	assert.True(t, ok)
+	assert.Equal(t, 60.0, specs.MinWatts)
+
+	// fall back to the default specs
+	specs, ok = gpus.Specs("T4")
+	assert.True(t, ok)
+	assert.Equal(t, 70.0, specs.MaxWatts)
+
+	// unknown models are not found
+	_, ok = gpus.Specs("unknown")
+	assert.False(t, ok)
+}
+
+func TestGetPUEData(t *testing.T) {
+	ef := &EmissionFactors{Provider: "fake"}
+	err := ef.getPUEData(testDataPath)
+	assert.Nil(t, err)
+	assert.Len(t, ef.PUE, 2)
+
+	// the PUE data file is optional
+	ef = &EmissionFactors{Provider: "fake2"}
+	err = ef.getPUEData(testDataPath)
+	assert.Nil(t, err)
+	assert.Empty(t, ef.PUE)
+}
+
+func TestResolvePUE(t *testing.T) {
+	ef := &EmissionFactors{
+		ProviderDefaults: &ProviderDefaults{AveragePUE: 1.125},
+		PUE: []PUE{
+			{Region: "us-east-1", PUE: 1.15},
+			{Region: "us-east-1", Zone: "us-east-1a", PUE: 1.08},
+		},
+	}
+
+	overrides := []PUE{
+		{Region: "eu-north-1", PUE: 1.2},
+		{Zone: "room-42", PUE: 1.4},
+	}
+
+	for _, test := range []struct {
+		name       string
+		region     string
+		datacenter string
+		zone       string
+		expPUE     float64
+		expLevel   string
+	}{
+		{
+			name:     "zone",
+			region:   "us-east-1",
+			zone:     "us-east-1a",
+			expPUE:   1.08,
+			expLevel: PUEZone,
+		},
+		{
+			name:     "zone falls back to region",
+			region:   "us-east-1",
+			zone:     "us-east-1b",
+			expPUE:   1.15,
+			expLevel: PUERegion,
+		},
+		{
+			name:     "region override",
+			region:   "eu-north-1",
+			zone:     "eu-north-1a",
+			expPUE:   1.2,
+			expLevel: PUERegion,
+		},
+		{
+			name:     "datacenter override without region",
+			region:   "on-prem",
+			zone:     "room-42",
+			expPUE:   1.4,
+			expLevel: PUEZone,
+		},
+		{
+			name:       "datacenter before zone",
+			region:     "us-east-1",
+			datacenter: "room-42",
+			zone:       "us-east-1a",
+			expPUE:     1.4,
+			expLevel:   PUEZone,
+		},
+		{
+			name:       "unknown datacenter falls back to zone",
+			region:     "us-east-1",
+			datacenter: "room-7",
+			zone:       "us-east-1a",
+			expPUE:     1.08,
+			expLevel:   PUEZone,
+		},
+		{
+			name:       "unknown datacenter and zone fall back to region",
+			region:     "us-east-1",
+			datacenter: "room-7",
+			zone:       "us-east-1b",
+			expPUE:     1.15,
+			expLevel:   PUERegion,
+		},
+		{
+			name:     "provider average",
+			region:   "us-west-2",
+			expPUE:   1.125,
+			expLevel: PUEProvider,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			pue, level := ef.ResolvePUE(overrides, test.region, test.datacenter, test.zone)
+			assert.Equal(t, test.expPUE, pue)
+			assert.Equal(t, test.expLevel, level)
+		})
+	}
+
+	// overrides take precedence over the provider data
+	pue, _ := ef.ResolvePUE([]PUE{{Region: "us-east-1", PUE: 1.3}}, "us-east-1")
+	assert.Equal(t, 1.3, pue)
+}
@@ -0,0 +1,52 @@
+package v1
+
+import "strings"
+
+// defaultGPUSpecs are the power curves of commonly used accelerators, used
+// when the provider dataset does not have specs for the GPU model.
+// The idle wattage is an approximation based on published measurements and
+// the max wattage is the TDP specified by the vendor for a single GPU.
+// Boards with multiple GPUs (K80, K520, M60) are split per GPU as that is
+// how cloud providers count them.
+var defaultGPUSpecs = GPUData{
+	"k520":            {Model: "k520", MinWatts: 20, MaxWatts: 112.5},
+	"k80":             {Model: "k80", MinWatts: 25, MaxWatts: 150},
+	"m60":             {Model: "m60", MinWatts: 25, MaxWatts: 150},
+	"p4":              {Model: "p4", MinWatts: 10, MaxWatts: 75},
+	"p100":            {Model: "p100", MinWatts: 30, MaxWatts: 250},
+	"v100":            {Model: "v100", MinWatts: 40, MaxWatts: 300},
+	"t4":              {Model: "t4", MinWatts: 10, MaxWatts: 70},
+	"a10g":            {Model: "a10g", MinWatts: 20, MaxWatts: 150},
+	"a100":            {Model: "a100", MinWatts: 50, MaxWatts: 400},
+	"a100-80gb":       {Model: "a100-80gb", MinWatts: 50, MaxWatts: 400},
+	"l4":              {Model: "l4", MinWatts: 15, MaxWatts: 72},
+	"h100":            {Model: "h100", MinWatts: 70, MaxWatts: 700},
+	"h100-80gb":       {Model: "h100-80gb", MinWatts: 70, MaxWatts: 700},
+	"radeon-pro-v520": {Model: "radeon-pro-v520", MinWatts: 20, MaxWatts: 225},
+}
+
+// Specs returns the power curve for a GPU model, looking it up in the
+// provider data first and falling back to the default specs
+func (g GPUData) Specs(model string) (GPUSpecs, bool) {
+	model = GPUModel(model)
+
+	if specs, ok := g[model]; ok {
+		return specs, true
+	}
+
+	specs, ok := defaultGPUSpecs[model]
+	return specs, ok
+}
+
+// GPUModel normalizes the different ways providers name accelerators, so
+// that they can be used as a lookup key.
+// Examples:
+// - nvidia-tesla-a100 (GCP) -> a100
+// - Tesla A100 (AWS) -> a100
+func GPUModel(name string) string {
+	model := strings.ToLower(strings.TrimSpace(name))
+	model = strings.ReplaceAll(model, " ", "-")
+	model = strings.TrimPrefix(model, "nvidia-")
+	model = strings.TrimPrefix(model, "tesla-")
+	return model
+}
@@ -0,0 +1,88 @@
+// Command instances derives the v2 instance datasets of the providers the
+// emissions-data repo has no v2 data for yet from their v1 datasets, so
+// that all providers have CPU and memory wattage curves.
+//
+// The CPU curve interpolates linearly between the min and max wattage per
+// vCPU of the architecture, as done by the CCF, and the memory curve is the
+// flat memory coefficient of the provider per GB. The curves are estimates,
+// not measurements like the Teads curves of the AWS dataset.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-yaml/yaml"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	factors "github.com/re-cinq/aether/pkg/types/v1/factors"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+)
+
+// the utilizations the curves have a wattage for, like the Teads curves
+var percentages = []int{0, 10, 50, 100}
+
+func main() {
+	dir := flag.String("data", "data", "the dataset directory with the v1 and v2 directories")
+	flag.Parse()
+
+	for _, provider := range []v1.Provider{v1.GCP, v1.Azure} {
+		if err := derive(provider, *dir); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// derive writes the {provider}-instances.yaml file of the provider
+func derive(provider v1.Provider, dir string) error {
+	ef, err := factors.ProviderEmissions(provider, filepath.Join(dir, "v1"))
+	if err != nil {
+		return fmt.Errorf("error loading emission factors of %s: %w", provider, err)
+	}
+
+	kinds := make([]string, 0, len(ef.Embodied))
+	for kind := range ef.Embodied {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	instances := make(map[string]data.Instance, len(kinds))
+	for _, kind := range kinds {
+		e := ef.Embodied[kind]
+		m := factors.MachineFromEmbodied(&e)
+
+		m.PkgWatt = curve(func(p float64) float64 {
+			return m.MinWatts + p*(m.MaxWatts-m.MinWatts)
+		})
+		m.RAMWatt = curve(func(float64) float64 {
+			// the coefficient is in kilowatt hours per GB
+			return ef.MemoryKilloWattHours * 1000
+		})
+
+		instances[kind] = m.Instance
+	}
+
+	b, err := yaml.Marshal(instances)
+	if err != nil {
+		return err
+	}
+
+	fp := filepath.Join(dir, "v2", fmt.Sprintf("%s-instances.yaml", provider))
+	return os.WriteFile(fp, b, 0o600)
+}
+
+// curve returns the wattage at the utilizations of the curves, the
+// function is passed the utilization as a fraction
+func curve(watts func(float64) float64) []data.Wattage {
+	c := make([]data.Wattage, len(percentages))
+	for i, p := range percentages {
+		c[i] = data.Wattage{
+			Percentage: p,
+			Wattage:    watts(float64(p) / 100),
+		}
+	}
+	return c
+}
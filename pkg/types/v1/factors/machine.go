@@ -0,0 +1,173 @@
+package v1
+
+import (
+	"math"
+
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+)
+
+// Machine is the emission factors of a machine type, built on the v2
+// instance schema of the emissions-data repo. It holds the wattage curves,
+// the share of the platform the machine type reserves and the embodied
+// emissions of the platform per component (Scope 3).
+type Machine struct {
+	data.Instance
+
+	// The min and max wattage of the CPU architecture, used when the
+	// machine type has no CPU wattage curve
+	MinWatts float64
+	MaxWatts float64
+}
+
+// MachineData are the machines of a provider keyed by machine type
+type MachineData map[string]Machine
+
+// HasWattageCurve returns true when the machine type has a measured
+// CPU wattage curve, instead of only the min and max wattage
+func (m *Machine) HasWattageCurve() bool {
+	for _, w := range m.PkgWatt {
+		if w.Wattage != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// MachineFromEmbodied adapts the v1 embodied emissions and machine specs
+// of a machine type to the v2 machine model
+func MachineFromEmbodied(e *Embodied) Machine {
+	return Machine{
+		Instance: data.Instance{
+			Kind:     e.MachineType,
+			VCPU:     int(math.Round(e.VCPU)),
+			MemoryGB: e.MemoryGB,
+			GPUCount: int(math.Round(e.GPU)),
+			Platform: data.Platform{
+				Architecture:  e.Architecture,
+				VCPU:          int(math.Round(e.TotalVCPU)),
+				MemoryGB:      e.TotalMemoryGB,
+				GPUCount:      int(math.Round(e.TotalGPU)),
+				MemoryScope3:  e.AdditionalMemoryKiloWattCO2e,
+				StorageScope3: e.AdditionalStorageKiloWattCO2e,
+				GPUScope3:     e.AdditionalGPUsKiloWattCO2e,
+				CPUScope3:     e.AdditionalCPUsKiloWattCO2e,
+				TotalScope3:   e.TotalEmbodiedKiloWattCO2e,
+			},
+		},
+		MinWatts: e.MinWatts,
+		MaxWatts: e.MaxWatts,
+	}
+}
+
+// joinMachines builds the machines of the provider from the v2 instance
+// data and the v1 embodied data, so both datasets are joined in one place.
+// The v2 data takes precedence, the v1 data only fills in what the v2
+// data does not have, and machine types in only one of the datasets
+// are used as they are. The machine types of the embodied overrides are
+// the exception, the fields they set take precedence over the v2 data.
+func joinMachines(ef *EmissionFactors, instances InstanceData) MachineData {
+	machines := make(MachineData, len(ef.Embodied))
+
+	for kind := range ef.Embodied {
+		e := ef.Embodied[kind]
+		machines[kind] = MachineFromEmbodied(&e)
+	}
+
+	for kind := range instances {
+		m, ok := machines[kind]
+		if !ok && ef.ProviderDefaults != nil {
+			m.MinWatts = ef.ProviderDefaults.MinWatts
+			m.MaxWatts = ef.ProviderDefaults.MaxWatts
+		}
+
+		joined := joinMachine(instances[kind], m)
+		if ef.overridden[kind] {
+			joined = overrideMachine(joined, m)
+		}
+		machines[kind] = joined
+	}
+
+	return machines
+}
+
+// joinMachine fills the gaps in the v2 instance data with the machine
+// adapted from the v1 data
+func joinMachine(i data.Instance, e Machine) Machine {
+	m := Machine{
+		Instance: i,
+		MinWatts: e.MinWatts,
+		MaxWatts: e.MaxWatts,
+	}
+
+	if m.VCPU == 0 {
+		m.VCPU = e.VCPU
+	}
+	if m.MemoryGB == 0 {
+		m.MemoryGB = e.MemoryGB
+	}
+	if m.GPUCount == 0 {
+		m.GPUCount = e.GPUCount
+	}
+	if m.Platform.VCPU == 0 {
+		m.Platform.VCPU = e.Platform.VCPU
+	}
+	if m.Platform.MemoryGB == 0 {
+		m.Platform.MemoryGB = e.Platform.MemoryGB
+	}
+	if m.Platform.GPUCount == 0 {
+		m.Platform.GPUCount = e.Platform.GPUCount
+	}
+	if m.Platform.Architecture == "" {
+		m.Platform.Architecture = e.Platform.Architecture
+	}
+
+	// the embodied emissions are only taken as a whole, so
+	// the components always add up to the total
+	if m.Platform.TotalScope3 == 0 {
+		m.Platform.MemoryScope3 = e.Platform.MemoryScope3
+		m.Platform.StorageScope3 = e.Platform.StorageScope3
+		m.Platform.GPUScope3 = e.Platform.GPUScope3
+		m.Platform.CPUScope3 = e.Platform.CPUScope3
+		m.Platform.TotalScope3 = e.Platform.TotalScope3
+	}
+
+	return m
+}
+
+// overrideMachine replaces the fields of the joined machine with the
+// fields the override of the machine type sets
+func overrideMachine(m, o Machine) Machine {
+	if o.VCPU != 0 {
+		m.VCPU = o.VCPU
+	}
+	if o.MemoryGB != 0 {
+		m.MemoryGB = o.MemoryGB
+	}
+	if o.GPUCount != 0 {
+		m.GPUCount = o.GPUCount
+	}
+	if o.Platform.VCPU != 0 {
+		m.Platform.VCPU = o.Platform.VCPU
+	}
+	if o.Platform.MemoryGB != 0 {
+		m.Platform.MemoryGB = o.Platform.MemoryGB
+	}
+	if o.Platform.GPUCount != 0 {
+		m.Platform.GPUCount = o.Platform.GPUCount
+	}
+	if o.Platform.Architecture != "" {
+		m.Platform.Architecture = o.Platform.Architecture
+	}
+
+	// the embodied emissions are only taken as a whole, so
+	// the components always add up to the total
+	if o.Platform.TotalScope3 != 0 {
+		m.Platform.MemoryScope3 = o.Platform.MemoryScope3
+		m.Platform.StorageScope3 = o.Platform.StorageScope3
+		m.Platform.GPUScope3 = o.Platform.GPUScope3
+		m.Platform.CPUScope3 = o.Platform.CPUScope3
+		m.Platform.TotalScope3 = o.Platform.TotalScope3
+	}
+
+	return m
+}
@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"testing"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMachineFromEmbodied(t *testing.T) {
+	m := MachineFromEmbodied(&Embodied{
+		MachineType:                   "n2-standard-8",
+		AdditionalMemoryKiloWattCO2e:  200,
+		AdditionalStorageKiloWattCO2e: 50,
+		AdditionalCPUsKiloWattCO2e:    100,
+		AdditionalGPUsKiloWattCO2e:    150,
+		TotalEmbodiedKiloWattCO2e:     1500,
+		VCPU:                          8,
+		TotalVCPU:                     80,
+		GPU:                           1,
+		TotalGPU:                      4,
+		MemoryGB:                      32,
+		TotalMemoryGB:                 640,
+		Architecture:                  "Cascade Lake",
+		MachineSpecs:                  MachineSpecs{MinWatts: 0.64, MaxWatts: 4.0},
+	})
+
+	assert.Equal(t, "n2-standard-8", m.Kind)
+	assert.Equal(t, 8, m.VCPU)
+	assert.Equal(t, 32.0, m.MemoryGB)
+	assert.Equal(t, 1, m.GPUCount)
+	assert.Equal(t, data.Platform{
+		Architecture:  "Cascade Lake",
+		VCPU:          80,
+		MemoryGB:      640,
+		GPUCount:      4,
+		MemoryScope3:  200,
+		StorageScope3: 50,
+		GPUScope3:     150,
+		CPUScope3:     100,
+		TotalScope3:   1500,
+	}, m.Platform)
+	assert.Equal(t, 0.64, m.MinWatts)
+	assert.Equal(t, 4.0, m.MaxWatts)
+	assert.False(t, m.HasWattageCurve())
+}
+
+func TestJoinMachines(t *testing.T) {
+	ef := &EmissionFactors{
+		Embodied: EmbodiedData{
+			"m5.large": {
+				MachineType:               "m5.large",
+				TotalEmbodiedKiloWattCO2e: 1610.79,
+				TotalVCPU:                 96,
+				MachineSpecs:              MachineSpecs{MinWatts: 0.7, MaxWatts: 3.6},
+			},
+			"a1.large": {
+				MachineType:               "a1.large",
+				TotalEmbodiedKiloWattCO2e: 1200,
+				VCPU:                      2,
+			},
+		},
+		ProviderDefaults: &ProviderDefaults{MinWatts: 0.74, MaxWatts: 3.5},
+	}
+	instances := InstanceData{
+		"m5.large": {
+			Kind:     "m5.large",
+			VCPU:     2,
+			MemoryGB: 8,
+			PkgWatt:  []data.Wattage{{Percentage: 0, Wattage: 1.21}, {Percentage: 100, Wattage: 9.96}},
+			Platform: data.Platform{MemoryScope3: 510.4, CPUScope3: 100, TotalScope3: 1610.4},
+		},
+		"m7i.large": {
+			Kind: "m7i.large",
+			VCPU: 2,
+		},
+	}
+
+	machines := joinMachines(ef, instances)
+	assert.Len(t, machines, 3)
+
+	// the v2 data takes precedence and the v1 data fills the gaps
+	m := machines["m5.large"]
+	assert.Equal(t, 2, m.VCPU)
+	assert.Equal(t, 96, m.Platform.VCPU)
+	assert.Equal(t, 1610.4, m.Platform.TotalScope3)
+	assert.Equal(t, 510.4, m.Platform.MemoryScope3)
+	assert.Equal(t, 0.7, m.MinWatts)
+	assert.True(t, m.HasWattageCurve())
+
+	// machine types only in the v1 data are adapted
+	m = machines["a1.large"]
+	assert.Equal(t, 2, m.VCPU)
+	assert.Equal(t, 1200.0, m.Platform.TotalScope3)
+	assert.False(t, m.HasWattageCurve())
+
+	// machine types only in the v2 data use the provider defaults
+	m = machines["m7i.large"]
+	assert.Equal(t, 0.74, m.MinWatts)
+	assert.Equal(t, 3.5, m.MaxWatts)
+	assert.Equal(t, 0.0, m.Platform.TotalScope3)
+
+	// the embedded snapshot joins the AWS datasets
+	dir, err := LoadFactorsData("")
+	assert.Nil(t, err)
+	d, err := LoadDataset("snapshot", dir.DataPath, dir.InstanceDataPath)
+	assert.Nil(t, err)
+	m = d.Providers[v1.AWS].Machines["m5.large"]
+	assert.Equal(t, 2, m.VCPU)
+	assert.Equal(t, 1610.4, m.Platform.TotalScope3)
+	assert.True(t, m.HasWattageCurve())
+}
@@ -0,0 +1,347 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/re-cinq/aether/pkg/log"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+)
+
+// the suffixes of the dataset files that can be overridden
+var overrideFiles = []string{
+	"default",
+	"use",
+	"grid",
+	"embodied",
+	"gpu",
+	"pue",
+	"instances",
+}
+
+// ApplyOverrides merges the datasets in the directory on top of the dataset.
+// The files use the same schema as the upstream datasets, for example
+// {provider}-embodied.yaml or {provider}-instances.yaml, and only need to
+// contain the entries that are added or overridden. The overrides take
+// precedence per entry:
+//
+//   - default: each field set in the file replaces the upstream field
+//   - use: the machine specs of an architecture replace the upstream specs,
+//     also for the upstream machine types of that architecture
+//   - grid: the grid intensity of a region replaces the upstream intensity
+//   - embodied: a machine type replaces the upstream machine type, and the
+//     fields it sets take precedence over the upstream v2 instance
+//   - gpu: the specs of a GPU model replace the upstream specs
+//   - pue: the PUE of a region or zone replaces the upstream PUE
+//   - instances: a v2 instance replaces the upstream instance
+//
+// Every upstream entry that is replaced is logged. Providers that are only
+// in the overrides are added to the dataset.
+func (d *Dataset) ApplyOverrides(ctx context.Context, dir string) error {
+	providers, err := overrideProviders(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, provider := range providers {
+		ef, ok := d.Providers[provider]
+		if !ok {
+			ef = &EmissionFactors{
+				Provider:    provider,
+				Coefficient: make(CoefficientData),
+				Embodied:    make(EmbodiedData),
+				GPU:         make(GPUData),
+			}
+			d.Providers[provider] = ef
+		}
+
+		o := &overrides{
+			logger:   log.FromContext(ctx).With("provider", provider, "overrides", dir),
+			dir:      dir,
+			provider: provider,
+		}
+
+		if err := o.apply(ef, d); err != nil {
+			return fmt.Errorf("error applying overrides of %s: %w", provider, err)
+		}
+
+		ef.Machines = joinMachines(ef, d.Instances[provider])
+	}
+
+	return nil
+}
+
+// overrideProviders returns the providers that have
+// dataset files in the overrides directory
+func overrideProviders(dir string) ([]v1.Provider, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[v1.Provider]bool)
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".yaml")
+		for _, suffix := range overrideFiles {
+			if p, ok := strings.CutSuffix(name, "-"+suffix); ok && p != "" {
+				found[v1.Provider(p)] = true
+			}
+		}
+	}
+
+	providers := make([]v1.Provider, 0, len(found))
+	for p := range found {
+		providers = append(providers, p)
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i] < providers[j] })
+
+	return providers, nil
+}
+
+// overrides reads the override files of a provider
+type overrides struct {
+	logger   *slog.Logger
+	dir      string
+	provider v1.Provider
+}
+
+// read reads the optional override file, it returns false
+// when the provider does not override the dataset
+func (o *overrides) read(suffix string, data interface{}) (bool, error) {
+	fp := filepath.Join(o.dir, fmt.Sprintf("%s-%s.yaml", o.provider, suffix))
+	err := readYamlData(fp, data)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (o *overrides) apply(ef *EmissionFactors, d *Dataset) error {
+	if err := o.defaults(ef); err != nil {
+		return err
+	}
+
+	specs, err := o.use(ef)
+	if err != nil {
+		return err
+	}
+
+	if err := o.grid(ef); err != nil {
+		return err
+	}
+
+	if err := o.embodied(ef, specs); err != nil {
+		return err
+	}
+
+	if err := o.gpu(ef); err != nil {
+		return err
+	}
+
+	if err := o.pue(ef); err != nil {
+		return err
+	}
+
+	return o.instances(d)
+}
+
+func (o *overrides) defaults(ef *EmissionFactors) error {
+	defaults := &ProviderDefaults{}
+	if ef.ProviderDefaults != nil {
+		// copy the upstream defaults, so only the fields
+		// in the file are replaced
+		*defaults = *ef.ProviderDefaults
+	}
+
+	ok, err := o.read("default", defaults)
+	if !ok || err != nil {
+		return err
+	}
+
+	if ef.ProviderDefaults != nil {
+		o.logger.Info("overriding provider defaults")
+	}
+	ef.ProviderDefaults = defaults
+	return nil
+}
+
+func (o *overrides) use(ef *EmissionFactors) (MachineSpecsData, error) {
+	data := []MachineSpecs{}
+	specs := make(MachineSpecsData)
+
+	ok, err := o.read("use", &data)
+	if !ok || err != nil {
+		return specs, err
+	}
+
+	for _, s := range data {
+		// an empty architecture is used for the provider defaults
+		if s.Architecture == "" {
+			continue
+		}
+		specs[s.Architecture] = s
+
+		// the upstream machine types of the architecture use the new specs
+		for kind, e := range ef.Embodied {
+			if e.MachineSpecs.Architecture != s.Architecture {
+				continue
+			}
+			o.logger.Info("overriding machine specs", "architecture", s.Architecture, "kind", kind)
+			e.MachineSpecs = s
+			ef.Embodied[kind] = e
+		}
+	}
+
+	return specs, nil
+}
+
+func (o *overrides) grid(ef *EmissionFactors) error {
+	data := []Coefficient{}
+
+	ok, err := o.read("grid", &data)
+	if !ok || err != nil {
+		return err
+	}
+
+	for _, c := range data {
+		if old, ok := ef.Coefficient[c.Region]; ok {
+			o.logger.Info("overriding grid intensity", "region", c.Region, "upstream", old, "override", c.Co2e)
+		}
+		ef.Coefficient[c.Region] = c.Co2e
+	}
+
+	return nil
+}
+
+func (o *overrides) embodied(ef *EmissionFactors, specs MachineSpecsData) error {
+	data := []Embodied{}
+
+	ok, err := o.read("embodied", &data)
+	if !ok || err != nil {
+		return err
+	}
+
+	for _, e := range data {
+		s, ok := machineSpecs(ef, specs, e.Architecture)
+		if !ok {
+			if ef.ProviderDefaults == nil {
+				return fmt.Errorf("error: machine specifications for architecture (%s) does not exist nor do provider defaults", e.Architecture)
+			}
+			s = MachineSpecs{
+				MinWatts: ef.MinWatts,
+				MaxWatts: ef.MaxWatts,
+			}
+		}
+		e.MachineSpecs = s
+
+		if _, ok := ef.Embodied[e.MachineType]; ok {
+			o.logger.Info("overriding machine type", "kind", e.MachineType)
+		}
+		ef.Embodied[e.MachineType] = e
+
+		if ef.overridden == nil {
+			ef.overridden = make(map[string]bool)
+		}
+		ef.overridden[e.MachineType] = true
+	}
+
+	return nil
+}
+
+// machineSpecs returns the specs of the architecture from the overrides,
+// or from the upstream machine types of the architecture
+func machineSpecs(ef *EmissionFactors, specs MachineSpecsData, architecture string) (MachineSpecs, bool) {
+	if s, ok := specs[architecture]; ok {
+		return s, true
+	}
+
+	for _, e := range ef.Embodied {
+		if architecture != "" && e.MachineSpecs.Architecture == architecture {
+			return e.MachineSpecs, true
+		}
+	}
+
+	return MachineSpecs{}, false
+}
+
+func (o *overrides) gpu(ef *EmissionFactors) error {
+	data := []GPUSpecs{}
+
+	ok, err := o.read("gpu", &data)
+	if !ok || err != nil {
+		return err
+	}
+
+	for _, g := range data {
+		g.Model = GPUModel(g.Model)
+		if _, ok := ef.GPU[g.Model]; ok {
+			o.logger.Info("overriding GPU specs", "model", g.Model)
+		}
+		ef.GPU[g.Model] = g
+	}
+
+	return nil
+}
+
+func (o *overrides) pue(ef *EmissionFactors) error {
+	data := []PUE{}
+
+	ok, err := o.read("pue", &data)
+	if !ok || err != nil {
+		return err
+	}
+
+	for _, p := range data {
+		found := false
+		for i := range ef.PUE {
+			if ef.PUE[i].Region == p.Region && ef.PUE[i].Zone == p.Zone {
+				o.logger.Info("overriding PUE", "region", p.Region, "zone", p.Zone, "upstream", ef.PUE[i].PUE, "override", p.PUE)
+				ef.PUE[i] = p
+				found = true
+			}
+		}
+
+		if !found {
+			ef.PUE = append(ef.PUE, p)
+		}
+	}
+
+	return nil
+}
+
+func (o *overrides) instances(d *Dataset) error {
+	data := make(InstanceData)
+
+	ok, err := o.read("instances", &data)
+	if !ok || err != nil {
+		return err
+	}
+
+	instances, exists := d.Instances[o.provider]
+	if !exists {
+		instances = make(InstanceData)
+		d.Instances[o.provider] = instances
+	}
+
+	for kind, i := range data {
+		if _, ok := instances[kind]; ok {
+			o.logger.Info("overriding instance", "kind", kind)
+		}
+		instances[kind] = i
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package v1
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	d, err := LoadDataset("test", testDataPath, testDataPath)
+	assert.Nil(t, err)
+
+	err = d.ApplyOverrides(context.TODO(), filepath.Join(testDataPath, "overrides"))
+	assert.Nil(t, err)
+	assert.Nil(t, d.Validate())
+
+	ef := d.Providers["fake"]
+
+	// only the fields in the file replace the defaults
+	assert.Equal(t, 1.3, ef.AveragePUE)
+	assert.Equal(t, 0.71, ef.MinWatts)
+
+	// regions are replaced and added
+	assert.Equal(t, 0.0004, ef.Coefficient["us-east1"])
+	assert.Equal(t, 0.0002, ef.Coefficient["on-prem-1"])
+	assert.Equal(t, 0.000479, ef.Coefficient["us-central1"])
+
+	// the machine specs of an architecture are replaced for upstream types
+	assert.Equal(t, 0.8, ef.Embodied["n1-standard-2"].MinWatts)
+	assert.Equal(t, 1677.48, ef.Embodied["n1-standard-2"].TotalEmbodiedKiloWattCO2e)
+
+	// machine types are replaced and added, using the specs of their
+	// architecture from the overrides or upstream
+	assert.Equal(t, 1300.0, ef.Embodied["e2-standard-2"].TotalEmbodiedKiloWattCO2e)
+	assert.Equal(t, 0.6446044454253452, ef.Embodied["e2-standard-2"].MinWatts)
+	assert.Equal(t, 2.5, ef.Embodied["private-standard-8"].MaxWatts)
+	assert.Equal(t, 8.0, ef.Embodied["private-standard-8"].VCPU)
+
+	// zones are kept and regions are replaced and added
+	assert.Equal(t, []PUE{
+		{Region: "us-east-1", PUE: 1.2},
+		{Region: "us-east-1", Zone: "us-east-1a", PUE: 1.08},
+		{Region: "on-prem-1", PUE: 1.4},
+	}, ef.PUE)
+
+	assert.Equal(t, 8, d.Instances["fake"]["private-standard-8"].VCPU)
+
+	// providers that are only in the overrides are added
+	private := d.Providers["private"]
+	assert.Equal(t, v1.Provider("private"), private.Provider)
+	assert.Equal(t, 1.5, private.AveragePUE)
+	assert.Equal(t, 0.0003, private.Coefficient["basement"])
+	assert.Equal(t, 3.2, private.Embodied["rack-1"].MaxWatts)
+	assert.Equal(t, "", private.Embodied["rack-1"].MachineSpecs.Architecture)
+
+	err = d.ApplyOverrides(context.TODO(), filepath.Join(testDataPath, "missing"))
+	assert.NotNil(t, err)
+}
+
+func TestOverrideUpstreamMachine(t *testing.T) {
+	embedded, err := LoadFactorsData("")
+	assert.Nil(t, err)
+	d, err := LoadDataset("snapshot", embedded.DataPath, embedded.InstanceDataPath)
+	assert.Nil(t, err)
+
+	// the v2 data of the machine type has embodied emissions
+	m := d.Providers[v1.AWS].Machines["m5.large"]
+	assert.Equal(t, 1610.4, m.Platform.TotalScope3)
+
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, "aws-embodied.yaml"), []byte(`
+- type: m5.large
+  additionalmemory: 300
+  additionalstorage: 0
+  additionalcpus: 100
+  additionalgpus: 0
+  total: 1400
+  architecture: ""
+`), 0o600)
+	assert.Nil(t, err)
+
+	err = d.ApplyOverrides(context.TODO(), dir)
+	assert.Nil(t, err)
+
+	// the override takes precedence over the v2 data
+	m = d.Providers[v1.AWS].Machines["m5.large"]
+	assert.Equal(t, 1400.0, m.Platform.TotalScope3)
+	assert.Equal(t, 300.0, m.Platform.MemoryScope3)
+	assert.Equal(t, 2, m.VCPU)
+	assert.True(t, m.HasWattageCurve())
+
+	// other machine types keep the v2 data
+	assert.Equal(t, d.Instances[v1.AWS]["m5.xlarge"].Platform.TotalScope3,
+		d.Providers[v1.AWS].Machines["m5.xlarge"].Platform.TotalScope3)
+}
+
+func TestOverrideProviders(t *testing.T) {
+	providers, err := overrideProviders(filepath.Join(testDataPath, "overrides"))
+	assert.Nil(t, err)
+	assert.Equal(t, []v1.Provider{"fake", "private"}, providers)
+
+	providers, err = overrideProviders(testDataPath)
+	assert.Nil(t, err)
+	assert.Equal(t, []v1.Provider{"bad", "fake", "fake2"}, providers)
+}
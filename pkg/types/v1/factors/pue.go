@@ -0,0 +1,47 @@
+package v1
+
+const (
+	// PUEZone is a PUE measured for the zone or datacenter
+	PUEZone = "zone"
+
+	// PUERegion is a PUE published for the region
+	PUERegion = "region"
+
+	// PUEProvider is the average PUE of the provider
+	PUEProvider = "provider"
+)
+
+// ResolvePUE returns the most specific PUE for the zones and region,
+// going from the zones to the region and finally the average PUE of the
+// provider. The zones are tried from the most to the least specific, like
+// the datacenter of the instance and the zone it runs in. The overrides
+// take precedence over the PUE data of the provider. It also returns the
+// level the PUE was resolved at.
+func (ef *EmissionFactors) ResolvePUE(overrides []PUE, region string, zones ...string) (float64, string) {
+	all := make([]PUE, 0, len(overrides)+len(ef.PUE))
+	all = append(all, overrides...)
+	all = append(all, ef.PUE...)
+
+	for _, zone := range zones {
+		if zone == "" {
+			continue
+		}
+		for _, p := range all {
+			if p.Zone == zone && (p.Region == "" || p.Region == region) && p.PUE > 0 {
+				return p.PUE, PUEZone
+			}
+		}
+	}
+
+	for _, p := range all {
+		if p.Zone == "" && p.Region == region && p.PUE > 0 {
+			return p.PUE, PUERegion
+		}
+	}
+
+	var avg float64
+	if ef.ProviderDefaults != nil {
+		avg = ef.AveragePUE
+	}
+	return avg, PUEProvider
+}
@@ -0,0 +1,212 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/re-cinq/aether/pkg/log"
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	data "github.com/re-cinq/emissions-data/pkg/types/v2"
+)
+
+// InstanceData are the v2 instance datasets keyed by machine type
+type InstanceData map[string]data.Instance
+
+// Dataset is a snapshot of the emission factors of all providers. It is
+// shared by all calculations, so it must not be modified once loaded
+type Dataset struct {
+	// The version of the datasets the snapshot was loaded from
+	Version string
+
+	// The emission factors keyed by provider
+	Providers map[v1.Provider]*EmissionFactors
+
+	// The v2 instance datasets keyed by provider, which not all providers
+	// have yet. They are joined into the machines of the providers.
+	Instances map[v1.Provider]InstanceData
+}
+
+// LoadDataset reads the emission factors of all providers that have
+// datasets in the v1 directory, and their v2 instance datasets
+func LoadDataset(version, dataPath, instanceDataPath string) (*Dataset, error) {
+	files, err := filepath.Glob(filepath.Join(dataPath, "*-default.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dataset{
+		Version:   version,
+		Providers: make(map[v1.Provider]*EmissionFactors),
+		Instances: make(map[v1.Provider]InstanceData),
+	}
+
+	for _, f := range files {
+		provider := v1.Provider(strings.TrimSuffix(filepath.Base(f), "-default.yaml"))
+
+		ef, err := ProviderEmissions(provider, dataPath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading emission factors of %s: %w", provider, err)
+		}
+		d.Providers[provider] = ef
+
+		instances, err := getInstanceData(provider, instanceDataPath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading instance data of %s: %w", provider, err)
+		}
+		if instances != nil {
+			d.Instances[provider] = instances
+		}
+
+		ef.Machines = joinMachines(ef, instances)
+	}
+
+	return d, nil
+}
+
+// getInstanceData reads the optional v2 {provider}-instances.yaml file
+func getInstanceData(provider v1.Provider, instanceDataPath string) (InstanceData, error) {
+	instances := make(InstanceData)
+
+	fp := filepath.Join(instanceDataPath, fmt.Sprintf("%s-instances.yaml", provider))
+	err := readYamlData(fp, &instances)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return instances, nil
+}
+
+// Validate checks that the dataset has the emission factors needed
+// for the calculations of each provider
+func (d *Dataset) Validate() error {
+	if len(d.Providers) == 0 {
+		return errors.New("error dataset has no providers")
+	}
+
+	for provider, ef := range d.Providers {
+		if ef.ProviderDefaults == nil {
+			return fmt.Errorf("error dataset has no defaults for %s", provider)
+		}
+
+		if len(ef.Coefficient) == 0 {
+			return fmt.Errorf("error dataset has no grid intensity for %s", provider)
+		}
+
+		if len(ef.Machines) == 0 {
+			return fmt.Errorf("error dataset has no machine types for %s", provider)
+		}
+	}
+
+	return nil
+}
+
+// FactorRegistry holds the emission factors of all providers in memory,
+// so they are read once instead of for every calculation. The dataset is
+// refreshed in the background and swapped atomically, so readers always
+// see a complete and valid dataset.
+type FactorRegistry struct {
+	dataset atomic.Pointer[Dataset]
+
+	// load reads a new snapshot of the datasets
+	load func() (*Dataset, error)
+
+	// refresh is locked while a new snapshot is loaded, as the
+	// datasets are read from the same directory on disk
+	refresh sync.Mutex
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewFactorRegistry returns a registry with the dataset returned by the
+// loader, which is also used to refresh the dataset
+func NewFactorRegistry(load func() (*Dataset, error)) (*FactorRegistry, error) {
+	r := &FactorRegistry{
+		load: load,
+		stop: make(chan struct{}),
+	}
+
+	if err := r.Refresh(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Dataset returns the current snapshot of the emission factors
+func (r *FactorRegistry) Dataset() *Dataset {
+	return r.dataset.Load()
+}
+
+// Version returns the version of the current dataset
+func (r *FactorRegistry) Version() string {
+	return r.Dataset().Version
+}
+
+// Refresh loads and validates a new snapshot of the datasets and swaps it
+// with the current one. The current dataset is kept when the new one is
+// not valid.
+func (r *FactorRegistry) Refresh() error {
+	r.refresh.Lock()
+	defer r.refresh.Unlock()
+
+	d, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	if err := d.Validate(); err != nil {
+		return err
+	}
+
+	r.dataset.Store(d)
+	return nil
+}
+
+// Start refreshes the dataset in the background at the
+// interval, until the context is done or Stop is called
+func (r *FactorRegistry) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	logger := log.FromContext(ctx)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				old := r.Version()
+				if err := r.Refresh(); err != nil {
+					logger.Error("failed refreshing emission factors", "error", err, "version", old)
+					continue
+				}
+				logger.Debug("refreshed emission factors", "version", r.Version(), "previous", old)
+			}
+		}
+	}()
+}
+
+// Stop stops refreshing the dataset
+func (r *FactorRegistry) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+}
@@ -0,0 +1,127 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/re-cinq/aether/pkg/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDataset(t *testing.T) {
+	d, err := LoadDataset("test", testDataPath, testDataPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "test", d.Version)
+
+	// only the fake provider has defaults in the test data
+	assert.Len(t, d.Providers, 1)
+	assert.Equal(t, 0.71, d.Providers["fake"].MinWatts)
+	assert.Empty(t, d.Instances)
+	assert.Nil(t, d.Validate())
+
+	// the embedded snapshot has the v2 instance data of all providers
+	dir, err := LoadFactorsData("")
+	assert.Nil(t, err)
+	d, err = LoadDataset("snapshot", dir.DataPath, dir.InstanceDataPath)
+	assert.Nil(t, err)
+	assert.Nil(t, d.Validate())
+	assert.Contains(t, d.Providers, v1.GCP)
+	assert.Equal(t, 2, d.Instances[v1.AWS]["t3.micro"].VCPU)
+	assert.Contains(t, d.Instances, v1.GCP)
+
+	_, err = LoadDataset("missing", filepath.Join(t.TempDir(), "missing"), "")
+	assert.Nil(t, err)
+}
+
+func TestValidateDataset(t *testing.T) {
+	valid := &EmissionFactors{
+		ProviderDefaults: &ProviderDefaults{},
+		Coefficient:      CoefficientData{"us-east1": 0.0005},
+		Machines:         MachineData{"n2-standard-2": {}},
+	}
+
+	assert.EqualError(t, (&Dataset{}).Validate(), "error dataset has no providers")
+	assert.Nil(t, (&Dataset{Providers: map[v1.Provider]*EmissionFactors{v1.GCP: valid}}).Validate())
+
+	for _, test := range []struct {
+		ef     *EmissionFactors
+		expErr string
+	}{
+		{
+			ef:     &EmissionFactors{Coefficient: valid.Coefficient, Machines: valid.Machines},
+			expErr: "error dataset has no defaults for gcp",
+		},
+		{
+			ef:     &EmissionFactors{ProviderDefaults: valid.ProviderDefaults, Machines: valid.Machines},
+			expErr: "error dataset has no grid intensity for gcp",
+		},
+		{
+			ef:     &EmissionFactors{ProviderDefaults: valid.ProviderDefaults, Coefficient: valid.Coefficient},
+			expErr: "error dataset has no machine types for gcp",
+		},
+	} {
+		d := &Dataset{Providers: map[v1.Provider]*EmissionFactors{v1.GCP: test.ef}}
+		assert.EqualError(t, d.Validate(), test.expErr)
+	}
+}
+
+func TestFactorRegistry(t *testing.T) {
+	var (
+		version = "v1"
+		loadErr error
+	)
+
+	r, err := NewFactorRegistry(func() (*Dataset, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		return LoadDataset(version, testDataPath, testDataPath)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", r.Version())
+	current := r.Dataset()
+
+	// a dataset that fails to load is not swapped
+	loadErr = errors.New("error loading")
+	assert.EqualError(t, r.Refresh(), "error loading")
+	assert.Equal(t, current, r.Dataset())
+
+	// a dataset that is not valid is not swapped
+	loadErr = nil
+	r.load = func() (*Dataset, error) { return &Dataset{Version: "empty"}, nil }
+	assert.EqualError(t, r.Refresh(), "error dataset has no providers")
+	assert.Equal(t, "v1", r.Version())
+
+	r.load = func() (*Dataset, error) { return LoadDataset(version, testDataPath, testDataPath) }
+	version = "v2"
+	assert.Nil(t, r.Refresh())
+	assert.Equal(t, "v2", r.Version())
+
+	// the dataset of readers is not modified by the swap
+	assert.Equal(t, "v1", current.Version)
+
+	_, err = NewFactorRegistry(func() (*Dataset, error) { return &Dataset{}, nil })
+	assert.EqualError(t, err, "error dataset has no providers")
+}
+
+func TestFactorRegistryStart(t *testing.T) {
+	refreshed := make(chan struct{}, 10)
+	r, err := NewFactorRegistry(func() (*Dataset, error) {
+		refreshed <- struct{}{}
+		return LoadDataset("v1", testDataPath, testDataPath)
+	})
+	assert.Nil(t, err)
+	<-refreshed
+
+	r.Start(context.TODO(), 10*time.Millisecond)
+	defer r.Stop()
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("dataset was not refreshed")
+	}
+}
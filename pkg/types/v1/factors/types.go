@@ -5,12 +5,20 @@ import v1 "github.com/re-cinq/aether/pkg/types/v1"
 type CoefficientData map[string]float64       // map[region] = co2e
 type EmbodiedData map[string]Embodied         // key = Machine type (n2-standard-
 type MachineSpecsData map[string]MachineSpecs // key = architecture name (Haswell, Skylake, ..)
+type GPUData map[string]GPUSpecs              // key = normalized GPU model (a100, t4, ..)
 
 type EmissionFactors struct {
 	Provider    v1.Provider
 	Coefficient CoefficientData // key is region
 	Embodied    EmbodiedData    // key is machineType
+	GPU         GPUData         // key is GPU model
+	PUE         []PUE           // zone and region specific PUE
+	Machines    MachineData     // key is machineType, joins the v1 and v2 data
 	*ProviderDefaults
+
+	// the machine types of the embodied overrides, which take
+	// precedence over the upstream v2 instance data
+	overridden map[string]bool
 }
 
 type Coefficient struct {
@@ -29,7 +37,6 @@ type Coefficient struct {
 // Based on Dell PowerEdge R740 Life-Cycle Assessment
 // = 533 kgCO₂eq for 12*32GB DIMMs Memory (384 GB).
 
-// TODO: Need to add machine lifespan for embodied carbon
 type Embodied struct {
 	MachineType                   string  `yaml:"type"`
 	AdditionalMemoryKiloWattCO2e  float64 `yaml:"additionalmemory"`
@@ -37,8 +44,12 @@ type Embodied struct {
 	AdditionalCPUsKiloWattCO2e    float64 `yaml:"additionalcpus"`
 	AdditionalGPUsKiloWattCO2e    float64 `yaml:"additionalgpus"`
 	TotalEmbodiedKiloWattCO2e     float64 `yaml:"total"`
-	VCPU                          float64 `yaml:"vCPU"`      // vCPU count for instance Type
-	TotalVCPU                     float64 `yaml:"totalVCPU"` // Highest amount of vCPUs in machine type family
+	VCPU                          float64 `yaml:"vCPU"`          // vCPU count for instance Type
+	TotalVCPU                     float64 `yaml:"totalVCPU"`     // Highest amount of vCPUs in machine type family
+	GPU                           float64 `yaml:"gpus"`          // GPU count for instance Type
+	TotalGPU                      float64 `yaml:"totalGPUs"`     // GPU count of the platform the instance type runs on
+	MemoryGB                      float64 `yaml:"memoryGB"`      // Memory of the instance type
+	TotalMemoryGB                 float64 `yaml:"totalMemoryGB"` // Memory of the platform the instance type runs on
 	Architecture                  string
 	MachineSpecs
 }
@@ -50,6 +61,22 @@ type MachineSpecs struct {
 	GBPerChip    float64 `yaml:"chip"`
 }
 
+// GPUSpecs is the power curve of an accelerator, going from the idle
+// wattage at 0% utilization to the thermal design power at 100%
+type GPUSpecs struct {
+	Model    string
+	MinWatts float64 `yaml:"minWatts"`
+	MaxWatts float64 `yaml:"maxWatts"`
+}
+
+// PUE is the Power Usage Effectiveness of a region, or of a zone or
+// datacenter within the region when the zone is set
+type PUE struct {
+	Region string  `yaml:"region"`
+	Zone   string  `yaml:"zone"`
+	PUE    float64 `yaml:"pue"`
+}
+
 type ProviderDefaults struct {
 	Provider                 string  `yaml:"name"`
 	MinWatts                 float64 `yaml:"minWatts"`
@@ -59,4 +86,30 @@ type ProviderDefaults struct {
 	NetworkingKilloWattHours float64 `yaml:"networkingKilloWattHours"`
 	MemoryKilloWattHours     float64 `yaml:"memoryKilloWattHours"`
 	AveragePUE               float64 `yaml:"averagePUE"`
+
+	// Optional networking coefficients per network scope, when not
+	// set NetworkingKilloWattHours is used
+	NetworkingIntraZoneKilloWattHours   float64 `yaml:"networkingIntraZoneKilloWattHours"`
+	NetworkingInterRegionKilloWattHours float64 `yaml:"networkingInterRegionKilloWattHours"`
+	NetworkingInternetKilloWattHours    float64 `yaml:"networkingInternetKilloWattHours"`
+}
+
+// NetworkingCoefficient returns the energy in kilowatt hours per gigabyte
+// transferred for the network scope, falling back to the provider wide
+// networking coefficient when the scope has no coefficient of its own
+func (pd *ProviderDefaults) NetworkingCoefficient(scope string) float64 {
+	var c float64
+	switch scope {
+	case v1.NetworkScopeIntraZone:
+		c = pd.NetworkingIntraZoneKilloWattHours
+	case v1.NetworkScopeInterRegion:
+		c = pd.NetworkingInterRegionKilloWattHours
+	case v1.NetworkScopeInternet:
+		c = pd.NetworkingInternetKilloWattHours
+	}
+
+	if c == 0 {
+		return pd.NetworkingKilloWattHours
+	}
+	return c
 }
@@ -64,10 +64,47 @@ type Instance struct {
 	// The embodied emissions for the service
 	EmbodiedEmissions ResourceEmissions
 
+	// The embodied emissions split into the hardware components
+	// they are attributed to, adding up to EmbodiedEmissions
+	EmbodiedBreakdown EmbodiedBreakdown
+
 	// Labels associated with the service
 	Labels Labels
 }
 
+// EmbodiedBreakdown are the embodied emissions per hardware component,
+// each amortized by the share of the component the instance reserves
+type EmbodiedBreakdown struct {
+	// The base platform and CPUs
+	CPU ResourceEmissions
+
+	// The additional memory
+	Memory ResourceEmissions
+
+	// The local storage drives
+	Storage ResourceEmissions
+
+	// The accelerators
+	GPU ResourceEmissions
+}
+
+// Total returns the sum of the embodied emissions of all components
+func (e EmbodiedBreakdown) Total() float64 {
+	return e.CPU.Value + e.Memory.Value + e.Storage.Value + e.GPU.Value
+}
+
+// TotalEmissions returns the sum of the embodied emissions of all
+// components including their bounds
+func (e EmbodiedBreakdown) TotalEmissions() ResourceEmissions {
+	total := ResourceEmissions{Unit: e.CPU.Unit}
+	for _, c := range []ResourceEmissions{e.CPU, e.Memory, e.Storage, e.GPU} {
+		total.Value += c.Value
+		total.Low += c.Low
+		total.High += c.High
+	}
+	return total
+}
+
 // Create a new instance.
 // We need both the name and the provider
 func NewInstance(name string, provider Provider) *Instance {
@@ -86,6 +123,18 @@ func NewInstance(name string, provider Provider) *Instance {
 	}
 }
 
+// Snapshot returns a copy of the instance without metrics, so the metrics
+// of a past window can be added without changing the instance
+func (i *Instance) Snapshot() *Instance {
+	s := *i
+	s.Metrics = Metrics{}
+	s.Labels = make(Labels, len(i.Labels))
+	for k, v := range i.Labels {
+		s.Labels[k] = v
+	}
+	return &s
+}
+
 func (i *Instance) PrintPretty(ctx context.Context) {
 	logger := log.FromContext(ctx)
 
@@ -25,3 +25,39 @@ func (l *Labels) Delete(key string) {
 	// Delete it
 	delete(*l, key)
 }
+
+const (
+	// DiskMediaLabel is the metric label used to specify the media of
+	// the disk a storage metric was collected for
+	DiskMediaLabel = "disk_media"
+
+	// DiskMediaHDD is used for hard disk drives
+	DiskMediaHDD = "hdd"
+
+	// DiskMediaSSD is used for solid state drives
+	DiskMediaSSD = "ssd"
+)
+
+const (
+	// NetworkScopeLabel is the metric label used to specify where the data
+	// of a network metric was transferred to
+	NetworkScopeLabel = "network_scope"
+
+	// NetworkScopeIntraZone is used for traffic within the same zone
+	NetworkScopeIntraZone = "intra-zone"
+
+	// NetworkScopeInterRegion is used for traffic between regions
+	NetworkScopeInterRegion = "inter-region"
+
+	// NetworkScopeInternet is used for egress traffic to the internet
+	NetworkScopeInternet = "internet"
+)
+
+// GPUModelLabel is the metric label used to specify the accelerator model
+// of a GPU metric, for example: nvidia-tesla-a100
+const GPUModelLabel = "gpu_model"
+
+// DatacenterLabel is the instance label used to specify the datacenter,
+// or the room within it, an instance runs in. It is used to look up
+// the PUE of the datacenter and takes precedence over the zone
+const DatacenterLabel = "datacenter"
@@ -19,6 +19,7 @@ type Metric struct {
 	// - Memory
 	// - Storage
 	// - Network
+	// - GPU
 	ResourceType ResourceType
 
 	// The resource usage in percentage
@@ -33,6 +34,7 @@ type Metric struct {
 	// The unit type representing this resource
 	// Examples:
 	// - vCPUs: in case of a CPU
+	// - GPUs: in case of a GPU
 	// - Gb: in case of a Disk
 	// - Gb: in case of Ram
 	Unit ResourceUnit
@@ -43,9 +45,21 @@ type Metric struct {
 	// the Emissions data
 	Energy float64
 
+	// Set by sources that report the energy consumption measured by
+	// the hardware (Kepler, RAPL, Redfish). The energy is then used
+	// as is and only converted into emissions
+	MeasuredEnergy bool
+
+	// The Power Usage Effectiveness the energy
+	// was multiplied by to get the Emissions
+	PUE float64
+
 	// Emissions at a specific point in time
 	Emissions ResourceEmissions
 
+	// How the emissions were calculated
+	Provenance Provenance
+
 	// Time of update
 	UpdatedAt time.Time
 
@@ -0,0 +1,51 @@
+package v1
+
+// Flags marking the quality of the data a metric was calculated with
+const (
+	// The energy was estimated by an energy model instead of measured
+	FlagEstimated = "estimated"
+
+	// The CPU wattage curve was made up of the min and max wattage of
+	// the machine specs, as the dataset has no curve for the instance
+	FlagFallbackWattage = "fallback_wattage"
+
+	// The vCPU count was taken from the source, as the dataset has
+	// no vCPU count for the instance
+	FlagMissingVCPU = "missing_vcpu"
+
+	// The source did not collect any usage for the metric
+	FlagMissingUsage = "missing_usage"
+
+	// The instance kind is not in the datasets, so its factors were
+	// approximated from a sibling in the same family or the defaults
+	FlagApproximated = "approximated"
+)
+
+// Provenance records how the emissions of a metric were calculated,
+// so that low quality numbers can be filtered out
+type Provenance struct {
+	// The energy model used to estimate the energy
+	EnergyModel string
+
+	// The version (commit) of the emission factors dataset
+	FactorsVersion string
+
+	// Where the grid intensity came from: static, file or http
+	GridSource string
+
+	// The level the PUE was resolved at: zone, region or provider
+	PUESource string
+
+	// The data quality flags
+	Flags []string
+}
+
+// HasFlag returns true when the provenance has the flag set
+func (p Provenance) HasFlag(flag string) bool {
+	for _, f := range p.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenanceHasFlag(t *testing.T) {
+	p := Provenance{Flags: []string{FlagEstimated, FlagMissingUsage}}
+
+	assert.True(t, p.HasFlag(FlagEstimated))
+	assert.True(t, p.HasFlag(FlagMissingUsage))
+	assert.False(t, p.HasFlag(FlagFallbackWattage))
+	assert.False(t, Provenance{}.HasFlag(FlagEstimated))
+}
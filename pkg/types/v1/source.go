@@ -1,6 +1,10 @@
 package v1
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // Source is an interface for fetching metrics that can be calculated
 type Source interface {
@@ -11,3 +15,38 @@ type Source interface {
 	// that have metrics attached to them mainly cpu, memory, storage and network
 	Fetch(context.Context) ([]*Instance, error)
 }
+
+// ScheduledSource is a source with its own scraping interval, the metrics
+// it fetches are averaged over the interval. Sources that do not implement
+// it are scraped at the interval of all providers.
+type ScheduledSource interface {
+	Source
+
+	// Interval returns how often the source is scraped
+	Interval() time.Duration
+}
+
+// NamedSource is a source with a name, which is used in the logs, the
+// health of the sources and the metadata of the collected metrics
+type NamedSource interface {
+	Source
+
+	// Name returns the name of the source, like gcp/my-project
+	Name() string
+}
+
+// ErrRangeNotSupported is returned by sources that cannot fetch the
+// metrics of past windows
+var ErrRangeNotSupported = errors.New("source does not support fetching ranges")
+
+// RangeSource is a source that fetches the metrics of past windows, so the
+// emissions from before Aether was deployed can be backfilled
+type RangeSource interface {
+	Source
+
+	// FetchRange returns the instances with the metrics of every step
+	// between start and end, an instance is returned once per step with the
+	// metrics averaged over the step. The metrics are updated at the end of
+	// their step.
+	FetchRange(ctx context.Context, start, end time.Time, step time.Duration) ([]*Instance, error)
+}
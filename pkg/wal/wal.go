@@ -0,0 +1,607 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// the extension of the segment files, which are
+	// named after the offset of their first record
+	segmentExt = ".wal"
+
+	// the file with the offset of the first record
+	// that has not been acknowledged
+	checkpointFile = "checkpoint"
+
+	// the length and checksum of a record
+	frameSize = 8
+
+	// the offset and time of a record
+	headerSize = 16
+
+	// records larger than this are treated as corrupted
+	maxRecordSize = 64 << 20
+)
+
+var (
+	// the default size after which a new segment is started
+	defaultSegmentSize int64 = 64 << 20
+
+	// the default amount of time the segments are kept
+	defaultRetention = 24 * time.Hour
+
+	// the default interval in which the log is synced to disk
+	defaultSyncInterval = time.Second
+)
+
+var (
+	// ErrCorrupted is returned when a record does not match its checksum
+	// or is cut off
+	ErrCorrupted = errors.New("wal record is corrupted")
+
+	// ErrClosed is returned when appending to a closed log
+	ErrClosed = errors.New("wal is closed")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Record is an entry of the log
+type Record struct {
+	// The position of the record in the log, starting at 0
+	Offset uint64
+
+	// When the record was written
+	Time time.Time
+
+	Data []byte
+}
+
+// segment is a file of the log
+type segment struct {
+	path string
+
+	// the offset of the first record and
+	// the offset after the last record
+	first uint64
+	next  uint64
+
+	// the time of the last record
+	last time.Time
+	size int64
+}
+
+// Log is a write-ahead log stored in segment files in a directory. The
+// records are checksummed, so records cut off by a crash are detected and
+// dropped on Open. Records are acknowledged once they are processed, and
+// segments are removed after the retention. Records that are never
+// acknowledged, e.g. because their processing failed, are dropped with
+// their segment, so they do not keep the log from being cleaned up.
+type Log struct {
+	dir          string
+	segmentSize  int64
+	retention    time.Duration
+	syncInterval time.Duration
+
+	segments []*segment
+	file     *os.File
+	next     uint64
+
+	// all records before the committed offset are acknowledged,
+	// acked holds the acknowledged records after it
+	committed uint64
+	acked     map[uint64]bool
+
+	// the committed offset written to the checkpoint
+	checkpoint uint64
+
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+}
+
+type option func(*Log)
+
+// WithSegmentSize sets the size in bytes after which a new segment file
+// is started
+func WithSegmentSize(size int64) option {
+	return func(l *Log) {
+		l.segmentSize = size
+	}
+}
+
+// WithRetention sets how long segments are kept after their last record,
+// so they can be replayed. The records that are not acknowledged by then
+// are dropped.
+func WithRetention(d time.Duration) option {
+	return func(l *Log) {
+		l.retention = d
+	}
+}
+
+// WithSyncInterval sets how often the log and the acknowledged offset are
+// synced to disk, a crash loses the records of the last interval
+func WithSyncInterval(d time.Duration) option {
+	return func(l *Log) {
+		l.syncInterval = d
+	}
+}
+
+// Open opens the log in the directory, which is created if it does not
+// exist. Records that are cut off at the end of the log are dropped.
+func Open(dir string, opts ...option) (*Log, error) {
+	l := &Log{
+		dir:          dir,
+		segmentSize:  defaultSegmentSize,
+		retention:    defaultRetention,
+		syncInterval: defaultSyncInterval,
+		acked:        make(map[uint64]bool),
+		done:         make(chan struct{}),
+	}
+
+	for _, o := range opts {
+		o(l)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("error creating wal directory: %w", err)
+	}
+
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+
+	if err := l.openActive(); err != nil {
+		return nil, err
+	}
+
+	if l.syncInterval > 0 {
+		l.wg.Add(1)
+		go l.syncLoop()
+	}
+
+	return l, nil
+}
+
+// load reads the segments and the checkpoint in the directory
+func (l *Log) load() error {
+	paths, err := filepath.Glob(filepath.Join(l.dir, "*"+segmentExt))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for i, path := range paths {
+		first, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), segmentExt), 10, 64)
+		if err != nil {
+			return fmt.Errorf("error invalid wal segment name: %s", path)
+		}
+
+		s := &segment{path: path, first: first, next: first}
+		end, err := readSegment(path, func(r Record) error {
+			s.next = r.Offset + 1
+			s.last = r.Time
+			return nil
+		})
+		s.size = end
+
+		// a record cut off at the end of the log was not completely
+		// written before a crash, so it is dropped
+		if errors.Is(err, ErrCorrupted) && i == len(paths)-1 {
+			if err := os.Truncate(path, end); err != nil {
+				return fmt.Errorf("error truncating wal segment: %w", err)
+			}
+		} else if err != nil && !errors.Is(err, ErrCorrupted) {
+			return err
+		}
+
+		l.segments = append(l.segments, s)
+		l.next = s.next
+	}
+
+	committed, err := l.readCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	if len(l.segments) > 0 && committed < l.segments[0].first {
+		committed = l.segments[0].first
+	}
+	if committed > l.next {
+		committed = l.next
+	}
+	l.committed = committed
+	l.checkpoint = committed
+
+	return nil
+}
+
+// openActive opens the last segment for appending,
+// or starts a new one when it is full
+func (l *Log) openActive() error {
+	if n := len(l.segments); n > 0 && l.segments[n-1].size < l.segmentSize {
+		f, err := os.OpenFile(l.segments[n-1].path, os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			return fmt.Errorf("error opening wal segment: %w", err)
+		}
+		l.file = f
+		return nil
+	}
+
+	return l.rotate()
+}
+
+// rotate closes the active segment and starts a new one
+func (l *Log) rotate() error {
+	if l.file != nil {
+		if err := l.file.Sync(); err != nil {
+			return err
+		}
+		if err := l.file.Close(); err != nil {
+			return err
+		}
+	}
+
+	path := filepath.Join(l.dir, fmt.Sprintf("%020d%s", l.next, segmentExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("error creating wal segment: %w", err)
+	}
+
+	l.file = f
+	l.segments = append(l.segments, &segment{path: path, first: l.next, next: l.next})
+	return nil
+}
+
+// Append writes the data to the log and returns its offset. The record
+// is on disk once the log is synced.
+func (l *Log) Append(t time.Time, data []byte) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return 0, ErrClosed
+	}
+
+	active := l.segments[len(l.segments)-1]
+	if active.size >= l.segmentSize && active.next > active.first {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+		active = l.segments[len(l.segments)-1]
+	}
+
+	offset := l.next
+	frame := encode(Record{Offset: offset, Time: t, Data: data})
+
+	if _, err := l.file.Write(frame); err != nil {
+		// remove the partial record, so the next records can be read
+		_ = l.file.Truncate(active.size)
+		return 0, fmt.Errorf("error writing to wal: %w", err)
+	}
+
+	active.size += int64(len(frame))
+	active.next = offset + 1
+	active.last = t
+	l.next = offset + 1
+
+	return offset, nil
+}
+
+// Ack acknowledges that the record at the offset has been processed, so
+// it is no longer pending once the log has been synced
+func (l *Log) Ack(offset uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if offset < l.committed || offset >= l.next {
+		return
+	}
+
+	l.acked[offset] = true
+	for l.acked[l.committed] {
+		delete(l.acked, l.committed)
+		l.committed++
+	}
+}
+
+// Committed returns the offset of the first record that has not been
+// acknowledged, all records before it are acknowledged
+func (l *Log) Committed() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.committed
+}
+
+// Next returns the offset of the next record that is appended
+func (l *Log) Next() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.next
+}
+
+// Read calls the function with the records from the offset up to, but
+// excluding, the end offset. The rest of a segment with a corrupted record
+// is skipped and the corruption is returned once all segments are read.
+// An error returned by the function stops reading.
+func (l *Log) Read(from, to uint64, fn func(Record) error) error {
+	l.mu.Lock()
+	segments := make([]segment, len(l.segments))
+	for i, s := range l.segments {
+		segments[i] = *s
+	}
+	if to > l.next {
+		to = l.next
+	}
+	l.mu.Unlock()
+
+	errStop := errors.New("stop")
+
+	var errs []error
+	for _, s := range segments {
+		if s.next <= from || s.first >= to {
+			continue
+		}
+
+		var fnErr error
+		_, err := readSegment(s.path, func(r Record) error {
+			if r.Offset < from {
+				return nil
+			}
+			if r.Offset >= to {
+				return errStop
+			}
+			if err := fn(r); err != nil {
+				fnErr = err
+				return errStop
+			}
+			return nil
+		})
+
+		switch {
+		case fnErr != nil:
+			return fnErr
+		case errors.Is(err, errStop), errors.Is(err, os.ErrNotExist):
+			// the segment was removed by the retention in the meantime
+		case err != nil:
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ReadAll calls the function with all records in the log
+func (l *Log) ReadAll(fn func(Record) error) error {
+	return l.Read(0, math.MaxUint64, fn)
+}
+
+// Sync writes the log and the acknowledged offset to disk, and removes the
+// segments that are acknowledged and older than the retention
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+
+	return l.sync()
+}
+
+func (l *Log) sync() error {
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("error syncing wal: %w", err)
+	}
+
+	l.dropExpired()
+
+	if l.committed != l.checkpoint {
+		if err := l.writeCheckpoint(l.committed); err != nil {
+			return err
+		}
+		l.checkpoint = l.committed
+	}
+
+	return l.removeExpired()
+}
+
+// dropExpired moves the committed offset past the records of the segments
+// older than the retention, the records that are not acknowledged by then
+// are dropped
+func (l *Log) dropExpired() {
+	var end uint64
+	for _, s := range l.segments[:len(l.segments)-1] {
+		if time.Since(s.last) < l.retention {
+			break
+		}
+		end = s.next
+	}
+
+	if end <= l.committed {
+		return
+	}
+
+	for offset := range l.acked {
+		if offset < end {
+			delete(l.acked, offset)
+		}
+	}
+
+	l.committed = end
+	for l.acked[l.committed] {
+		delete(l.acked, l.committed)
+		l.committed++
+	}
+}
+
+// removeExpired removes the oldest segments once they are older than the
+// retention and the checkpoint is past their records, the active segment
+// is kept
+func (l *Log) removeExpired() error {
+	for len(l.segments) > 1 {
+		s := l.segments[0]
+		if s.next > l.checkpoint || time.Since(s.last) < l.retention {
+			return nil
+		}
+
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error removing wal segment: %w", err)
+		}
+		l.segments = l.segments[1:]
+	}
+	return nil
+}
+
+func (l *Log) syncLoop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// errors are retried on the next tick
+			_ = l.Sync()
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// Close syncs the log and closes the active segment
+func (l *Log) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	close(l.done)
+	l.mu.Unlock()
+
+	l.wg.Wait()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	return errors.Join(l.sync(), l.file.Close())
+}
+
+func (l *Log) readCheckpoint() (uint64, error) {
+	b, err := os.ReadFile(filepath.Join(l.dir, checkpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading wal checkpoint: %w", err)
+	}
+
+	offset, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing wal checkpoint: %w", err)
+	}
+	return offset, nil
+}
+
+// writeCheckpoint replaces the checkpoint, so it is never partially written
+func (l *Log) writeCheckpoint(offset uint64) error {
+	path := filepath.Join(l.dir, checkpointFile)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("error writing wal checkpoint: %w", err)
+	}
+
+	_, err = fmt.Fprintf(f, "%d\n", offset)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err := errors.Join(err, f.Close()); err != nil {
+		return fmt.Errorf("error writing wal checkpoint: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// encode returns the record as a frame: the length and checksum of the
+// record, followed by its offset, time and data
+func encode(r Record) []byte {
+	frame := make([]byte, frameSize+headerSize+len(r.Data))
+	body := frame[frameSize:]
+
+	binary.BigEndian.PutUint64(body[0:], r.Offset)
+	binary.BigEndian.PutUint64(body[8:], uint64(r.Time.UnixNano()))
+	copy(body[headerSize:], r.Data)
+
+	binary.BigEndian.PutUint32(frame[0:], uint32(len(body)))
+	binary.BigEndian.PutUint32(frame[4:], crc32.Checksum(body, crcTable))
+	return frame
+}
+
+// readSegment calls the function with the records of the segment file,
+// and returns the position after the last valid record
+func readSegment(path string, fn func(Record) error) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	frame := make([]byte, frameSize)
+
+	var pos int64
+	for {
+		if _, err := io.ReadFull(r, frame); err != nil {
+			if errors.Is(err, io.EOF) {
+				return pos, nil
+			}
+			return pos, corrupted(path, pos, err)
+		}
+
+		length := binary.BigEndian.Uint32(frame[0:])
+		checksum := binary.BigEndian.Uint32(frame[4:])
+		if length < headerSize || length > maxRecordSize {
+			return pos, corrupted(path, pos, fmt.Errorf("invalid length %d", length))
+		}
+
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return pos, corrupted(path, pos, err)
+		}
+		if crc32.Checksum(body, crcTable) != checksum {
+			return pos, corrupted(path, pos, errors.New("checksum mismatch"))
+		}
+
+		record := Record{
+			Offset: binary.BigEndian.Uint64(body[0:]),
+			Time:   time.Unix(0, int64(binary.BigEndian.Uint64(body[8:]))),
+			Data:   body[headerSize:],
+		}
+		if err := fn(record); err != nil {
+			return pos, err
+		}
+
+		pos += int64(frameSize + length)
+	}
+}
+
+func corrupted(path string, pos int64, err error) error {
+	return fmt.Errorf("%w: %s at %d: %v", ErrCorrupted, filepath.Base(path), pos, err)
+}
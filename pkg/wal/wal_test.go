@@ -0,0 +1,214 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// records returns the data of the records in the log from the offset
+func records(t *testing.T, l *Log, from uint64) []string {
+	var data []string
+	err := l.Read(from, l.Next(), func(r Record) error {
+		data = append(data, string(r.Data))
+		return nil
+	})
+	assert.NoError(t, err)
+	return data
+}
+
+func TestAppendAndRead(t *testing.T) {
+	dir := t.TempDir()
+
+	// a small segment size, so each record is in its own segment
+	l, err := Open(dir, WithSegmentSize(1), WithSyncInterval(0))
+	assert.NoError(t, err)
+
+	now := time.Now()
+	for i, data := range []string{"a", "b", "c"} {
+		offset, err := l.Append(now, []byte(data))
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(i), offset)
+	}
+
+	assert.Equal(t, []string{"a", "b", "c"}, records(t, l, 0))
+	assert.Equal(t, []string{"b", "c"}, records(t, l, 1))
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	assert.Len(t, segments, 3)
+
+	assert.NoError(t, l.Close())
+	_, err = l.Append(now, []byte("d"))
+	assert.ErrorIs(t, err, ErrClosed)
+
+	// the records are read back after reopening
+	l, err = Open(dir, WithSegmentSize(1), WithSyncInterval(0))
+	assert.NoError(t, err)
+	defer l.Close()
+
+	assert.Equal(t, uint64(3), l.Next())
+	assert.Equal(t, []string{"a", "b", "c"}, records(t, l, 0))
+
+	var r Record
+	assert.NoError(t, l.ReadAll(func(record Record) error {
+		r = record
+		return nil
+	}))
+	assert.Equal(t, uint64(2), r.Offset)
+	assert.Equal(t, now.UnixNano(), r.Time.UnixNano())
+}
+
+func TestAck(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, WithSyncInterval(0))
+	assert.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, err := l.Append(time.Now(), []byte{byte(i)})
+		assert.NoError(t, err)
+	}
+
+	// the committed offset only moves over consecutive acknowledgements
+	l.Ack(1)
+	assert.Equal(t, uint64(0), l.Committed())
+	l.Ack(0)
+	assert.Equal(t, uint64(2), l.Committed())
+	l.Ack(10)
+	assert.Equal(t, uint64(2), l.Committed())
+
+	assert.NoError(t, l.Close())
+
+	l, err = Open(dir, WithSyncInterval(0))
+	assert.NoError(t, err)
+	defer l.Close()
+
+	assert.Equal(t, uint64(2), l.Committed())
+	assert.Len(t, records(t, l, l.Committed()), 2)
+}
+
+func TestTornRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, WithSyncInterval(0))
+	assert.NoError(t, err)
+	_, err = l.Append(time.Now(), []byte("complete"))
+	assert.NoError(t, err)
+	_, err = l.Append(time.Now(), []byte("cut off"))
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+
+	// cut off the last record, like a crash while writing
+	path := filepath.Join(dir, "00000000000000000000"+segmentExt)
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Truncate(path, info.Size()-3))
+
+	l, err = Open(dir, WithSyncInterval(0))
+	assert.NoError(t, err)
+	defer l.Close()
+
+	assert.Equal(t, []string{"complete"}, records(t, l, 0))
+
+	// the next record is written after the last complete one
+	offset, err := l.Append(time.Now(), []byte("next"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), offset)
+	assert.Equal(t, []string{"complete", "next"}, records(t, l, 0))
+}
+
+func TestCorruptedRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, WithSegmentSize(1), WithSyncInterval(0))
+	assert.NoError(t, err)
+	for _, data := range []string{"a", "b", "c"} {
+		_, err := l.Append(time.Now(), []byte(data))
+		assert.NoError(t, err)
+	}
+
+	// flip the data of the second record
+	path := filepath.Join(dir, "00000000000000000001"+segmentExt)
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	b[len(b)-1] ^= 0xff
+	assert.NoError(t, os.WriteFile(path, b, 0o640))
+
+	var data []string
+	err = l.ReadAll(func(r Record) error {
+		data = append(data, string(r.Data))
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrCorrupted)
+	assert.Equal(t, []string{"a", "c"}, data)
+	assert.NoError(t, l.Close())
+}
+
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, WithSegmentSize(1), WithRetention(time.Hour), WithSyncInterval(0))
+	assert.NoError(t, err)
+	defer l.Close()
+
+	old := time.Now().Add(-2 * time.Hour)
+	for i := 0; i < 2; i++ {
+		_, err := l.Append(old, []byte{byte(i)})
+		assert.NoError(t, err)
+	}
+	for i := 2; i < 4; i++ {
+		_, err := l.Append(time.Now(), []byte{byte(i)})
+		assert.NoError(t, err)
+	}
+
+	// acknowledged segments are kept until they are older than the retention
+	l.Ack(0)
+	l.Ack(1)
+	l.Ack(2)
+	assert.NoError(t, l.Sync())
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	assert.Len(t, segments, 2)
+	assert.Len(t, records(t, l, 2), 2)
+	assert.Equal(t, uint64(3), l.Committed())
+}
+
+func TestRetentionUnacknowledged(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, WithSegmentSize(1), WithRetention(time.Hour), WithSyncInterval(0))
+	assert.NoError(t, err)
+
+	old := time.Now().Add(-2 * time.Hour)
+	for i := 0; i < 3; i++ {
+		_, err := l.Append(old, []byte{byte(i)})
+		assert.NoError(t, err)
+	}
+	_, err = l.Append(time.Now(), []byte{3})
+	assert.NoError(t, err)
+
+	// the processing of the record at offset 1 failed, so it is never
+	// acknowledged and keeps the committed offset from moving
+	l.Ack(0)
+	l.Ack(2)
+	l.Ack(3)
+	assert.Equal(t, uint64(1), l.Committed())
+
+	// the record is dropped with its segment after the retention
+	assert.NoError(t, l.Sync())
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	assert.Len(t, segments, 1)
+	assert.Equal(t, uint64(4), l.Committed())
+	assert.Empty(t, l.acked)
+	assert.NoError(t, l.Close())
+
+	l, err = Open(dir, WithSyncInterval(0))
+	assert.NoError(t, err)
+	defer l.Close()
+
+	assert.Equal(t, uint64(4), l.Committed())
+	assert.Empty(t, records(t, l, l.Committed()))
+}